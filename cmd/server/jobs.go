@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	app "review-assigner/internal/app"
+	"review-assigner/internal/email"
+)
+
+func verifyReviewerStorageJob(service *app.Service, interval time.Duration) app.Job {
+	return app.Job{Name: "reviewer_storage_verify", Interval: interval, Run: func(ctx context.Context) error {
+		report, err := service.VerifyReviewerStorage(ctx, false)
+		if err != nil {
+			return err
+		}
+		if len(report.Mismatches) > 0 {
+			slog.Warn("reviewer storage: pull requests differ between array and table",
+				"mismatches", len(report.Mismatches), "checked", report.Checked)
+		}
+		return nil
+	}}
+}
+
+func archiveJob(service *app.Service, retention, interval time.Duration) app.Job {
+	return app.Job{Name: "archive_merged", Interval: interval, Run: func(ctx context.Context) error {
+		run, err := service.ArchiveMergedPullRequests(ctx, retention)
+		if err != nil {
+			return err
+		}
+		if run.Archived > 0 {
+			slog.Info("archived merged pull requests", "archived", run.Archived, "merged_before", run.Cutoff)
+		}
+		return nil
+	}}
+}
+
+func auditPurgeJob(service *app.Service, retention, interval time.Duration) app.Job {
+	return app.Job{Name: "audit_purge", Interval: interval, Run: func(ctx context.Context) error {
+		purged, err := service.PurgeAuditLog(ctx, retention)
+		if err != nil {
+			return err
+		}
+		if purged > 0 {
+			slog.Info("purged audit log entries", "purged", purged, "retention", retention)
+		}
+		return nil
+	}}
+}
+
+func statsSnapshotJob(service *app.Service, interval time.Duration) app.Job {
+	return app.Job{Name: "stats_snapshot", Interval: interval, Run: func(ctx context.Context) error {
+		_, err := service.SnapshotStats(ctx, time.Now())
+		return err
+	}}
+}
+
+func outboxDispatchJob(service *app.Service, interval time.Duration) app.Job {
+	return app.Job{Name: "outbox_dispatch", Interval: interval, Run: func(ctx context.Context) error {
+		run, err := service.DispatchOutbox(ctx)
+		if err != nil {
+			return err
+		}
+		if run.Failed > 0 || run.DeadLettered > 0 {
+			slog.Warn("outbox dispatch had failures",
+				"delivered", run.Delivered, "failed", run.Failed, "dead_lettered", run.DeadLettered)
+		}
+		return nil
+	}}
+}
+
+// reviewDigestsJob e-mails the digests; a failed e-mail does not stop the
+// others, and the run reports how many failed.
+func reviewDigestsJob(service *app.Service, mailer *email.Notifier, interval time.Duration) app.Job {
+	return app.Job{Name: "review_digests", Interval: interval, Run: func(ctx context.Context) error {
+		digests, err := service.GetReviewDigests(ctx, time.Now())
+		if err != nil {
+			return err
+		}
+		failed := 0
+		for _, d := range digests {
+			if err := mailer.SendDigest(d); err != nil {
+				slog.Error("send review digest", "user_id", d.User.ID, "error", err)
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d digests failed", failed, len(digests))
+		}
+		return nil
+	}}
+}
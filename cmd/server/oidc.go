@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"review-assigner/internal/config"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+)
+
+// oidcDiscoveryTimeout bounds the request for the provider's configuration
+// at startup.
+const oidcDiscoveryTimeout = 30 * time.Second
+
+// newOIDCVerifier creates the verifier of Bearer tokens. The provider's
+// keys are fetched on first use and again when a token is signed by an
+// unknown key, so key rotation needs no restart.
+func newOIDCVerifier(ctx context.Context, cfg config.Config) (*oidc.IDTokenVerifier, error) {
+	verifierConfig := &oidc.Config{ClientID: cfg.OIDCAudience}
+	if cfg.OIDCJWKSURL != "" {
+		// The request context only configures the key requests, it does
+		// not bound them.
+		keys := oidc.NewRemoteKeySet(ctx, cfg.OIDCJWKSURL)
+		return oidc.NewVerifier(cfg.OIDCIssuerURL, keys, verifierConfig), nil
+	}
+
+	discoveryCtx, cancel := context.WithTimeout(ctx, oidcDiscoveryTimeout)
+	defer cancel()
+	provider, err := oidc.NewProvider(discoveryCtx, cfg.OIDCIssuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("discover OIDC provider: %w", err)
+	}
+	return provider.Verifier(verifierConfig), nil
+}
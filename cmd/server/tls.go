@@ -0,0 +1,77 @@
+package main
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"review-assigner/internal/config"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// serveHTTPS reports whether the server is configured to serve HTTPS itself.
+func serveHTTPS(cfg config.Config) bool {
+	return cfg.TLSCertFile != "" || len(cfg.ACMEHosts) > 0
+}
+
+// configureTLS sets up HTTPS on server with the certificate from files or
+// issued by Let's Encrypt for the ACME hosts, and returns the plain HTTP
+// server listening on the redirect address, nil when it is disabled. It
+// redirects to HTTPS and answers ACME HTTP-01 challenges.
+func configureTLS(server *http.Server, cfg config.Config) *http.Server {
+	redirect := redirectToHTTPS(server.Addr)
+	if len(cfg.ACMEHosts) > 0 {
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			Cache:      autocert.DirCache(cfg.ACMECacheDir),
+			HostPolicy: autocert.HostWhitelist(cfg.ACMEHosts...),
+			Email:      cfg.ACMEEmail,
+		}
+		server.TLSConfig = m.TLSConfig()
+		redirect = m.HTTPHandler(redirect)
+	} else {
+		server.TLSConfig = &tls.Config{}
+	}
+	server.TLSConfig.MinVersion = tls.VersionTLS12
+
+	if cfg.HTTPRedirectAddr == "" {
+		return nil
+	}
+	return &http.Server{
+		Addr:         cfg.HTTPRedirectAddr,
+		Handler:      redirect,
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 10 * time.Second,
+		IdleTimeout:  60 * time.Second,
+		ErrorLog:     server.ErrorLog,
+	}
+}
+
+// listenAndServe serves HTTPS when it is configured and plain HTTP otherwise.
+func listenAndServe(server *http.Server, cfg config.Config) error {
+	if !serveHTTPS(cfg) {
+		return server.ListenAndServe()
+	}
+	// ACME certificates come from TLSConfig.GetCertificate.
+	return server.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+}
+
+// redirectToHTTPS redirects requests to the same host and path on the HTTPS
+// address. 308 keeps the method and body of API calls.
+func redirectToHTTPS(httpsAddr string) http.Handler {
+	_, port, err := net.SplitHostPort(httpsAddr)
+	if err != nil || port == "443" {
+		port = ""
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
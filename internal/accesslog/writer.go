@@ -0,0 +1,162 @@
+// Package accesslog writes the request audit trail to a file that is
+// rotated by size and age.
+package accesslog
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeLayout names rotated files, e.g.
+// access.log.20260102T150405.000000000Z.
+const backupTimeLayout = "20060102T150405.000000000Z"
+
+// Options control rotation. Zero values disable the respective limit.
+type Options struct {
+	// MaxSize rotates the file before a write would make it larger, in bytes.
+	MaxSize int64
+	// MaxAge rotates the file once it has been written to for this long.
+	MaxAge time.Duration
+	// MaxBackups is the number of rotated files kept; older ones are removed.
+	MaxBackups int
+}
+
+// Writer appends to a log file and rotates it. Rotated files get the
+// rotation time as a suffix. It is safe for concurrent use.
+type Writer struct {
+	path string
+	opts Options
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+}
+
+// Open opens or creates the file at path for appending. An existing file
+// continues until it reaches the limits.
+func Open(path string, opts Options) (*Writer, error) {
+	w := &Writer{path: path, opts: opts}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write appends p as one unit: a rotation happens before it, never inside.
+// When the rotation fails, p still goes to the current file, the rotation
+// error is returned and the next write tries to rotate again.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return 0, errors.New("accesslog: write to closed file")
+	}
+	var rotateErr error
+	if w.due(int64(len(p))) {
+		rotateErr = w.rotate()
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	if err != nil {
+		return n, err
+	}
+	return n, rotateErr
+}
+
+// Close closes the file. Later writes fail.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// due reports whether the file must be rotated before writing n bytes. An
+// empty file is never rotated, so a line longer than MaxSize still fits.
+func (w *Writer) due(n int64) bool {
+	if w.size == 0 {
+		return false
+	}
+	if w.opts.MaxSize > 0 && w.size+n > w.opts.MaxSize {
+		return true
+	}
+	return w.opts.MaxAge > 0 && time.Since(w.openedAt) >= w.opts.MaxAge
+}
+
+func (w *Writer) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.path), 0o755); err != nil {
+		return fmt.Errorf("accesslog: create directory: %w", err)
+	}
+	f, err := os.OpenFile(w.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o640)
+	if err != nil {
+		return fmt.Errorf("accesslog: open: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("accesslog: stat: %w", err)
+	}
+	w.file = f
+	w.size = info.Size()
+	// File systems do not reliably keep creation times, so the age of a
+	// file reopened after a restart counts from the restart.
+	w.openedAt = time.Now()
+	return nil
+}
+
+// rotate renames the file to a backup and opens a new one at the path. The
+// current file is only replaced once the new one is open, so a failure
+// leaves it in place. A path already renamed by an earlier attempt that
+// failed to reopen it is not renamed again.
+func (w *Writer) rotate() error {
+	backup := w.path + "." + time.Now().UTC().Format(backupTimeLayout)
+	if err := os.Rename(w.path, backup); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("accesslog: rotate: %w", err)
+	}
+	old := w.file
+	if err := w.open(); err != nil {
+		return err
+	}
+	if err := old.Close(); err != nil {
+		return fmt.Errorf("accesslog: close: %w", err)
+	}
+	return w.prune()
+}
+
+// prune removes the oldest rotated files beyond MaxBackups. The fixed-width
+// time suffix makes name order the rotation order.
+func (w *Writer) prune() error {
+	if w.opts.MaxBackups <= 0 {
+		return nil
+	}
+	backups, err := filepath.Glob(w.path + ".*")
+	if err != nil {
+		return fmt.Errorf("accesslog: list backups: %w", err)
+	}
+	backups = slices.DeleteFunc(backups, func(name string) bool {
+		_, err := time.Parse(backupTimeLayout, strings.TrimPrefix(name, w.path+"."))
+		return err != nil
+	})
+	slices.Sort(backups)
+	for len(backups) > w.opts.MaxBackups {
+		if err := os.Remove(backups[0]); err != nil {
+			return fmt.Errorf("accesslog: remove backup: %w", err)
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// MaxClientNameLength limits client names of API keys and X-Client-ID.
+const MaxClientNameLength = 64
+
+// API key scopes. A key with scopes may call the operations of its scopes
+// and the reads of teams, users and pull requests; a key without scopes
+// may call everything, the /admin endpoints included.
+const (
+	ScopeTeamsWrite     = "teams:write"
+	ScopePRsWrite       = "prs:write"
+	ScopeStatsRead      = "stats:read"
+	ScopeWebhooksManage = "webhooks:manage"
+)
+
+// IsValidScope reports whether s is a known API key scope.
+func IsValidScope(s string) bool {
+	switch s {
+	case ScopeTeamsWrite, ScopePRsWrite, ScopeStatsRead, ScopeWebhooksManage:
+		return true
+	}
+	return false
+}
+
+// apiKeyPrefixLength is how much of a key is kept in clear to tell keys apart.
+const apiKeyPrefixLength = 12
+
+// apiKeyUsageResolution limits last_used_at updates to one per key and
+// minute, so that authentication does not write on every request.
+const apiKeyUsageResolution = time.Minute
+
+// APIKey is an issued key without its secret, which is only returned by
+// IssueAPIKey.
+type APIKey struct {
+	ID         int64  `json:"key_id"`
+	ClientName string `json:"client_name"`
+	Prefix     string `json:"key_prefix"`
+	// Scopes limit the key; omitted for keys with full access.
+	Scopes     []string   `json:"scopes,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// IssueAPIKey creates a key for a client and returns it with the key
+// itself, which is stored only as a hash. Nil scopes give the key full
+// access.
+func (s *Service) IssueAPIKey(ctx context.Context, clientName string, scopes []string) (APIKey, string, error) {
+	var fields []FieldError
+	switch {
+	case clientName == "":
+		fields = append(fields, FieldError{Field: "client_name", Message: "client_name is required"})
+	case len(clientName) > MaxClientNameLength:
+		fields = append(fields, FieldError{Field: "client_name",
+			Message: fmt.Sprintf("client_name must be at most %d characters", MaxClientNameLength)})
+	}
+	if scopes != nil && len(scopes) == 0 {
+		fields = append(fields, FieldError{Field: "scopes", Message: "scopes must not be empty; omit them for full access"})
+	}
+	for i, scope := range scopes {
+		if !IsValidScope(scope) {
+			fields = append(fields, FieldError{Field: fmt.Sprintf("scopes[%d]", i),
+				Message: fmt.Sprintf("unknown scope %q", scope)})
+		}
+	}
+	if len(fields) > 0 {
+		return APIKey{}, "", validationError(fields)
+	}
+	var scopesArg any
+	if scopes != nil {
+		scopesArg = pq.Array(scopes)
+	}
+
+	secret, err := randomHex(24)
+	if err != nil {
+		return APIKey{}, "", err
+	}
+	secret = "rak_" + secret
+
+	const query = `
+INSERT INTO api_keys (client_name, key_hash, key_prefix, scopes)
+VALUES ($1, $2, $3, $4)
+RETURNING key_id, client_name, key_prefix, scopes, created_at
+`
+	var key APIKey
+	err = s.db.QueryRowContext(ctx, query, clientName, hashAPIKey(secret), secret[:apiKeyPrefixLength], scopesArg).
+		Scan(&key.ID, &key.ClientName, &key.Prefix, pq.Array(&key.Scopes), &key.CreatedAt)
+	if err != nil {
+		return APIKey{}, "", fmt.Errorf("insert api key: %w", err)
+	}
+	return key, secret, nil
+}
+
+// ListAPIKeys returns all keys, revoked ones included.
+func (s *Service) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	const query = `
+SELECT key_id, client_name, key_prefix, scopes, created_at, last_used_at, revoked_at
+FROM api_keys
+ORDER BY key_id
+`
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	keys := make([]APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("api keys rows: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey makes a key invalid from now on. Revoking a revoked key
+// keeps its original revocation time.
+func (s *Service) RevokeAPIKey(ctx context.Context, id int64) (APIKey, error) {
+	const query = `
+UPDATE api_keys
+SET revoked_at = COALESCE(revoked_at, NOW())
+WHERE key_id = $1
+RETURNING key_id, client_name, key_prefix, scopes, created_at, last_used_at, revoked_at
+`
+	key, err := scanAPIKey(s.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return APIKey{}, &Error{Code: ErrorCodeNotFound, Message: "api key not found"}
+	}
+	return key, err
+}
+
+// AuthenticateAPIKey returns the active key matching secret, or
+// UNAUTHORIZED.
+func (s *Service) AuthenticateAPIKey(ctx context.Context, secret string) (APIKey, error) {
+	const query = `
+SELECT key_id, client_name, key_prefix, scopes, created_at, last_used_at, revoked_at
+FROM api_keys
+WHERE key_hash = $1
+  AND revoked_at IS NULL
+`
+	key, err := scanAPIKey(s.db.QueryRowContext(ctx, query, hashAPIKey(secret)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return APIKey{}, &Error{Code: ErrorCodeUnauthorized, Message: "invalid API key"}
+	}
+	if err != nil {
+		return APIKey{}, err
+	}
+
+	if key.LastUsedAt == nil || time.Since(*key.LastUsedAt) >= apiKeyUsageResolution {
+		const touchQuery = `UPDATE api_keys SET last_used_at = NOW() WHERE key_id = $1`
+		if _, err := s.db.ExecContext(ctx, touchQuery, key.ID); err != nil {
+			return APIKey{}, fmt.Errorf("touch api key: %w", err)
+		}
+	}
+	return key, nil
+}
+
+// hashAPIKey returns the stored form of a key. Keys are random, so a plain
+// SHA-256 is as good as a slow password hash and keeps lookups indexed.
+func hashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func scanAPIKey(row rowScanner) (APIKey, error) {
+	var key APIKey
+	var lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(&key.ID, &key.ClientName, &key.Prefix, pq.Array(&key.Scopes), &key.CreatedAt, &lastUsedAt, &revokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return APIKey{}, err
+	}
+	if err != nil {
+		return APIKey{}, fmt.Errorf("scan api key: %w", err)
+	}
+	key.LastUsedAt = nullTimePtr(lastUsedAt)
+	key.RevokedAt = nullTimePtr(revokedAt)
+	return key, nil
+}
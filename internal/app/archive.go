@@ -0,0 +1,114 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ArchiveRun describes a single archival of merged pull requests.
+type ArchiveRun struct {
+	ID         int64     `json:"run_id"`
+	Cutoff     time.Time `json:"cutoff"`
+	Archived   int       `json:"archived"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// ArchiveStatus summarizes the retention policy and archival progress.
+type ArchiveStatus struct {
+	RetentionDays int         `json:"retention_days"`
+	ArchivedTotal int         `json:"archived_total"`
+	Eligible      int         `json:"eligible"`
+	LastRun       *ArchiveRun `json:"last_run"`
+}
+
+// WithMergedRetention enables archival of pull requests merged longer than d ago.
+func WithMergedRetention(d time.Duration) Option {
+	return func(s *Service) {
+		s.mergedRetention = d
+	}
+}
+
+// MergedRetention returns the configured retention of merged pull requests; zero means disabled.
+func (s *Service) MergedRetention() time.Duration {
+	return s.mergedRetention
+}
+
+// ArchiveMergedPullRequests marks pull requests merged more than olderThan ago
+// as archived. Archived pull requests are hidden from /pullRequest/list and
+// reviewer queues but keep their reviews and history.
+func (s *Service) ArchiveMergedPullRequests(ctx context.Context, olderThan time.Duration) (ArchiveRun, error) {
+	run := ArchiveRun{
+		StartedAt: time.Now(),
+	}
+	run.Cutoff = run.StartedAt.Add(-olderThan)
+
+	const query = `
+WITH archived AS (
+    UPDATE pull_requests
+    SET archived_at = NOW()
+    WHERE status = 'MERGED'
+      AND archived_at IS NULL
+      AND merged_at < $1
+    RETURNING 1
+)
+INSERT INTO archive_runs(cutoff, archived, started_at)
+SELECT $1, COUNT(*), $2
+FROM archived
+RETURNING run_id, archived, finished_at
+`
+	err := s.db.QueryRowContext(ctx, query, run.Cutoff, run.StartedAt).Scan(&run.ID, &run.Archived, &run.FinishedAt)
+	if err != nil {
+		return ArchiveRun{}, fmt.Errorf("archive merged pull requests: %w", err)
+	}
+	if run.Archived > 0 {
+		s.publishQueueChange()
+	}
+	return run, nil
+}
+
+// GetArchiveStatus reports the retention policy, the number of archived and
+// archivable pull requests, and the latest archival run.
+func (s *Service) GetArchiveStatus(ctx context.Context) (ArchiveStatus, error) {
+	status := ArchiveStatus{
+		RetentionDays: int(s.mergedRetention / (24 * time.Hour)),
+	}
+
+	// Without a configured retention nothing becomes eligible automatically.
+	var cutoff *time.Time
+	if s.mergedRetention > 0 {
+		c := time.Now().Add(-s.mergedRetention)
+		cutoff = &c
+	}
+
+	const countQuery = `
+SELECT COUNT(*) FILTER (WHERE archived_at IS NOT NULL),
+       COUNT(*) FILTER (WHERE archived_at IS NULL AND merged_at < $1)
+FROM pull_requests
+WHERE status = 'MERGED'
+`
+	if err := s.db.QueryRowContext(ctx, countQuery, cutoff).Scan(&status.ArchivedTotal, &status.Eligible); err != nil {
+		return status, fmt.Errorf("count archived pull requests: %w", err)
+	}
+
+	const lastRunQuery = `
+SELECT run_id, cutoff, archived, started_at, finished_at
+FROM archive_runs
+ORDER BY run_id DESC
+LIMIT 1
+`
+	var run ArchiveRun
+	err := s.db.QueryRowContext(ctx, lastRunQuery).Scan(&run.ID, &run.Cutoff, &run.Archived, &run.StartedAt, &run.FinishedAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return status, fmt.Errorf("get last archive run: %w", err)
+	default:
+		status.LastRun = &run
+	}
+
+	return status, nil
+}
@@ -0,0 +1,129 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Audit results, filtering entries by their status.
+const (
+	AuditResultSuccess = "success"
+	AuditResultFailure = "failure"
+)
+
+// AuditEntry is a mutating API call. Client is the client name of the API
+// key (or X-Client-ID), UserID the user of a Bearer token. Payload holds a
+// summary of the request body with secrets redacted.
+type AuditEntry struct {
+	ID        int64           `json:"audit_id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Client    string          `json:"client"`
+	UserID    string          `json:"user_id,omitempty"`
+	Method    string          `json:"method"`
+	Endpoint  string          `json:"endpoint"`
+	Path      string          `json:"path"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	Status    int             `json:"status"`
+	ErrorCode string          `json:"error_code,omitempty"`
+	RequestID string          `json:"request_id"`
+}
+
+// AuditFilter narrows the audit log. Actor matches the client or the user;
+// Result is AuditResultSuccess (status below 400) or AuditResultFailure.
+type AuditFilter struct {
+	Actor    string
+	Endpoint string
+	Result   string
+	From     *time.Time
+	To       *time.Time
+}
+
+// RecordAudit appends an entry to the audit log.
+func (s *Service) RecordAudit(ctx context.Context, e AuditEntry) error {
+	const query = `
+INSERT INTO audit_log (client, user_id, method, endpoint, path, payload, status, error_code, request_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+`
+	var payload any
+	if len(e.Payload) > 0 {
+		payload = []byte(e.Payload)
+	}
+	_, err := s.db.ExecContext(ctx, query, e.Client, e.UserID, e.Method, e.Endpoint, e.Path, payload,
+		e.Status, e.ErrorCode, e.RequestID)
+	if err != nil {
+		return fmt.Errorf("insert audit entry: %w", err)
+	}
+	return nil
+}
+
+// ListAudit returns a page of audit entries, newest first, and the cursor
+// key of the next page.
+func (s *Service) ListAudit(ctx context.Context, filter AuditFilter, page Page) ([]AuditEntry, string, error) {
+	switch filter.Result {
+	case "", AuditResultSuccess, AuditResultFailure:
+	default:
+		return nil, "", validationError([]FieldError{{Field: "result",
+			Message: "result must be " + AuditResultSuccess + " or " + AuditResultFailure}})
+	}
+	var after *int64
+	if page.After != "" {
+		id, err := strconv.ParseInt(page.After, 10, 64)
+		if err != nil {
+			return nil, "", &Error{Code: ErrorCodeValidation, Message: "cursor is invalid"}
+		}
+		after = &id
+	}
+
+	const query = `
+SELECT audit_id, created_at, client, user_id, method, endpoint, path, payload, status, error_code, request_id
+FROM audit_log
+WHERE ($1 = '' OR client = $1 OR user_id = $1)
+  AND ($2 = '' OR endpoint = $2)
+  AND ($3 = '' OR (status < 400) = ($3 = 'success'))
+  AND ($4::TIMESTAMPTZ IS NULL OR created_at >= $4)
+  AND ($5::TIMESTAMPTZ IS NULL OR created_at < $5)
+  AND ($6::BIGINT IS NULL OR audit_id < $6)
+ORDER BY audit_id DESC
+LIMIT $7 OFFSET $8
+`
+	rows, err := s.db.QueryContext(ctx, query, filter.Actor, filter.Endpoint, filter.Result, filter.From, filter.To,
+		after, page.fetchLimit(), page.Offset)
+	if err != nil {
+		return nil, "", fmt.Errorf("list audit log: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	entries := make([]AuditEntry, 0)
+	for rows.Next() {
+		var e AuditEntry
+		var payload []byte
+		if err := rows.Scan(&e.ID, &e.CreatedAt, &e.Client, &e.UserID, &e.Method, &e.Endpoint, &e.Path, &payload,
+			&e.Status, &e.ErrorCode, &e.RequestID); err != nil {
+			return nil, "", fmt.Errorf("scan audit entry: %w", err)
+		}
+		e.Payload = payload
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("audit log rows: %w", err)
+	}
+
+	entries, next := cutPage(entries, page, func(e AuditEntry) string { return strconv.FormatInt(e.ID, 10) })
+	return entries, next, nil
+}
+
+// PurgeAuditLog deletes the entries older than retention and returns how
+// many were deleted.
+func (s *Service) PurgeAuditLog(ctx context.Context, retention time.Duration) (int64, error) {
+	const query = `DELETE FROM audit_log WHERE created_at < $1`
+	res, err := s.db.ExecContext(ctx, query, time.Now().Add(-retention))
+	if err != nil {
+		return 0, fmt.Errorf("purge audit log: %w", err)
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,235 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"review-assigner/internal/secretbox"
+)
+
+// Names of the integration credentials that can be stored encrypted
+// instead of passed in the environment.
+const (
+	CredentialSlackBotToken      = "slack_bot_token"
+	CredentialSlackWebhookURL    = "slack_webhook_url"
+	CredentialSlackSigningSecret = "slack_signing_secret"
+	CredentialSMTPPassword       = "smtp_password"
+	CredentialJiraAPIToken       = "jira_api_token"
+	CredentialSCIMToken          = "scim_token"
+	CredentialGitHubPrivateKey   = "github_app_private_key"
+)
+
+// IsValidCredentialName reports whether name is a known credential.
+func IsValidCredentialName(name string) bool {
+	switch name {
+	case CredentialSlackBotToken, CredentialSlackWebhookURL, CredentialSlackSigningSecret,
+		CredentialSMTPPassword, CredentialJiraAPIToken, CredentialSCIMToken, CredentialGitHubPrivateKey:
+		return true
+	}
+	return false
+}
+
+// Credential is a stored credential without its secret, which is never
+// returned by the API.
+type Credential struct {
+	Name      string    `json:"name"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WithCredentialsKey encrypts stored credentials and webhook secrets with
+// box. Without it, credentials cannot be stored and webhook secrets are
+// kept in clear.
+func WithCredentialsKey(box *secretbox.Box) Option {
+	return func(s *Service) {
+		s.credentialsBox = box
+	}
+}
+
+var errCredentialsDisabled = &Error{Code: ErrorCodeValidation, Message: "credentials encryption is not configured"}
+
+// SetCredential encrypts and stores the secret of a credential, replacing
+// the previous one.
+func (s *Service) SetCredential(ctx context.Context, name, secret string) (Credential, error) {
+	if s.credentialsBox == nil {
+		return Credential{}, errCredentialsDisabled
+	}
+	var fields []FieldError
+	if !IsValidCredentialName(name) {
+		fields = append(fields, FieldError{Field: "name", Message: fmt.Sprintf("unknown credential %q", name)})
+	}
+	if secret == "" {
+		fields = append(fields, FieldError{Field: "secret", Message: "secret is required"})
+	}
+	if len(fields) > 0 {
+		return Credential{}, validationError(fields)
+	}
+
+	sealed, err := s.credentialsBox.Seal([]byte(secret), []byte(name))
+	if err != nil {
+		return Credential{}, err
+	}
+	const query = `
+INSERT INTO credentials (name, sealed)
+VALUES ($1, $2)
+ON CONFLICT (name) DO UPDATE SET sealed = EXCLUDED.sealed, updated_at = NOW()
+RETURNING name, updated_at
+`
+	var c Credential
+	if err := s.db.QueryRowContext(ctx, query, name, sealed).Scan(&c.Name, &c.UpdatedAt); err != nil {
+		return Credential{}, fmt.Errorf("store credential: %w", err)
+	}
+	return c, nil
+}
+
+// ListCredentials returns the stored credentials by name.
+func (s *Service) ListCredentials(ctx context.Context) ([]Credential, error) {
+	const query = `SELECT name, updated_at FROM credentials ORDER BY name`
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("list credentials: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	credentials := make([]Credential, 0)
+	for rows.Next() {
+		var c Credential
+		if err := rows.Scan(&c.Name, &c.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan credential: %w", err)
+		}
+		credentials = append(credentials, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("credentials rows: %w", err)
+	}
+	return credentials, nil
+}
+
+// DeleteCredential removes a stored credential, so that the environment
+// variable applies again.
+func (s *Service) DeleteCredential(ctx context.Context, name string) error {
+	const query = `DELETE FROM credentials WHERE name = $1`
+	res, err := s.db.ExecContext(ctx, query, name)
+	if err != nil {
+		return fmt.Errorf("delete credential: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("delete credential: %w", err)
+	} else if n == 0 {
+		return &Error{Code: ErrorCodeNotFound, Message: "credential not found"}
+	}
+	return nil
+}
+
+// LoadCredentials decrypts all stored credentials by name. It runs before
+// the Service exists, as the integrations are built from the credentials.
+func LoadCredentials(ctx context.Context, db *sql.DB, box *secretbox.Box) (map[string]string, error) {
+	const query = `SELECT name, sealed FROM credentials`
+	rows, err := db.QueryContext(ctx, query)
+	if hasPQCode(err, pqUndefinedTable) {
+		// The migration is not applied yet; /readyz reports it.
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load credentials: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	credentials := make(map[string]string)
+	for rows.Next() {
+		var name string
+		var sealed []byte
+		if err := rows.Scan(&name, &sealed); err != nil {
+			return nil, fmt.Errorf("scan credential: %w", err)
+		}
+		value, err := box.Open(sealed, []byte(name))
+		if err != nil {
+			return nil, fmt.Errorf("credential %s: %w", name, err)
+		}
+		credentials[name] = string(value)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("credentials rows: %w", err)
+	}
+	return credentials, nil
+}
+
+// SealWebhookSecrets encrypts the webhook secrets still stored in clear,
+// e.g. those created before the master key was configured, and returns how
+// many it encrypted.
+func (s *Service) SealWebhookSecrets(ctx context.Context) (int, error) {
+	if s.credentialsBox == nil {
+		return 0, errCredentialsDisabled
+	}
+	const selectQuery = `SELECT subscription_id, secret FROM webhook_subscriptions WHERE secret_sealed IS NULL`
+	rows, err := s.db.QueryContext(ctx, selectQuery)
+	if err != nil {
+		return 0, fmt.Errorf("list clear webhook secrets: %w", err)
+	}
+	type clearSecret struct {
+		id     int64
+		secret string
+	}
+	var clear []clearSecret
+	for rows.Next() {
+		var c clearSecret
+		if err := rows.Scan(&c.id, &c.secret); err != nil {
+			_ = rows.Close()
+			return 0, fmt.Errorf("scan webhook secret: %w", err)
+		}
+		clear = append(clear, c)
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return 0, fmt.Errorf("webhook secrets rows: %w", err)
+	}
+	_ = rows.Close()
+
+	const updateQuery = `
+UPDATE webhook_subscriptions
+SET secret = '', secret_sealed = $2
+WHERE subscription_id = $1
+  AND secret_sealed IS NULL
+`
+	for _, c := range clear {
+		sealed, err := s.sealWebhookSecret(c.id, c.secret)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := s.db.ExecContext(ctx, updateQuery, c.id, sealed); err != nil {
+			return 0, fmt.Errorf("seal webhook secret: %w", err)
+		}
+	}
+	return len(clear), nil
+}
+
+// sealWebhookSecret encrypts the secret of a subscription, bound to its ID.
+func (s *Service) sealWebhookSecret(id int64, secret string) ([]byte, error) {
+	return s.credentialsBox.Seal([]byte(secret), webhookSecretLabel(id))
+}
+
+func webhookSecretLabel(id int64) []byte {
+	return []byte(fmt.Sprintf("webhook_subscriptions/%d", id))
+}
+
+// openWebhookSecret returns the secret of a subscription, decrypting it
+// when it is sealed.
+func (s *Service) openWebhookSecret(id int64, secret string, sealed []byte) (string, error) {
+	if sealed == nil {
+		return secret, nil
+	}
+	if s.credentialsBox == nil {
+		return "", errors.New("webhook secret is encrypted but CREDENTIALS_KEY is not configured")
+	}
+	value, err := s.credentialsBox.Open(sealed, webhookSecretLabel(id))
+	if err != nil {
+		return "", fmt.Errorf("webhook subscription %d: %w", id, err)
+	}
+	return string(value), nil
+}
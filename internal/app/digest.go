@@ -0,0 +1,84 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ReviewDigest lists the open reviews waiting for a user, split by whether
+// their deadline has passed.
+type ReviewDigest struct {
+	User    User
+	Reviews []PullRequestShort
+	Overdue []PullRequestShort
+}
+
+// GetPendingReviews returns the open pull requests the user still has to
+// review, in queue order.
+func (s *Service) GetPendingReviews(ctx context.Context, userID string) ([]PullRequestShort, error) {
+	prs, _, err := s.GetUserReviews(ctx, userID, Page{})
+	if err != nil {
+		return nil, err
+	}
+	pending := make([]PullRequestShort, 0, len(prs))
+	for _, pr := range prs {
+		if pr.Status == "OPEN" && pr.ReviewStatus == "PENDING" {
+			pending = append(pending, pr)
+		}
+	}
+	return pending, nil
+}
+
+// GetReviewDigests builds the digests of users who enabled the e-mail digest
+// and have an e-mail address. Users without pending reviews are skipped.
+func (s *Service) GetReviewDigests(ctx context.Context, now time.Time) ([]ReviewDigest, error) {
+	const query = `
+SELECT user_id, username, COALESCE(team_name, ''), is_active, role, email, slack_handle, avatar_url, github_login
+FROM users
+WHERE email_digest = TRUE
+  AND email <> ''
+  AND deleted_at IS NULL
+ORDER BY user_id
+`
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("list digest users: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var users []User
+	for rows.Next() {
+		var u User
+		if err := rows.Scan(&u.ID, &u.Name, &u.TeamName, &u.IsActive, &u.Role, &u.Email, &u.SlackHandle, &u.AvatarURL, &u.GitHubLogin); err != nil {
+			return nil, fmt.Errorf("scan digest user: %w", err)
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("digest user rows: %w", err)
+	}
+
+	digests := make([]ReviewDigest, 0, len(users))
+	for _, u := range users {
+		pending, err := s.GetPendingReviews(ctx, u.ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(pending) == 0 {
+			continue
+		}
+		d := ReviewDigest{User: u}
+		for _, pr := range pending {
+			if pr.Deadline != nil && pr.Deadline.Before(now) {
+				d.Overdue = append(d.Overdue, pr)
+			} else {
+				d.Reviews = append(d.Reviews, pr)
+			}
+		}
+		digests = append(digests, d)
+	}
+	return digests, nil
+}
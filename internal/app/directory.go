@@ -0,0 +1,153 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// DirectoryUser holds the user attributes a corporate directory owns. Role,
+// Slack handle and avatar stay managed by the service.
+type DirectoryUser struct {
+	ID       string
+	Name     string
+	Email    string
+	IsActive bool
+}
+
+// SyncDirectoryUser overwrites the directory attributes of a user.
+// Deactivation cleans up assignments as SetUserIsActive does.
+func (s *Service) SyncDirectoryUser(ctx context.Context, du DirectoryUser) (User, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return User{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	const query = `
+UPDATE users
+SET username = $2,
+    email = $3
+WHERE user_id = $1
+  AND deleted_at IS NULL
+`
+	res, err := tx.ExecContext(ctx, query, du.ID, du.Name, du.Email)
+	if err != nil {
+		return User{}, fmt.Errorf("update directory user: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return User{}, fmt.Errorf("update directory user: %w", err)
+	}
+	if n == 0 {
+		return User{}, &Error{Code: ErrorCodeNotFound, Message: "user not found"}
+	}
+
+	u, err := s.setUserIsActive(ctx, tx, du.ID, du.IsActive)
+	if err != nil {
+		return User{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return User{}, fmt.Errorf("commit tx: %w", err)
+	}
+
+	if !du.IsActive {
+		s.publishQueueChange()
+	}
+	return u, nil
+}
+
+// UpdateTeamMembers moves the users in add into a team, leaving their
+// previous teams, and detaches the members in remove from it, as directory
+// group changes do. Detached users stay without a team and keep their open
+// reviews; users in remove that are not members are ignored.
+func (s *Service) UpdateTeamMembers(ctx context.Context, teamName string, add, remove []string) (Team, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Team{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if err := lockTeam(ctx, tx, teamName); err != nil {
+		return Team{}, err
+	}
+
+	const selectQuery = `
+SELECT user_id, COALESCE(team_name, ''), is_active
+FROM users
+WHERE user_id = ANY($1)
+  AND deleted_at IS NULL
+ORDER BY user_id
+FOR UPDATE
+`
+	rows, err := tx.QueryContext(ctx, selectQuery, pq.Array(append(append([]string(nil), add...), remove...)))
+	if err != nil {
+		return Team{}, fmt.Errorf("select users: %w", err)
+	}
+	type userTeam struct {
+		teamName string
+		isActive bool
+	}
+	users := make(map[string]userTeam)
+	for rows.Next() {
+		var id string
+		var ut userTeam
+		if err := rows.Scan(&id, &ut.teamName, &ut.isActive); err != nil {
+			_ = rows.Close()
+			return Team{}, fmt.Errorf("scan user: %w", err)
+		}
+		users[id] = ut
+	}
+	if err := rows.Close(); err != nil {
+		return Team{}, fmt.Errorf("users rows: %w", err)
+	}
+
+	var missing []string
+	for _, id := range add {
+		if _, ok := users[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		return Team{}, &Error{Code: ErrorCodeNotFound, Message: "users not found: " + strings.Join(missing, ", ")}
+	}
+
+	const setTeamQuery = `UPDATE users SET team_name = NULLIF($2, '') WHERE user_id = $1`
+	for _, id := range add {
+		ut := users[id]
+		if ut.teamName == teamName {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, setTeamQuery, id, teamName); err != nil {
+			return Team{}, fmt.Errorf("move user %s: %w", id, err)
+		}
+		if err := recordJoin(ctx, tx, teamName, id, ut.teamName, ut.isActive); err != nil {
+			return Team{}, err
+		}
+		users[id] = userTeam{teamName: teamName, isActive: ut.isActive}
+	}
+	for _, id := range remove {
+		if ut, ok := users[id]; !ok || ut.teamName != teamName {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, setTeamQuery, id, ""); err != nil {
+			return Team{}, fmt.Errorf("detach user %s: %w", id, err)
+		}
+		if err := recordMembershipEvent(ctx, tx, teamName, id, MembershipLeft, ""); err != nil {
+			return Team{}, err
+		}
+		users[id] = userTeam{}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Team{}, fmt.Errorf("commit tx: %w", err)
+	}
+	return s.GetTeam(ctx, teamName)
+}
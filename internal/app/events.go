@@ -0,0 +1,182 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// queueEvents is the in-process event bus of review queue changes. It lives
+// in one process; WithQueueRelay brings in the changes of other replicas.
+type queueEvents struct {
+	mu   sync.Mutex
+	next int
+	subs map[int]queueSubscriber
+}
+
+type queueSubscriber struct {
+	userID string
+	ch     chan struct{}
+}
+
+func newQueueEvents() *queueEvents {
+	return &queueEvents{subs: make(map[int]queueSubscriber)}
+}
+
+// SubscribeQueueChanges returns a channel that receives a value whenever the
+// review queue of userID may have changed, and a function that ends the
+// subscription. Changes that arrive while the subscriber is busy are
+// coalesced into a single value.
+func (s *Service) SubscribeQueueChanges(userID string) (<-chan struct{}, func()) {
+	e := s.queueEvents
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	id := e.next
+	e.next++
+	ch := make(chan struct{}, 1)
+	e.subs[id] = queueSubscriber{userID: userID, ch: ch}
+	return ch, func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		delete(e.subs, id)
+	}
+}
+
+// publishQueueChange tells subscribers of the given users that their queues
+// changed. Without users every subscriber is told, for changes that may touch
+// any queue, such as deactivations.
+func (s *Service) publishQueueChange(userIDs ...string) {
+	s.queueEvents.publish(userIDs)
+	if s.queueRelayDSN != "" {
+		s.relayQueueChange(userIDs)
+	}
+}
+
+func (e *queueEvents) publish(userIDs []string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, sub := range e.subs {
+		if len(userIDs) > 0 && !slices.Contains(userIDs, sub.userID) {
+			continue
+		}
+		select {
+		case sub.ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// queueRelayChannel is the PostgreSQL notification channel of queue changes.
+const queueRelayChannel = "review_queue_changes"
+
+// queueRelayTimeout bounds the notification sent after a change.
+const queueRelayTimeout = 2 * time.Second
+
+// queueRelayMessage is the payload of a queue change notification.
+type queueRelayMessage struct {
+	// Sender is the relay ID of the replica that made the change and has
+	// told its own subscribers already.
+	Sender string   `json:"sender"`
+	Users  []string `json:"users,omitempty"`
+}
+
+// WithQueueRelay relays review queue changes between replicas through
+// PostgreSQL notifications, so that a WebSocket client connected to one
+// replica learns about changes made through another. dsn is the database
+// of the service; ListenQueueChanges receives the notifications.
+func WithQueueRelay(dsn string) Option {
+	return func(s *Service) {
+		s.queueRelayDSN = dsn
+		s.relayID = newRelayID()
+	}
+}
+
+// newRelayID returns a random ID telling the relayed changes of one
+// Service apart from those of the others.
+func newRelayID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// relayQueueChange notifies the other replicas of a change. A lost
+// notification only delays clients until their next refresh, so errors are
+// logged.
+func (s *Service) relayQueueChange(userIDs []string) {
+	payload, err := json.Marshal(queueRelayMessage{Sender: s.relayID, Users: userIDs})
+	if err != nil {
+		s.logger.Error("encode queue change", "error", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), queueRelayTimeout)
+	defer cancel()
+	if _, err := s.db.ExecContext(ctx, `SELECT pg_notify($1, $2)`, queueRelayChannel, string(payload)); err != nil {
+		s.logger.Warn("relay queue change", "error", err)
+	}
+}
+
+// ListenQueueChanges tells local subscribers about the queue changes other
+// replicas relay, until ctx is done. It returns at once without
+// WithQueueRelay.
+func (s *Service) ListenQueueChanges(ctx context.Context) error {
+	if s.queueRelayDSN == "" {
+		return nil
+	}
+
+	l := pq.NewListener(s.queueRelayDSN, time.Second, 30*time.Second, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			s.logger.Warn("queue relay connection", "event", ev, "error", err)
+		}
+	})
+	defer func() {
+		_ = l.Close()
+	}()
+	if err := l.Listen(queueRelayChannel); err != nil {
+		return fmt.Errorf("listen %s: %w", queueRelayChannel, err)
+	}
+
+	ping := time.NewTicker(time.Minute)
+	defer ping.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ping.C:
+			// Detects a silently dropped connection; the listener reconnects.
+			_ = l.Ping()
+		case n := <-l.Notify:
+			if n == nil {
+				// The connection was re-established and notifications may
+				// have been missed, so every client refreshes.
+				s.queueEvents.publish(nil)
+				continue
+			}
+			var msg queueRelayMessage
+			if err := json.Unmarshal([]byte(n.Extra), &msg); err != nil {
+				s.logger.Warn("decode queue change", "error", err)
+				continue
+			}
+			if msg.Sender != s.relayID {
+				s.queueEvents.publish(msg.Users)
+			}
+		}
+	}
+}
+
+// queueUsers lists the users whose review queues contain the pull request.
+func queueUsers(pr PullRequest) []string {
+	users := append([]string(nil), pr.AssignedReviewers...)
+	if pr.SecurityReviewerID != nil {
+		users = append(users, *pr.SecurityReviewerID)
+	}
+	return users
+}
@@ -0,0 +1,62 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// IssueFeedToken creates the feed token of a user, replacing the previous
+// one, and returns it. The token authenticates the user's calendar feed and
+// queue WebSocket, in the token query parameter.
+func (s *Service) IssueFeedToken(ctx context.Context, userID string) (string, error) {
+	secret, err := randomHex(24)
+	if err != nil {
+		return "", err
+	}
+	secret = "rft_" + secret
+
+	const query = `
+INSERT INTO feed_tokens (user_id, token_hash)
+SELECT user_id, $2
+FROM users
+WHERE user_id = $1
+  AND deleted_at IS NULL
+ON CONFLICT (user_id) DO UPDATE
+SET token_hash = EXCLUDED.token_hash,
+    created_at = NOW()
+`
+	res, err := s.db.ExecContext(ctx, query, userID, hashAPIKey(secret))
+	if err != nil {
+		return "", fmt.Errorf("issue feed token: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return "", fmt.Errorf("issue feed token: %w", err)
+	}
+	if n == 0 {
+		return "", &Error{Code: ErrorCodeNotFound, Message: "user not found"}
+	}
+	return secret, nil
+}
+
+// AuthenticateFeedToken returns the user of a feed token, or UNAUTHORIZED.
+func (s *Service) AuthenticateFeedToken(ctx context.Context, secret string) (string, error) {
+	const query = `
+SELECT t.user_id
+FROM feed_tokens t
+JOIN users u ON u.user_id = t.user_id
+WHERE t.token_hash = $1
+  AND u.deleted_at IS NULL
+`
+	var userID string
+	err := s.db.QueryRowContext(ctx, query, hashAPIKey(secret)).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", &Error{Code: ErrorCodeUnauthorized, Message: "invalid feed token"}
+	}
+	if err != nil {
+		return "", fmt.Errorf("authenticate feed token: %w", err)
+	}
+	return userID, nil
+}
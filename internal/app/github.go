@@ -0,0 +1,261 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// GitHubChannel is the outbox channel of GitHub updates.
+const GitHubChannel = "github"
+
+var (
+	gitHubRepoPattern  = regexp.MustCompile(`^[A-Za-z0-9-]{1,39}/[A-Za-z0-9._-]{1,100}$`)
+	gitHubLoginPattern = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9-]{0,38})$`)
+)
+
+// IsValidGitHubRepo reports whether repo is a GitHub repository name in the
+// owner/name form.
+func IsValidGitHubRepo(repo string) bool {
+	return gitHubRepoPattern.MatchString(repo)
+}
+
+// IsValidGitHubLogin reports whether login looks like a GitHub user login.
+func IsValidGitHubLogin(login string) bool {
+	return gitHubLoginPattern.MatchString(login)
+}
+
+// ValidateGitHubLink checks the GitHub repository and number of a pull
+// request and returns a message for the client, or an empty string if they
+// are valid. Both are empty for a pull request that is not on GitHub.
+func ValidateGitHubLink(repo string, number int) string {
+	switch {
+	case repo == "" && number == 0:
+		return ""
+	case repo == "" || number == 0:
+		return "github_repo and github_number must be set together"
+	case !IsValidGitHubRepo(repo):
+		return "github_repo must be owner/name"
+	case number < 0:
+		return "github_number must be positive"
+	}
+	return ""
+}
+
+// CodeHost mirrors reviewer assignments on the code host, e.g. GitHub.
+// Errors make the outbox retry the update.
+type CodeHost interface {
+	// RequestReviews asks the given users to review a pull request.
+	RequestReviews(ctx context.Context, repo string, number int, logins []string) error
+	// RemoveReviewRequests withdraws review requests from the given users.
+	RemoveReviewRequests(ctx context.Context, repo string, number int, logins []string) error
+	// ReportReviewers publishes the assigned reviewers on the pull request,
+	// e.g. as a check run.
+	ReportReviewers(ctx context.Context, repo string, number int, title, summary string) error
+}
+
+// WithCodeHost requests reviews from the assigned reviewers on the code
+// host of pull requests linked to it and reports the assignment there.
+func WithCodeHost(h CodeHost) Option {
+	return func(s *Service) {
+		s.codeHost = h
+	}
+}
+
+// codeHostSyncMessage is the outbox payload of a code host update. The
+// reviewers are read when it is delivered, so a message brings the code
+// host up to date with all changes made before it.
+type codeHostSyncMessage struct {
+	PullRequestID string `json:"pull_request_id"`
+	// Removed lists the logins of unassigned reviewers, resolved when the
+	// message is queued because deleting a user clears the login.
+	Removed []string `json:"removed,omitempty"`
+}
+
+// enqueueCodeHostSync queues an update of the code host in the transaction
+// that changed the reviewers of a pull request linked to it. removed lists
+// the users unassigned by the change.
+func (s *Service) enqueueCodeHostSync(ctx context.Context, q querier, prID string, removed []string) error {
+	if s.codeHost == nil {
+		return nil
+	}
+	msg := codeHostSyncMessage{PullRequestID: prID}
+	if len(removed) > 0 {
+		logins, err := gitHubLogins(ctx, q, removed)
+		if err != nil {
+			return err
+		}
+		for _, id := range removed {
+			if login := logins[id]; login != "" {
+				msg.Removed = append(msg.Removed, login)
+			}
+		}
+	}
+	return enqueueOutbox(ctx, q, OutboxKindCodeHostSync, GitHubChannel, msg)
+}
+
+// enqueueCodeHostRemovals queues updates of the linked open pull requests
+// the given users are about to be unassigned from.
+func (s *Service) enqueueCodeHostRemovals(ctx context.Context, q querier, userIDs []string) error {
+	if s.codeHost == nil {
+		return nil
+	}
+	const query = `
+SELECT pull_request_id, ARRAY(SELECT u FROM unnest({reviewers:pull_requests}) AS u WHERE u = ANY($1))
+FROM pull_requests
+WHERE status = 'OPEN'
+  AND deleted_at IS NULL
+  AND github_repo <> ''
+  AND {reviewers:pull_requests} && $1
+`
+	rows, err := q.QueryContext(ctx, s.expandReviewers(query), pq.Array(userIDs))
+	if err != nil {
+		return fmt.Errorf("select linked pull requests: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	type removal struct {
+		prID    string
+		userIDs []string
+	}
+	var removals []removal
+	for rows.Next() {
+		var r removal
+		if err := rows.Scan(&r.prID, pq.Array(&r.userIDs)); err != nil {
+			return fmt.Errorf("scan linked pull request: %w", err)
+		}
+		removals = append(removals, r)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("linked pull request rows: %w", err)
+	}
+	// The transaction's connection is needed for the inserts below.
+	_ = rows.Close()
+
+	for _, r := range removals {
+		if err := s.enqueueCodeHostSync(ctx, q, r.prID, r.userIDs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deliverCodeHostSync requests reviews from the current reviewers of a pull
+// request, withdraws the removed ones and reports the reviewers. Updates of
+// deleted, merged and unlinked pull requests are dropped, as are reviewers
+// without a GitHub login.
+func (s *Service) deliverCodeHostSync(ctx context.Context, q querier, msg codeHostSyncMessage) error {
+	if s.codeHost == nil {
+		return errors.New("no code host is configured")
+	}
+	pr, err := s.getPullRequest(ctx, q, msg.PullRequestID)
+	if err != nil {
+		var appErr *Error
+		if errors.As(err, &appErr) && appErr.Code == ErrorCodeNotFound {
+			return nil
+		}
+		return err
+	}
+	if pr.GitHubRepo == "" || pr.Status != "OPEN" {
+		return nil
+	}
+
+	logins, err := gitHubLogins(ctx, q, pr.AssignedReviewers)
+	if err != nil {
+		return err
+	}
+	var requested, removed []string
+	for _, id := range pr.AssignedReviewers {
+		if login := logins[id]; login != "" {
+			requested = append(requested, login)
+		}
+	}
+	for _, login := range msg.Removed {
+		if !containsFold(requested, login) {
+			removed = append(removed, login)
+		}
+	}
+
+	if len(removed) > 0 {
+		if err := s.codeHost.RemoveReviewRequests(ctx, pr.GitHubRepo, pr.GitHubNumber, removed); err != nil {
+			return err
+		}
+	}
+	if len(requested) > 0 {
+		if err := s.codeHost.RequestReviews(ctx, pr.GitHubRepo, pr.GitHubNumber, requested); err != nil {
+			return err
+		}
+	}
+	title, summary := reviewersReport(pr, logins)
+	return s.codeHost.ReportReviewers(ctx, pr.GitHubRepo, pr.GitHubNumber, title, summary)
+}
+
+// gitHubLogins maps the given users to their GitHub logins. Users without
+// a login are left out.
+func gitHubLogins(ctx context.Context, q querier, userIDs []string) (map[string]string, error) {
+	const query = `
+SELECT user_id, github_login
+FROM users
+WHERE user_id = ANY($1)
+  AND github_login <> ''
+  AND deleted_at IS NULL
+`
+	rows, err := q.QueryContext(ctx, query, pq.Array(userIDs))
+	if err != nil {
+		return nil, fmt.Errorf("select github logins: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	logins := make(map[string]string)
+	for rows.Next() {
+		var id, login string
+		if err := rows.Scan(&id, &login); err != nil {
+			return nil, fmt.Errorf("scan github login: %w", err)
+		}
+		logins[id] = login
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("github login rows: %w", err)
+	}
+	return logins, nil
+}
+
+// reviewersReport is the title and Markdown summary listing the reviewers
+// of a pull request, with a mention of those who have a GitHub login.
+func reviewersReport(pr PullRequest, logins map[string]string) (title, summary string) {
+	switch len(pr.AssignedReviewers) {
+	case 0:
+		return "No reviewers assigned", "No reviewers could be assigned to this pull request."
+	case 1:
+		title = "1 reviewer assigned"
+	default:
+		title = fmt.Sprintf("%d reviewers assigned", len(pr.AssignedReviewers))
+	}
+	var b strings.Builder
+	b.WriteString("Assigned reviewers:\n")
+	for _, id := range pr.AssignedReviewers {
+		if login := logins[id]; login != "" {
+			fmt.Fprintf(&b, "- @%s (%s)\n", login, id)
+		} else {
+			fmt.Fprintf(&b, "- %s\n", id)
+		}
+	}
+	return title, b.String()
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package app
+
+import (
+	"context"
+	"fmt"
+)
+
+// SchemaVersion is the number of the newest migration the code relies on.
+// Bump it with every migration in migrations/.
+const SchemaVersion = 43
+
+// DatabaseSchemaVersion pings the database and returns the number of the
+// newest applied migration, 0 when the database predates schema_migrations.
+func (s *Service) DatabaseSchemaVersion(ctx context.Context) (int, error) {
+	if err := s.db.PingContext(ctx); err != nil {
+		return 0, fmt.Errorf("ping db: %w", err)
+	}
+
+	var version int
+	err := s.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	if hasPQCode(err, pqUndefinedTable) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("select schema version: %w", err)
+	}
+	return version, nil
+}
@@ -0,0 +1,184 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Assignment event types.
+const (
+	AssignmentEventAssigned = "ASSIGNED"
+	AssignmentEventRemoved  = "REMOVED"
+	AssignmentEventReplaced = "REPLACED"
+)
+
+// Reasons recorded with assignment events.
+const (
+	AssignmentReasonCreated         = "PR_CREATED"
+	AssignmentReasonReassigned      = "REASSIGNED"
+	AssignmentReasonDeclined        = "DECLINED"
+	AssignmentReasonUserDeactivated = "USER_DEACTIVATED"
+	AssignmentReasonTeamDeactivated = "TEAM_DEACTIVATED"
+	AssignmentReasonTeamDeleted     = "TEAM_DELETED"
+)
+
+// Strategies used to pick reviewers.
+const (
+	// AssignmentStrategyTeamOrder picks active teammates ordered by user_id.
+	AssignmentStrategyTeamOrder = "TEAM_ORDER"
+	// AssignmentStrategyRandom picks a random active teammate.
+	AssignmentStrategyRandom = "RANDOM"
+)
+
+// AssignmentEvent is a single change of a pull request's reviewer list.
+type AssignmentEvent struct {
+	ID                int64     `json:"event_id"`
+	PullRequestID     string    `json:"pull_request_id"`
+	EventType         string    `json:"event_type"`
+	UserID            string    `json:"user_id"`
+	ReplacementUserID *string   `json:"replacement_user_id,omitempty"`
+	Reason            string    `json:"reason"`
+	Strategy          *string   `json:"strategy,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// PreviousReviewer is a reviewer who used to be assigned to a pull request.
+type PreviousReviewer struct {
+	UserID string `json:"user_id"`
+	// AssignedAt is nil when the assignment predates assignment history.
+	AssignedAt   *time.Time `json:"assigned_at,omitempty"`
+	UnassignedAt time.Time  `json:"unassigned_at"`
+	// ReplacedBy is empty when the reviewer was removed without a replacement.
+	ReplacedBy string `json:"replaced_by,omitempty"`
+	Reason     string `json:"reason"`
+}
+
+// previousReviewers reconstructs the chain of former reviewers from events
+// ordered by event_id.
+func previousReviewers(events []AssignmentEvent) []PreviousReviewer {
+	assignedAt := make(map[string]time.Time)
+	prev := make([]PreviousReviewer, 0)
+	for _, e := range events {
+		switch e.EventType {
+		case AssignmentEventAssigned:
+			assignedAt[e.UserID] = e.CreatedAt
+		case AssignmentEventReplaced, AssignmentEventRemoved:
+			p := PreviousReviewer{
+				UserID:       e.UserID,
+				UnassignedAt: e.CreatedAt,
+				Reason:       e.Reason,
+			}
+			if t, ok := assignedAt[e.UserID]; ok {
+				p.AssignedAt = &t
+				delete(assignedAt, e.UserID)
+			}
+			if e.ReplacementUserID != nil {
+				p.ReplacedBy = *e.ReplacementUserID
+				assignedAt[p.ReplacedBy] = e.CreatedAt
+			}
+			prev = append(prev, p)
+		}
+	}
+	return prev
+}
+
+// recordAssignments logs an ASSIGNED event for every user in userIDs.
+func recordAssignments(ctx context.Context, q querier, prID string, userIDs []string, reason, strategy string) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	const query = `
+INSERT INTO assignment_events(pull_request_id, event_type, user_id, reason, strategy)
+SELECT $1, 'ASSIGNED', u.user_id, $3, $4
+FROM unnest($2::text[]) WITH ORDINALITY AS u(user_id, position)
+ORDER BY u.position
+`
+	if _, err := q.ExecContext(ctx, query, prID, pq.Array(userIDs), reason, strategy); err != nil {
+		return fmt.Errorf("record assignments: %w", err)
+	}
+	return nil
+}
+
+// recordReplacement logs that oldUserID was replaced by newUserID.
+func recordReplacement(ctx context.Context, q querier, prID, oldUserID, newUserID, reason, strategy string) error {
+	const query = `
+INSERT INTO assignment_events(pull_request_id, event_type, user_id, replacement_user_id, reason, strategy)
+VALUES ($1, 'REPLACED', $2, $3, $4, $5)
+`
+	if _, err := q.ExecContext(ctx, query, prID, oldUserID, newUserID, reason, strategy); err != nil {
+		return fmt.Errorf("record replacement: %w", err)
+	}
+	return nil
+}
+
+// recordOpenRemovals logs a REMOVED event for every open pull request the users
+// are assigned to. It must run before the assignments are actually removed.
+func recordOpenRemovals(ctx context.Context, q querier, userIDs []string, reason string) error {
+	const query = `
+INSERT INTO assignment_events(pull_request_id, event_type, user_id, reason)
+SELECT p.pull_request_id, 'REMOVED', r.user_id, $2
+FROM pull_requests p
+CROSS JOIN LATERAL unnest(p.assigned_reviewers) AS r(user_id)
+WHERE p.status <> 'MERGED'
+  AND p.deleted_at IS NULL
+  AND r.user_id = ANY($1)
+ORDER BY p.pull_request_id, r.user_id
+`
+	if _, err := q.ExecContext(ctx, query, pq.Array(userIDs), reason); err != nil {
+		return fmt.Errorf("record removals: %w", err)
+	}
+	return nil
+}
+
+// GetAssignmentHistory returns assignment events of a pull request in the order they happened.
+func (s *Service) GetAssignmentHistory(ctx context.Context, prID string) ([]AssignmentEvent, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `SELECT TRUE FROM pull_requests WHERE pull_request_id = $1`, prID).Scan(&exists)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &Error{Code: ErrorCodeNotFound, Message: "pull request not found"}
+		}
+		return nil, fmt.Errorf("get pull request: %w", err)
+	}
+
+	return assignmentEvents(ctx, s.db, prID)
+}
+
+func assignmentEvents(ctx context.Context, q querier, prID string) ([]AssignmentEvent, error) {
+	const query = `
+SELECT event_id, pull_request_id, event_type, user_id, replacement_user_id, reason, strategy, created_at
+FROM assignment_events
+WHERE pull_request_id = $1
+ORDER BY event_id
+`
+	rows, err := q.QueryContext(ctx, query, prID)
+	if err != nil {
+		return nil, fmt.Errorf("get assignment history: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	events := make([]AssignmentEvent, 0)
+	for rows.Next() {
+		var e AssignmentEvent
+		var replacement, strategy sql.NullString
+		if err := rows.Scan(&e.ID, &e.PullRequestID, &e.EventType, &e.UserID, &replacement, &e.Reason, &strategy, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan assignment event: %w", err)
+		}
+		e.ReplacementUserID = nullStringPtr(replacement)
+		e.Strategy = nullStringPtr(strategy)
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("assignment history rows: %w", err)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,74 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// fingerprint identifies the payload of a create request so that a reused
+// idempotency key with a different payload can be detected.
+func (in PullRequestInput) fingerprint() string {
+	in.IdempotencyKey = ""
+	data, _ := json.Marshal(in)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// findIdempotentPullRequest returns the pull request previously created with
+// the same idempotency key, if any.
+func (s *Service) findIdempotentPullRequest(ctx context.Context, in PullRequestInput) (PullRequest, bool, error) {
+	const query = `SELECT pull_request_id, request_hash FROM idempotency_keys WHERE idempotency_key = $1`
+	var prID, hash string
+	err := s.db.QueryRowContext(ctx, query, in.IdempotencyKey).Scan(&prID, &hash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return PullRequest{}, false, nil
+		}
+		return PullRequest{}, false, fmt.Errorf("get idempotency key: %w", err)
+	}
+
+	if hash != in.fingerprint() {
+		return PullRequest{}, false, &Error{
+			Code:    ErrorCodeIdempotencyKeyReused,
+			Message: "idempotency key was already used with a different request",
+		}
+	}
+
+	pr, err := s.getPullRequest(ctx, s.db, prID)
+	if err != nil {
+		return PullRequest{}, false, err
+	}
+	return pr, true, nil
+}
+
+func saveIdempotencyKey(ctx context.Context, q querier, in PullRequestInput) error {
+	const query = `
+INSERT INTO idempotency_keys(idempotency_key, pull_request_id, request_hash)
+VALUES ($1, $2, $3)
+`
+	if _, err := q.ExecContext(ctx, query, in.IdempotencyKey, in.ID, in.fingerprint()); err != nil {
+		return fmt.Errorf("save idempotency key: %w", err)
+	}
+	return nil
+}
+
+// duplicatePullRequest answers a create that lost the race for the pull
+// request ID or the idempotency key to a concurrent request: a retry with
+// the same key gets the pull request the winner created.
+func (s *Service) duplicatePullRequest(ctx context.Context, in PullRequestInput) (PullRequest, error) {
+	if in.IdempotencyKey != "" {
+		pr, found, err := s.findIdempotentPullRequest(ctx, in)
+		if err != nil {
+			return PullRequest{}, err
+		}
+		if found {
+			return pr, nil
+		}
+	}
+	return PullRequest{}, &Error{Code: ErrorCodePRExists, Message: "PR id already exists"}
+}
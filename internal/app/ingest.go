@@ -0,0 +1,175 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Event types accepted by IngestEvent.
+const (
+	EventPullRequestOpened  = "pull_request.opened"
+	EventPullRequestUpdated = "pull_request.updated"
+	EventPullRequestMerged  = "pull_request.merged"
+	EventPullRequestClosed  = "pull_request.closed"
+	EventReviewApproved     = "review.approved"
+	EventReviewDeclined     = "review.declined"
+)
+
+// IsValidEventType reports whether t is one of the Event* types.
+func IsValidEventType(t string) bool {
+	switch t {
+	case EventPullRequestOpened, EventPullRequestUpdated, EventPullRequestMerged, EventPullRequestClosed,
+		EventReviewApproved, EventReviewDeclined:
+		return true
+	}
+	return false
+}
+
+// PullRequestEvent is a code host event translated to the provider-agnostic
+// form. PullRequest carries the fields the event type needs: all of them
+// when a pull request is opened, the changed ones for an update and only
+// the ID otherwise.
+type PullRequestEvent struct {
+	// ID identifies the event at its source. Events with an ID already seen
+	// are acknowledged without being applied again; empty disables it.
+	ID          string
+	Type        string
+	PullRequest PullRequestInput
+	// ReviewerID is the reviewer of review events.
+	ReviewerID string
+}
+
+// IngestEvent applies a code host event to its pull request as the matching
+// API call would and returns the result. duplicate is true, and the pull
+// request empty, when the event ID was already ingested. An event whose
+// earlier delivery is still being applied fails with EVENT_IN_PROGRESS.
+func (s *Service) IngestEvent(ctx context.Context, ev PullRequestEvent) (pr PullRequest, duplicate bool, err error) {
+	if ev.ID != "" {
+		fresh, err := s.recordIngestedEvent(ctx, ev)
+		if err != nil {
+			return PullRequest{}, false, err
+		}
+		if !fresh {
+			return PullRequest{}, true, nil
+		}
+		// A failed event is forgotten so that the source can redeliver it,
+		// and an applied one is marked done so that redeliveries are
+		// acknowledged as duplicates.
+		defer func() {
+			if err != nil {
+				s.forgetIngestedEvent(context.WithoutCancel(ctx), ev.ID)
+			} else {
+				s.completeIngestedEvent(context.WithoutCancel(ctx), ev.ID)
+			}
+		}()
+	}
+
+	in := ev.PullRequest
+	switch ev.Type {
+	case EventPullRequestOpened:
+		pr, err = s.CreatePullRequest(ctx, in)
+	case EventPullRequestUpdated:
+		upd := PullRequestUpdate{ID: in.ID, Labels: nilIfAbsent(in.Labels), JiraIssues: nilIfAbsent(in.JiraIssues),
+			Deadline: in.Deadline}
+		if in.Name != "" {
+			upd.Name = &in.Name
+		}
+		if in.Priority != "" {
+			upd.Priority = &in.Priority
+		}
+		if in.GitHubRepo != "" {
+			upd.GitHubRepo, upd.GitHubNumber = &in.GitHubRepo, &in.GitHubNumber
+		}
+		pr, err = s.UpdatePullRequest(ctx, upd)
+	case EventPullRequestMerged:
+		pr, _, err = s.MergePullRequest(ctx, in.ID)
+	case EventPullRequestClosed:
+		pr, err = s.DeletePullRequest(ctx, in.ID)
+	case EventReviewApproved:
+		pr, err = s.ApprovePullRequest(ctx, in.ID, ev.ReviewerID)
+	case EventReviewDeclined:
+		pr, _, _, err = s.DeclineReview(ctx, in.ID, ev.ReviewerID)
+	default:
+		err = &Error{Code: ErrorCodeValidation, Message: "unknown event type " + ev.Type}
+	}
+	return pr, false, err
+}
+
+// nilIfAbsent turns a missing list into a nil pointer, which leaves the
+// field unchanged; an empty list clears it.
+func nilIfAbsent(list []string) *[]string {
+	if list == nil {
+		return nil
+	}
+	return &list
+}
+
+// ingestStaleAfter is how long an event stays in progress before a
+// redelivery takes it over, e.g. after the replica applying it crashed. It
+// is far longer than any request may run.
+const ingestStaleAfter = 5 * time.Minute
+
+var errEventInProgress = &Error{Code: ErrorCodeEventInProgress, Message: "event is being applied, retry later"}
+
+// recordIngestedEvent remembers an event ID as in progress and reports
+// whether it is new. It fails with EVENT_IN_PROGRESS while another delivery
+// of the event is being applied.
+func (s *Service) recordIngestedEvent(ctx context.Context, ev PullRequestEvent) (bool, error) {
+	const query = `
+INSERT INTO ingested_events (event_id, event_type, pull_request_id)
+VALUES ($1, $2, $3)
+ON CONFLICT (event_id) DO UPDATE
+SET event_type = EXCLUDED.event_type,
+    pull_request_id = EXCLUDED.pull_request_id,
+    received_at = NOW()
+WHERE ingested_events.completed_at IS NULL
+  AND ingested_events.received_at < NOW() - make_interval(secs => $4)
+`
+	res, err := s.db.ExecContext(ctx, query, ev.ID, ev.Type, ev.PullRequest.ID, ingestStaleAfter.Seconds())
+	if err != nil {
+		return false, fmt.Errorf("record event: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("record event: %w", err)
+	}
+	if n == 1 {
+		return true, nil
+	}
+
+	var completed bool
+	err = s.db.QueryRowContext(ctx, `SELECT completed_at IS NOT NULL FROM ingested_events WHERE event_id = $1`, ev.ID).
+		Scan(&completed)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return false, fmt.Errorf("get event: %w", err)
+	}
+	// A row deleted in the meantime belongs to a delivery that just failed,
+	// which the source retries like one in progress.
+	if !completed {
+		return false, errEventInProgress
+	}
+	return false, nil
+}
+
+// completeIngestedEvent marks an applied event as done.
+func (s *Service) completeIngestedEvent(ctx context.Context, eventID string) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err := s.db.ExecContext(ctx, `UPDATE ingested_events SET completed_at = NOW() WHERE event_id = $1`, eventID); err != nil {
+		// The event stays in progress until it goes stale, so a redelivery
+		// after that applies it again.
+		s.log(ctx).Error("complete ingested event", "event_id", eventID, "error", err)
+	}
+}
+
+func (s *Service) forgetIngestedEvent(ctx context.Context, eventID string) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM ingested_events WHERE event_id = $1`, eventID); err != nil {
+		// The event stays recorded, so a redelivery is taken for a duplicate.
+		s.log(ctx).Error("forget failed event", "event_id", eventID, "error", err)
+	}
+}
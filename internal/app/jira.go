@@ -0,0 +1,106 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// JiraChannel is the outbox channel of issue tracker updates.
+const JiraChannel = "jira"
+
+// MaxJiraIssues limits the Jira issues linked to a pull request.
+const MaxJiraIssues = 20
+
+var jiraIssueKeyPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*-[1-9][0-9]*$`)
+
+// IsValidJiraIssueKey reports whether key looks like a Jira issue key, e.g. PROJ-123.
+func IsValidJiraIssueKey(key string) bool {
+	return jiraIssueKeyPattern.MatchString(key)
+}
+
+// NormalizeJiraIssues upper-cases and trims issue keys and drops empty and
+// duplicate entries, keeping order.
+func NormalizeJiraIssues(keys []string) []string {
+	out := make([]string, 0, len(keys))
+	for _, k := range NormalizeLabels(keys) {
+		k = strings.ToUpper(k)
+		if !slices.Contains(out, k) {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// IssueTracker updates issues linked to pull requests, e.g. in Jira.
+// Errors make the outbox retry the update.
+type IssueTracker interface {
+	CommentIssue(ctx context.Context, key, text string) error
+	TransitionIssue(ctx context.Context, key, transitionID string) error
+}
+
+// WithIssueTracker reports merges to the issues linked to pull requests as
+// configured by the author's team settings.
+func WithIssueTracker(t IssueTracker) Option {
+	return func(s *Service) {
+		s.issueTracker = t
+	}
+}
+
+// jiraMergeMessage is the outbox payload of one update of a linked issue.
+// Each issue and action is a separate message, so that a retry repeats
+// only the update that failed.
+type jiraMergeMessage struct {
+	PullRequestID string `json:"pull_request_id"`
+	Issue         string `json:"issue"`
+	// TransitionID is set for a transition and empty for a comment.
+	TransitionID string `json:"transition_id,omitempty"`
+}
+
+// enqueueJiraMerge queues the updates of the issues linked to a merged pull
+// request in the merge transaction, as the author's team settings say.
+func (s *Service) enqueueJiraMerge(ctx context.Context, q querier, pr PullRequest, settings TeamSettings) error {
+	if s.issueTracker == nil {
+		return nil
+	}
+	for _, key := range pr.JiraIssues {
+		if settings.JiraCommentOnMerge {
+			msg := jiraMergeMessage{PullRequestID: pr.ID, Issue: key}
+			if err := enqueueOutbox(ctx, q, OutboxKindJiraMerge, JiraChannel, msg); err != nil {
+				return err
+			}
+		}
+		if settings.JiraMergeTransition != "" {
+			msg := jiraMergeMessage{PullRequestID: pr.ID, Issue: key, TransitionID: settings.JiraMergeTransition}
+			if err := enqueueOutbox(ctx, q, OutboxKindJiraMerge, JiraChannel, msg); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// deliverJiraMerge comments on or transitions an issue. Comments about
+// deleted pull requests are dropped.
+func (s *Service) deliverJiraMerge(ctx context.Context, q querier, msg jiraMergeMessage) error {
+	if s.issueTracker == nil {
+		return errors.New("no issue tracker is configured")
+	}
+	if msg.TransitionID != "" {
+		return s.issueTracker.TransitionIssue(ctx, msg.Issue, msg.TransitionID)
+	}
+
+	pr, err := s.getPullRequest(ctx, q, msg.PullRequestID)
+	if err != nil {
+		var appErr *Error
+		if errors.As(err, &appErr) && appErr.Code == ErrorCodeNotFound {
+			return nil
+		}
+		return err
+	}
+	text := fmt.Sprintf("Pull request %s (%s) by %s was merged.", pr.Name, pr.ID, pr.AuthorID)
+	return s.issueTracker.CommentIssue(ctx, msg.Issue, text)
+}
@@ -0,0 +1,219 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Job is a periodic background task. Replicas share its schedule: a run
+// starts on whichever replica claims it first once Interval has passed
+// since the previous run, and runs never overlap.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// JobStatus is a registered job with its last run on any replica.
+type JobStatus struct {
+	Name            string  `json:"name"`
+	IntervalSeconds float64 `json:"interval_seconds"`
+	// Running is set from the start of a run until it finishes.
+	Running        bool       `json:"running"`
+	LastStartedAt  *time.Time `json:"last_started_at,omitempty"`
+	LastFinishedAt *time.Time `json:"last_finished_at,omitempty"`
+	// LastDurationSeconds is the duration of the last finished run.
+	LastDurationSeconds *float64 `json:"last_duration_seconds,omitempty"`
+	LastError           string   `json:"last_error,omitempty"`
+	// LastInstance is the replica that started the last run.
+	LastInstance string     `json:"last_instance,omitempty"`
+	NextRunAt    *time.Time `json:"next_run_at,omitempty"`
+	Runs         int64      `json:"runs"`
+	Failures     int64      `json:"failures"`
+}
+
+// jobLockPrefix namespaces the advisory locks of jobs.
+const jobLockPrefix = "review-assigner:job:"
+
+// jobCheckInterval is how often a replica checks whether a job is due:
+// a quarter of its interval, between a second and a minute.
+func jobCheckInterval(interval time.Duration) time.Duration {
+	return min(max(interval/4, time.Second), time.Minute)
+}
+
+// instanceName identifies this replica in job statuses.
+var instanceName = sync.OnceValue(func() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return host + ":" + strconv.Itoa(os.Getpid())
+})
+
+// RegisterJob adds a job run by RunJobs. Jobs with a non-positive interval
+// are ignored. It must be called before RunJobs.
+func (s *Service) RegisterJob(job Job) {
+	if job.Interval <= 0 {
+		return
+	}
+	s.jobs = append(s.jobs, job)
+}
+
+// RunJobs runs the registered jobs on their schedules until ctx is done,
+// then waits for the runs in progress, which complete despite ctx.
+func (s *Service) RunJobs(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, job := range s.jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.scheduleJob(ctx, job)
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *Service) scheduleJob(ctx context.Context, job Job) {
+	runCtx := context.WithoutCancel(ctx)
+	ticker := time.NewTicker(jobCheckInterval(job.Interval))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.runJobIfDue(runCtx, job); err != nil {
+				s.logger.Error("run job", "job", job.Name, "error", err)
+			}
+		}
+	}
+}
+
+// runJobIfDue runs job when no replica is running it and its interval has
+// passed since the last start. A session advisory lock held for the run
+// keeps runs from overlapping, and the conditional claim in job_runs keeps
+// two replicas from running it in the same interval.
+func (s *Service) runJobIfDue(ctx context.Context, job Job) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("get connection: %w", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	var locked bool
+	err = conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, jobLockPrefix+job.Name).Scan(&locked)
+	if err != nil {
+		return fmt.Errorf("lock job: %w", err)
+	}
+	if !locked {
+		return nil
+	}
+	defer func() {
+		// Closing the connection would release the lock too, but the pool
+		// keeps it open. When the unlock fails, the connection is discarded
+		// instead of going back to the pool still holding the lock.
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock(hashtext($1))`, jobLockPrefix+job.Name); err != nil {
+			s.logger.Error("unlock job", "job", job.Name, "error", err)
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+	}()
+
+	const claimQuery = `
+INSERT INTO job_runs (job_name, started_at, instance)
+VALUES ($1, NOW(), $3)
+ON CONFLICT (job_name) DO UPDATE
+SET started_at = NOW(),
+    finished_at = NULL,
+    instance = EXCLUDED.instance
+WHERE job_runs.started_at <= NOW() - make_interval(secs => $2)
+RETURNING job_name
+`
+	var name string
+	err = conn.QueryRowContext(ctx, claimQuery, job.Name, job.Interval.Seconds(), instanceName()).Scan(&name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("claim job: %w", err)
+	}
+
+	runErr := job.Run(ctx)
+	var lastError string
+	if runErr != nil {
+		lastError = runErr.Error()
+		s.logger.Error("job failed", "job", job.Name, "error", runErr)
+	}
+
+	const finishQuery = `
+UPDATE job_runs
+SET finished_at = NOW(),
+    last_error = $2,
+    runs = runs + 1,
+    failures = failures + CASE WHEN $2 = '' THEN 0 ELSE 1 END
+WHERE job_name = $1
+`
+	if _, err := conn.ExecContext(ctx, finishQuery, job.Name, lastError); err != nil {
+		return fmt.Errorf("finish job: %w", err)
+	}
+	return nil
+}
+
+// ListJobs returns the registered jobs with their last runs.
+func (s *Service) ListJobs(ctx context.Context) ([]JobStatus, error) {
+	const query = `
+SELECT job_name, started_at, finished_at, instance, last_error, runs, failures
+FROM job_runs
+`
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("select job runs: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	runs := make(map[string]JobStatus)
+	for rows.Next() {
+		var st JobStatus
+		var started time.Time
+		var finished sql.NullTime
+		if err := rows.Scan(&st.Name, &started, &finished, &st.LastInstance, &st.LastError, &st.Runs, &st.Failures); err != nil {
+			return nil, fmt.Errorf("scan job run: %w", err)
+		}
+		st.LastStartedAt = &started
+		if finished.Valid {
+			st.LastFinishedAt = &finished.Time
+			d := finished.Time.Sub(started).Seconds()
+			st.LastDurationSeconds = &d
+		} else {
+			st.Running = true
+		}
+		runs[st.Name] = st
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("job runs rows: %w", err)
+	}
+
+	jobs := make([]JobStatus, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		st, ok := runs[job.Name]
+		st.Name = job.Name
+		st.IntervalSeconds = job.Interval.Seconds()
+		if ok {
+			next := st.LastStartedAt.Add(job.Interval)
+			st.NextRunAt = &next
+		}
+		jobs = append(jobs, st)
+	}
+	return jobs, nil
+}
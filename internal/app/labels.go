@@ -0,0 +1,90 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Label limits enforced by the HTTP layer.
+const (
+	MaxLabels      = 20
+	MaxLabelLength = 50
+)
+
+// NormalizeLabels trims labels and drops empty and duplicate entries, keeping order.
+func NormalizeLabels(labels []string) []string {
+	out := make([]string, 0, len(labels))
+	seen := make(map[string]bool, len(labels))
+	for _, l := range labels {
+		l = strings.TrimSpace(l)
+		if l == "" || seen[l] {
+			continue
+		}
+		seen[l] = true
+		out = append(out, l)
+	}
+	return out
+}
+
+// UpdatePullRequest changes the name, priority, labels, Jira issues and
+// GitHub link of a pull request. Linking an open pull request to GitHub
+// requests reviews from its reviewers there.
+func (s *Service) UpdatePullRequest(ctx context.Context, upd PullRequestUpdate) (PullRequest, error) {
+	var labels, jiraIssues []string
+	if upd.Labels != nil {
+		labels = NormalizeLabels(*upd.Labels)
+	}
+	if upd.JiraIssues != nil {
+		jiraIssues = NormalizeJiraIssues(*upd.JiraIssues)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return PullRequest{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	const query = `
+UPDATE pull_requests
+SET pull_request_name = COALESCE($2, pull_request_name),
+    priority = COALESCE($3, priority),
+    labels = COALESCE($4, labels),
+    deadline = COALESCE($5, deadline),
+    jira_issues = COALESCE($6, jira_issues),
+    github_repo = COALESCE($7, github_repo),
+    github_number = COALESCE($8, github_number)
+WHERE pull_request_id = $1
+  AND deleted_at IS NULL
+RETURNING ` + pullRequestColumns
+	pr, err := s.scanPullRequest(tx.QueryRowContext(ctx, s.expandReviewers(query), upd.ID, upd.Name, upd.Priority, pq.Array(labels),
+		upd.Deadline, pq.Array(jiraIssues), upd.GitHubRepo, upd.GitHubNumber))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return PullRequest{}, &Error{Code: ErrorCodeNotFound, Message: "pull request not found"}
+		}
+		return PullRequest{}, fmt.Errorf("update pull request: %w", err)
+	}
+
+	linked := upd.GitHubRepo != nil && pr.GitHubRepo != "" && pr.Status == "OPEN"
+	if linked {
+		if err := s.enqueueCodeHostSync(ctx, tx, pr.ID, nil); err != nil {
+			return PullRequest{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return PullRequest{}, fmt.Errorf("commit tx: %w", err)
+	}
+	if linked {
+		s.kickOutbox()
+	}
+	s.publishQueueChange(queueUsers(pr)...)
+	return pr, nil
+}
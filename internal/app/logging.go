@@ -0,0 +1,30 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+)
+
+type loggerKey struct{}
+
+// WithLogger sets the logger of background work, such as outbox delivery,
+// and of failures the service handles without returning them.
+func WithLogger(l *slog.Logger) Option {
+	return func(s *Service) {
+		s.logger = l
+	}
+}
+
+// ContextWithLogger attaches a request-scoped logger, e.g. one carrying the
+// request ID, which the service uses for work done on behalf of ctx.
+func ContextWithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// log returns the logger of ctx, falling back to the service logger.
+func (s *Service) log(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return s.logger
+}
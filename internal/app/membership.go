@@ -0,0 +1,99 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Membership event types. A transfer is recorded as LEFT in the old team
+// and JOINED in the new one, each pointing to the other via related_team.
+const (
+	MembershipJoined      = "JOINED"
+	MembershipLeft        = "LEFT"
+	MembershipActivated   = "ACTIVATED"
+	MembershipDeactivated = "DEACTIVATED"
+)
+
+// MembershipEvent is a single change of a team's headcount.
+type MembershipEvent struct {
+	ID          int64     `json:"event_id"`
+	TeamName    string    `json:"team_name"`
+	UserID      string    `json:"user_id"`
+	EventType   string    `json:"event_type"`
+	RelatedTeam *string   `json:"related_team,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// GetMembershipHistory returns membership events of a team, oldest first.
+// Deleted (archived) teams keep their history.
+func (s *Service) GetMembershipHistory(ctx context.Context, teamName string) ([]MembershipEvent, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `SELECT TRUE FROM teams WHERE team_name = $1`, teamName).Scan(&exists)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &Error{Code: ErrorCodeNotFound, Message: "team not found"}
+		}
+		return nil, fmt.Errorf("check team: %w", err)
+	}
+
+	const query = `
+SELECT event_id, team_name, user_id, event_type, related_team, created_at
+FROM membership_events
+WHERE team_name = $1
+ORDER BY event_id
+`
+	rows, err := s.db.QueryContext(ctx, query, teamName)
+	if err != nil {
+		return nil, fmt.Errorf("get membership events: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	events := make([]MembershipEvent, 0)
+	for rows.Next() {
+		var e MembershipEvent
+		var related sql.NullString
+		if err := rows.Scan(&e.ID, &e.TeamName, &e.UserID, &e.EventType, &related, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan membership event: %w", err)
+		}
+		e.RelatedTeam = nullStringPtr(related)
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("membership events rows: %w", err)
+	}
+	return events, nil
+}
+
+func recordMembershipEvent(ctx context.Context, q querier, teamName, userID, eventType, relatedTeam string) error {
+	const query = `
+INSERT INTO membership_events(team_name, user_id, event_type, related_team)
+VALUES ($1, $2, $3, NULLIF($4, ''))
+`
+	if _, err := q.ExecContext(ctx, query, teamName, userID, eventType, relatedTeam); err != nil {
+		return fmt.Errorf("record membership event: %w", err)
+	}
+	return nil
+}
+
+// recordJoin records a user joining a team, leaving previousTeam if set.
+// Users joining as inactive get a DEACTIVATED event right away, so that
+// the history reflects the active headcount.
+func recordJoin(ctx context.Context, q querier, teamName, userID, previousTeam string, isActive bool) error {
+	if previousTeam != "" {
+		if err := recordMembershipEvent(ctx, q, previousTeam, userID, MembershipLeft, teamName); err != nil {
+			return err
+		}
+	}
+	if err := recordMembershipEvent(ctx, q, teamName, userID, MembershipJoined, previousTeam); err != nil {
+		return err
+	}
+	if !isActive {
+		return recordMembershipEvent(ctx, q, teamName, userID, MembershipDeactivated, "")
+	}
+	return nil
+}
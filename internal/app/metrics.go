@@ -0,0 +1,54 @@
+package app
+
+import (
+	"context"
+	"fmt"
+)
+
+// BusinessMetrics is the current state of the service exported as gauges.
+type BusinessMetrics struct {
+	OpenPullRequests int
+	// OpenAssignmentsByTeam counts open review assignments by the reviewer's team.
+	OpenAssignmentsByTeam map[string]int
+}
+
+// GetBusinessMetrics returns current business gauges.
+func (s *Service) GetBusinessMetrics(ctx context.Context) (BusinessMetrics, error) {
+	m := BusinessMetrics{OpenAssignmentsByTeam: make(map[string]int)}
+
+	const openQuery = `SELECT COUNT(*) FROM pull_requests WHERE status = 'OPEN' AND deleted_at IS NULL`
+	if err := s.db.QueryRowContext(ctx, openQuery).Scan(&m.OpenPullRequests); err != nil {
+		return m, fmt.Errorf("count open pull requests: %w", err)
+	}
+
+	const byTeamQuery = `
+SELECT u.team_name, COUNT(*)
+FROM pull_requests p
+CROSS JOIN LATERAL unnest({reviewers:p}) AS r(user_id)
+JOIN users u ON u.user_id = r.user_id
+WHERE p.status = 'OPEN'
+  AND p.deleted_at IS NULL
+  AND u.team_name IS NOT NULL
+GROUP BY u.team_name
+`
+	rows, err := s.db.QueryContext(ctx, s.expandReviewers(byTeamQuery))
+	if err != nil {
+		return m, fmt.Errorf("count open assignments: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	for rows.Next() {
+		var team string
+		var n int
+		if err := rows.Scan(&team, &n); err != nil {
+			return m, fmt.Errorf("scan open assignments: %w", err)
+		}
+		m.OpenAssignmentsByTeam[team] = n
+	}
+	if err := rows.Err(); err != nil {
+		return m, fmt.Errorf("open assignments rows: %w", err)
+	}
+	return m, nil
+}
@@ -2,12 +2,24 @@ package app
 
 import "time"
 
+// Profile holds user contact details used by notifications.
+type Profile struct {
+	Email       string `json:"email,omitempty"`
+	SlackHandle string `json:"slack_handle,omitempty"`
+	AvatarURL   string `json:"avatar_url,omitempty"`
+	// GitHubLogin is requested as a reviewer on GitHub in GitHub App mode.
+	GitHubLogin string `json:"github_login,omitempty"`
+}
+
 // User represents an application user.
 type User struct {
-	ID       string `json:"user_id"`
-	Name     string `json:"username"`
+	ID   string `json:"user_id"`
+	Name string `json:"username"`
+	// TeamName is empty for users that are not attached to a team.
 	TeamName string `json:"team_name"`
 	IsActive bool   `json:"is_active"`
+	Role     string `json:"role"`
+	Profile
 }
 
 // TeamMember represents a user within a team.
@@ -15,23 +27,140 @@ type TeamMember struct {
 	ID       string `json:"user_id"`
 	Name     string `json:"username"`
 	IsActive bool   `json:"is_active"`
+	// Role is LEAD, SENIOR or MEMBER (the default).
+	Role string `json:"role"`
+	Profile
 }
 
 // Team represents a team of members.
 type Team struct {
-	Name    string       `json:"team_name"`
+	Name string `json:"team_name"`
+	// OrgName is the organization the team belongs to, if any.
+	OrgName string       `json:"org_name,omitempty"`
 	Members []TeamMember `json:"members"`
 }
 
 // PullRequest represents a pull request entity.
 type PullRequest struct {
-	ID                string     `json:"pull_request_id"`
-	Name              string     `json:"pull_request_name"`
-	AuthorID          string     `json:"author_id"`
-	Status            string     `json:"status"`
-	AssignedReviewers []string   `json:"assigned_reviewers"`
-	CreatedAt         *time.Time `json:"createdAt,omitempty"`
-	MergedAt          *time.Time `json:"mergedAt,omitempty"`
+	ID                string   `json:"pull_request_id"`
+	Name              string   `json:"pull_request_name"`
+	AuthorID          string   `json:"author_id"`
+	Status            string   `json:"status"`
+	AssignedReviewers []string `json:"assigned_reviewers"`
+	Priority          string   `json:"priority"`
+	CoAuthorIDs       []string `json:"co_author_ids,omitempty"`
+	Labels            []string `json:"labels,omitempty"`
+	JiraIssues        []string `json:"jira_issues,omitempty"`
+	// GitHubRepo ("owner/name") and GitHubNumber identify the pull request
+	// on GitHub in GitHub App mode. Both are set or neither.
+	GitHubRepo   string     `json:"github_repo,omitempty"`
+	GitHubNumber int        `json:"github_number,omitempty"`
+	CreatedAt    *time.Time `json:"createdAt,omitempty"`
+	MergedAt     *time.Time `json:"mergedAt,omitempty"`
+	DeletedAt    *time.Time `json:"deletedAt,omitempty"`
+	Deadline     *time.Time `json:"deadline,omitempty"`
+	ArchivedAt   *time.Time `json:"archivedAt,omitempty"`
+	// Security review happens after all regular reviewers approved and blocks merge until done.
+	NeedsSecurityReview  bool       `json:"needs_security_review,omitempty"`
+	SecurityReviewerID   *string    `json:"security_reviewer_id,omitempty"`
+	SecurityApprovedAt   *time.Time `json:"securityApprovedAt,omitempty"`
+	SecurityReviewStatus string     `json:"security_review_status,omitempty"`
+	FirstReviewAt        *time.Time `json:"firstReviewAt,omitempty"`
+	ApprovedAt           *time.Time `json:"approvedAt,omitempty"`
+}
+
+// Pull request priorities.
+const (
+	PriorityLow    = "LOW"
+	PriorityMedium = "MEDIUM"
+	PriorityHigh   = "HIGH"
+)
+
+// IsValidPriority reports whether p is a known pull request priority.
+func IsValidPriority(p string) bool {
+	switch p {
+	case PriorityLow, PriorityMedium, PriorityHigh:
+		return true
+	}
+	return false
+}
+
+// PullRequestInput holds the fields accepted when creating a pull request.
+type PullRequestInput struct {
+	ID       string
+	Name     string
+	AuthorID string
+	Priority string
+	// CoAuthorIDs are excluded from the reviewer candidate pool along with the author.
+	CoAuthorIDs []string
+	Labels      []string
+	JiraIssues  []string
+	// GitHubRepo and GitHubNumber link the pull request to GitHub.
+	GitHubRepo   string
+	GitHubNumber int
+	Deadline     *time.Time
+	// NeedsSecurityReview requires an extra approval from the security team before merge.
+	NeedsSecurityReview bool
+	// IdempotencyKey makes retried requests return the originally created pull request.
+	IdempotencyKey string `json:"-"`
+	// Wait is how long to keep retrying reviewer selection in strict mode
+	// before giving up with NOT_ENOUGH_REVIEWERS (at most MaxCreateWait).
+	Wait time.Duration `json:"-"`
+}
+
+// PullRequestUpdate holds editable pull request fields. Nil fields are left unchanged.
+type PullRequestUpdate struct {
+	ID         string
+	Name       *string
+	Priority   *string
+	Labels     *[]string
+	JiraIssues *[]string
+	// GitHubRepo and GitHubNumber are changed together; an empty repo
+	// with number 0 unlinks the pull request from GitHub.
+	GitHubRepo   *string
+	GitHubNumber *int
+	Deadline     *time.Time
+}
+
+// PullRequestFilter narrows down ListPullRequests results. Zero values are ignored.
+type PullRequestFilter struct {
+	Status     string
+	AuthorID   string
+	TeamName   string
+	ReviewerID string
+	Priority   string
+	// Labels lists labels every returned pull request must carry.
+	Labels      []string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	// IncludeDeleted also returns soft-deleted pull requests.
+	IncludeDeleted bool
+	// IncludeArchived also returns archived merged pull requests.
+	IncludeArchived bool
+	// Sort is one of pull_request_id, created_at or priority,
+	// optionally prefixed with "-" for descending order.
+	Sort string
+}
+
+// Reviewer review statuses.
+const (
+	ReviewStatusPending  = "PENDING"
+	ReviewStatusApproved = "APPROVED"
+)
+
+// ReviewerStatus is the review state of a single assigned reviewer.
+type ReviewerStatus struct {
+	UserID     string     `json:"user_id"`
+	Status     string     `json:"status"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+}
+
+// ReviewSummary summarizes the review state of a pull request.
+type ReviewSummary struct {
+	Reviewers        []ReviewerStatus `json:"reviewers"`
+	Approvals        int              `json:"approvals"`
+	PendingReviewers []string         `json:"pending_reviewers"`
+	TimeOpenSeconds  float64          `json:"time_open_seconds"`
 }
 
 // PullRequestShort represents a short pull request description.
@@ -40,6 +169,12 @@ type PullRequestShort struct {
 	Name     string `json:"pull_request_name"`
 	AuthorID string `json:"author_id"`
 	Status   string `json:"status"`
+	// The fields below are filled by GetUserReviews for the reviewer's queue.
+	Priority  string     `json:"priority,omitempty"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	Deadline  *time.Time `json:"deadline,omitempty"`
+	// ReviewStatus is the requesting reviewer's own status: PENDING or APPROVED.
+	ReviewStatus string `json:"review_status,omitempty"`
 }
 
 // ErrorCode defines a machine-readable application error code.
@@ -53,12 +188,31 @@ const (
 	ErrorCodeNotAssigned ErrorCode = "NOT_ASSIGNED"
 	ErrorCodeNoCandidate ErrorCode = "NO_CANDIDATE"
 	ErrorCodeNotFound    ErrorCode = "NOT_FOUND"
+
+	ErrorCodeIdempotencyKeyReused   ErrorCode = "IDEMPOTENCY_KEY_REUSED"
+	ErrorCodeSecurityReviewRequired ErrorCode = "SECURITY_REVIEW_REQUIRED"
+	ErrorCodeNotEnoughReviewers     ErrorCode = "NOT_ENOUGH_REVIEWERS"
+	ErrorCodeTeamHasOpenPRs         ErrorCode = "TEAM_HAS_OPEN_PRS"
+	ErrorCodeUserExists             ErrorCode = "USER_EXISTS"
+	ErrorCodeOrgExists              ErrorCode = "ORG_EXISTS"
+	ErrorCodeNotEnoughApprovals     ErrorCode = "NOT_ENOUGH_APPROVALS"
+	ErrorCodeValidation             ErrorCode = "VALIDATION"
+	ErrorCodeUserInOtherTeam        ErrorCode = "USER_IN_OTHER_TEAM"
+	ErrorCodeInternal               ErrorCode = "INTERNAL"
+	ErrorCodeUnauthorized           ErrorCode = "UNAUTHORIZED"
+	ErrorCodeForbidden              ErrorCode = "FORBIDDEN"
+	ErrorCodeRateLimited            ErrorCode = "RATE_LIMITED"
+	ErrorCodeUnsupportedMediaType   ErrorCode = "UNSUPPORTED_MEDIA_TYPE"
+	ErrorCodeTimeout                ErrorCode = "TIMEOUT"
+	ErrorCodeEventInProgress        ErrorCode = "EVENT_IN_PROGRESS"
 )
 
 // Error represents a domain error with a code and message.
 type Error struct {
 	Code    ErrorCode
 	Message string
+	// Fields lists invalid payload fields for VALIDATION errors.
+	Fields []FieldError
 }
 
 // Error returns the error message.
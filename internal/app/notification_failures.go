@@ -0,0 +1,96 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// NotificationFailure is an outbox message whose channel gave up on it.
+type NotificationFailure struct {
+	ID        int64           `json:"failure_id"`
+	MessageID int64           `json:"message_id"`
+	Kind      string          `json:"kind"`
+	Channel   string          `json:"channel"`
+	Payload   json.RawMessage `json:"payload"`
+	Attempts  int             `json:"attempts"`
+	LastError string          `json:"last_error"`
+	CreatedAt time.Time       `json:"created_at"`
+	FailedAt  time.Time       `json:"failed_at"`
+}
+
+// ListNotificationFailures returns a page of dead-lettered messages, newest
+// first, optionally of one channel, and the cursor key of the next page.
+func (s *Service) ListNotificationFailures(ctx context.Context, channel string, page Page) ([]NotificationFailure, string, error) {
+	var after *int64
+	if page.After != "" {
+		id, err := strconv.ParseInt(page.After, 10, 64)
+		if err != nil {
+			return nil, "", &Error{Code: ErrorCodeValidation, Message: "cursor is invalid"}
+		}
+		after = &id
+	}
+
+	const query = `
+SELECT failure_id, message_id, kind, channel, payload, attempts, last_error, created_at, failed_at
+FROM notification_failures
+WHERE ($1 = '' OR channel = $1)
+  AND ($2::BIGINT IS NULL OR failure_id < $2)
+ORDER BY failure_id DESC
+LIMIT $3 OFFSET $4
+`
+	rows, err := s.db.QueryContext(ctx, query, channel, after, page.fetchLimit(), page.Offset)
+	if err != nil {
+		return nil, "", fmt.Errorf("list notification failures: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	failures := make([]NotificationFailure, 0)
+	for rows.Next() {
+		var f NotificationFailure
+		var payload []byte
+		if err := rows.Scan(&f.ID, &f.MessageID, &f.Kind, &f.Channel, &payload, &f.Attempts, &f.LastError,
+			&f.CreatedAt, &f.FailedAt); err != nil {
+			return nil, "", fmt.Errorf("scan notification failure: %w", err)
+		}
+		f.Payload = payload
+		failures = append(failures, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("notification failures rows: %w", err)
+	}
+
+	failures, next := cutPage(failures, page, func(f NotificationFailure) string { return strconv.FormatInt(f.ID, 10) })
+	return failures, next, nil
+}
+
+// ReplayNotificationFailure moves a dead-lettered message back to the
+// outbox with a fresh retry budget and returns its new outbox message ID.
+func (s *Service) ReplayNotificationFailure(ctx context.Context, failureID int64) (int64, error) {
+	const query = `
+WITH replayed AS (
+    DELETE FROM notification_failures
+    WHERE failure_id = $1
+    RETURNING kind, channel, payload
+)
+INSERT INTO outbox (kind, channel, payload)
+SELECT kind, channel, payload
+FROM replayed
+RETURNING message_id
+`
+	var messageID int64
+	if err := s.db.QueryRowContext(ctx, query, failureID).Scan(&messageID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, &Error{Code: ErrorCodeNotFound, Message: "notification failure not found"}
+		}
+		return 0, fmt.Errorf("replay notification failure: %w", err)
+	}
+	s.kickOutbox()
+	return messageID, nil
+}
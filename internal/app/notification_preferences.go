@@ -0,0 +1,69 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// NotificationPreferences selects which notifications a user receives.
+type NotificationPreferences struct {
+	UserID string `json:"user_id"`
+	// Slack and Email enable assignment notices in each channel.
+	Slack bool `json:"slack"`
+	Email bool `json:"email"`
+	// EmailDigest enables the daily e-mail digest of open reviews.
+	EmailDigest bool `json:"email_digest"`
+}
+
+// NotificationPreferencesUpdate holds changed preferences. Nil fields are
+// left unchanged.
+type NotificationPreferencesUpdate struct {
+	UserID      string
+	Slack       *bool
+	Email       *bool
+	EmailDigest *bool
+}
+
+// GetNotificationPreferences returns the notification preferences of a user.
+func (s *Service) GetNotificationPreferences(ctx context.Context, userID string) (NotificationPreferences, error) {
+	const query = `
+SELECT user_id, notify_slack, notify_email, email_digest
+FROM users
+WHERE user_id = $1
+  AND deleted_at IS NULL
+`
+	var p NotificationPreferences
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(&p.UserID, &p.Slack, &p.Email, &p.EmailDigest)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return NotificationPreferences{}, &Error{Code: ErrorCodeNotFound, Message: "user not found"}
+		}
+		return NotificationPreferences{}, fmt.Errorf("get notification preferences: %w", err)
+	}
+	return p, nil
+}
+
+// UpdateNotificationPreferences changes the notification preferences of a user.
+func (s *Service) UpdateNotificationPreferences(ctx context.Context, upd NotificationPreferencesUpdate) (NotificationPreferences, error) {
+	const query = `
+UPDATE users
+SET notify_slack = COALESCE($2, notify_slack),
+    notify_email = COALESCE($3, notify_email),
+    email_digest = COALESCE($4, email_digest)
+WHERE user_id = $1
+  AND deleted_at IS NULL
+RETURNING user_id, notify_slack, notify_email, email_digest
+`
+	var p NotificationPreferences
+	err := s.db.QueryRowContext(ctx, query, upd.UserID, upd.Slack, upd.Email, upd.EmailDigest).
+		Scan(&p.UserID, &p.Slack, &p.Email, &p.EmailDigest)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return NotificationPreferences{}, &Error{Code: ErrorCodeNotFound, Message: "user not found"}
+		}
+		return NotificationPreferences{}, fmt.Errorf("update notification preferences: %w", err)
+	}
+	return p, nil
+}
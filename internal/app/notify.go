@@ -0,0 +1,161 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// AssignmentNotice tells a reviewer that they were assigned to or removed
+// from a pull request.
+type AssignmentNotice struct {
+	PullRequest PullRequest
+	Reviewer    User
+	// EventType is AssignmentEventAssigned or AssignmentEventReplaced.
+	EventType string
+	Reason    string
+	// ReplacedBy is the reviewer taking over on AssignmentEventReplaced.
+	ReplacedBy string
+	// Preferences tell notifiers whether the reviewer wants their channel.
+	Preferences NotificationPreferences
+}
+
+// Notifier delivers assignment notices, e.g. as Slack messages. Notices are
+// queued in the outbox with the assignment, one per notifier, and delivered
+// afterwards; a returned error makes the outbox retry the notice on this
+// notifier's channel only.
+type Notifier interface {
+	// Channel names the notifier in the outbox and in retry policies,
+	// e.g. "slack"; it must be unique and stable across restarts.
+	Channel() string
+	NotifyAssignment(ctx context.Context, notice AssignmentNotice) error
+}
+
+// WithNotifier sends assignment notices to n in addition to notifiers
+// added before.
+func WithNotifier(n Notifier) Option {
+	return func(s *Service) {
+		s.notifiers = append(s.notifiers, n)
+	}
+}
+
+// assignmentNoticeMessage is the outbox payload of an assignment notice.
+// Pull request and reviewer details are loaded on delivery.
+type assignmentNoticeMessage struct {
+	PullRequestID string `json:"pull_request_id"`
+	UserID        string `json:"user_id"`
+	EventType     string `json:"event_type"`
+	Reason        string `json:"reason"`
+	ReplacedBy    string `json:"replaced_by,omitempty"`
+}
+
+// enqueueAssigned queues notices for newly assigned reviewers in the
+// transaction that assigns them.
+func (s *Service) enqueueAssigned(ctx context.Context, q querier, prID string, userIDs []string, reason string) error {
+	for _, id := range userIDs {
+		msg := assignmentNoticeMessage{PullRequestID: prID, UserID: id, EventType: AssignmentEventAssigned, Reason: reason}
+		if err := s.enqueueNotice(ctx, q, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enqueueReplaced queues notices for both reviewers of a replacement.
+func (s *Service) enqueueReplaced(ctx context.Context, q querier, prID, oldUserID, newUserID, reason string) error {
+	msg := assignmentNoticeMessage{
+		PullRequestID: prID,
+		UserID:        oldUserID,
+		EventType:     AssignmentEventReplaced,
+		Reason:        reason,
+		ReplacedBy:    newUserID,
+	}
+	if err := s.enqueueNotice(ctx, q, msg); err != nil {
+		return err
+	}
+	return s.enqueueAssigned(ctx, q, prID, []string{newUserID}, reason)
+}
+
+// enqueueNotice queues a notice for every notifier's channel and the
+// matching webhook event.
+func (s *Service) enqueueNotice(ctx context.Context, q querier, msg assignmentNoticeMessage) error {
+	for _, n := range s.notifiers {
+		if err := enqueueOutbox(ctx, q, OutboxKindAssignmentNotice, n.Channel(), msg); err != nil {
+			return err
+		}
+	}
+
+	eventType := WebhookEventReviewAssigned
+	if msg.EventType == AssignmentEventReplaced {
+		eventType = WebhookEventReviewReplaced
+	}
+	data := map[string]any{"pull_request_id": msg.PullRequestID, "reviewer_id": msg.UserID, "reason": msg.Reason}
+	if msg.ReplacedBy != "" {
+		data["replaced_by"] = msg.ReplacedBy
+	}
+	return s.enqueueWebhookEvent(ctx, q, eventType, data)
+}
+
+// deliverAssignmentNotice fills in the pull request and the reviewer's
+// contacts and preferences and hands the notice to the notifier of channel,
+// or to every notifier for messages queued without one. Notices about
+// deleted pull requests or users are dropped.
+func (s *Service) deliverAssignmentNotice(ctx context.Context, q querier, channel string, msg assignmentNoticeMessage) error {
+	notifiers := s.notifiers
+	if channel != "" {
+		notifiers = nil
+		for _, n := range s.notifiers {
+			if n.Channel() == channel {
+				notifiers = append(notifiers, n)
+			}
+		}
+		if len(notifiers) == 0 {
+			return fmt.Errorf("notification channel %q is not configured", channel)
+		}
+	}
+
+	pr, err := s.getPullRequest(ctx, q, msg.PullRequestID)
+	if err != nil {
+		var appErr *Error
+		if errors.As(err, &appErr) && appErr.Code == ErrorCodeNotFound {
+			return nil
+		}
+		return err
+	}
+
+	const query = `
+SELECT user_id, username, COALESCE(team_name, ''), is_active, role, email, slack_handle, avatar_url, github_login,
+       notify_slack, notify_email, email_digest
+FROM users
+WHERE user_id = $1
+  AND deleted_at IS NULL
+`
+	var u User
+	var p NotificationPreferences
+	err = q.QueryRowContext(ctx, query, msg.UserID).Scan(&u.ID, &u.Name, &u.TeamName, &u.IsActive, &u.Role,
+		&u.Email, &u.SlackHandle, &u.AvatarURL, &u.GitHubLogin, &p.Slack, &p.Email, &p.EmailDigest)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("get notified user: %w", err)
+	}
+	p.UserID = u.ID
+
+	notice := AssignmentNotice{
+		PullRequest: pr,
+		Reviewer:    u,
+		EventType:   msg.EventType,
+		Reason:      msg.Reason,
+		ReplacedBy:  msg.ReplacedBy,
+		Preferences: p,
+	}
+	var errs []error
+	for _, n := range notifiers {
+		if err := n.NotifyAssignment(ctx, notice); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
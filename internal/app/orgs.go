@@ -0,0 +1,142 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Organization groups teams, e.g. a department.
+type Organization struct {
+	Name      string    `json:"org_name"`
+	Teams     []string  `json:"teams"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// CreateOrganization creates an empty organization.
+func (s *Service) CreateOrganization(ctx context.Context, name string) (Organization, error) {
+	const query = `
+INSERT INTO organizations(org_name)
+VALUES ($1)
+ON CONFLICT (org_name) DO NOTHING
+RETURNING created_at
+`
+	org := Organization{Name: name, Teams: []string{}}
+	err := s.db.QueryRowContext(ctx, query, name).Scan(&org.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Organization{}, &Error{Code: ErrorCodeOrgExists, Message: "org_name already exists"}
+		}
+		return Organization{}, fmt.Errorf("insert organization: %w", err)
+	}
+	return org, nil
+}
+
+// GetOrganization returns an organization with the names of its teams.
+func (s *Service) GetOrganization(ctx context.Context, name string) (Organization, error) {
+	const query = `
+SELECT o.org_name, o.created_at,
+       COALESCE(array_agg(t.team_name ORDER BY t.team_name) FILTER (WHERE t.team_name IS NOT NULL), '{}')
+FROM organizations o
+LEFT JOIN teams t ON t.org_name = o.org_name AND t.archived_at IS NULL
+WHERE o.org_name = $1
+GROUP BY o.org_name, o.created_at
+`
+	var org Organization
+	err := s.db.QueryRowContext(ctx, query, name).Scan(&org.Name, &org.CreatedAt, pq.Array(&org.Teams))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Organization{}, &Error{Code: ErrorCodeNotFound, Message: "organization not found"}
+		}
+		return Organization{}, fmt.Errorf("get organization: %w", err)
+	}
+	return org, nil
+}
+
+// SetTeamOrganization moves a team into an organization. An empty orgName
+// detaches the team.
+func (s *Service) SetTeamOrganization(ctx context.Context, teamName, orgName string) (Team, error) {
+	if orgName != "" {
+		if err := checkOrganizationExists(ctx, s.db, orgName); err != nil {
+			return Team{}, err
+		}
+	}
+
+	const query = `UPDATE teams SET org_name = NULLIF($2, '') WHERE team_name = $1 AND archived_at IS NULL`
+	res, err := s.db.ExecContext(ctx, query, teamName, orgName)
+	if err != nil {
+		return Team{}, fmt.Errorf("set team organization: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return Team{}, fmt.Errorf("set team organization: %w", err)
+	}
+	if n == 0 {
+		return Team{}, &Error{Code: ErrorCodeNotFound, Message: "team not found"}
+	}
+
+	return s.GetTeam(ctx, teamName)
+}
+
+func checkOrganizationExists(ctx context.Context, q querier, orgName string) error {
+	var exists bool
+	err := q.QueryRowContext(ctx, `SELECT TRUE FROM organizations WHERE org_name = $1`, orgName).Scan(&exists)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &Error{Code: ErrorCodeNotFound, Message: "organization not found"}
+		}
+		return fmt.Errorf("check organization: %w", err)
+	}
+	return nil
+}
+
+// orgAuthorsQuery selects members of the organization passed as $1, or all
+// users when it is empty.
+const orgAuthorsQuery = `
+SELECT u.user_id
+FROM users u
+LEFT JOIN teams t ON t.team_name = u.team_name
+WHERE $1 = '' OR t.org_name = $1`
+
+// orgFallbackReviewers returns up to limit active members of other teams in
+// the same organization as teamName, skipping the excluded users.
+func orgFallbackReviewers(ctx context.Context, q querier, teamName string, exclude []string, excludeLeads bool, limit int) ([]string, error) {
+	const query = `
+SELECT u.user_id
+FROM users u
+JOIN teams t ON t.team_name = u.team_name
+JOIN teams own ON own.org_name = t.org_name
+WHERE own.team_name = $1
+  AND t.team_name <> $1
+  AND t.archived_at IS NULL
+  AND u.is_active = TRUE
+  AND NOT (u.user_id = ANY($2))
+  AND NOT ($3 AND u.role = 'LEAD')
+ORDER BY t.team_name, u.user_id
+LIMIT $4
+`
+	rows, err := q.QueryContext(ctx, query, teamName, pq.Array(exclude), excludeLeads, limit)
+	if err != nil {
+		return nil, fmt.Errorf("select org fallback reviewers: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan org fallback reviewer: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("org fallback reviewers rows: %w", err)
+	}
+	return ids, nil
+}
@@ -0,0 +1,338 @@
+package app
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Kinds of outbox messages.
+const (
+	OutboxKindAssignmentNotice = "ASSIGNMENT_NOTICE"
+	// OutboxKindJiraMerge updates an issue linked to a merged pull request.
+	OutboxKindJiraMerge = "JIRA_MERGE"
+	// OutboxKindWebhook delivers an event to one webhook subscription.
+	OutboxKindWebhook = "WEBHOOK"
+	// OutboxKindCodeHostSync mirrors the reviewers of a pull request on its
+	// code host.
+	OutboxKindCodeHostSync = "CODE_HOST_SYNC"
+)
+
+const (
+	// outboxBatchSize caps the messages claimed by one dispatch.
+	outboxBatchSize = 100
+	// outboxLease is how long claimed messages stay hidden from other
+	// dispatches; messages of a dispatcher that died are retried after it.
+	outboxLease = 5 * time.Minute
+	// outboxKickTimeout bounds the dispatch started right after a change.
+	outboxKickTimeout = 30 * time.Second
+	// defaultOutboxWorkers is the number of concurrent deliveries of a dispatch.
+	defaultOutboxWorkers = 4
+)
+
+// RetryPolicy controls how a delivery channel retries failed messages.
+// Attempt n is retried after 2^n seconds, capped by MaxBackoff; a message
+// still failing after MaxAttempts attempts moves to the dead-letter table.
+type RetryPolicy struct {
+	// MaxAttempts of zero retries forever.
+	MaxAttempts int
+	MaxBackoff  time.Duration
+}
+
+// DefaultRetryPolicy applies to channels without a policy of their own.
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 15, MaxBackoff: time.Hour}
+
+// WithRetryPolicy sets the retry policy of a delivery channel: a notifier's
+// Channel, JiraChannel or WebhookChannel. Zero MaxBackoff keeps the default one.
+func WithRetryPolicy(channel string, p RetryPolicy) Option {
+	return func(s *Service) {
+		if s.retryPolicies == nil {
+			s.retryPolicies = make(map[string]RetryPolicy)
+		}
+		if p.MaxBackoff <= 0 {
+			p.MaxBackoff = DefaultRetryPolicy.MaxBackoff
+		}
+		s.retryPolicies[channel] = p
+	}
+}
+
+// WithOutboxWorkers sets how many messages a dispatch delivers concurrently.
+func WithOutboxWorkers(n int) Option {
+	return func(s *Service) {
+		if n > 0 {
+			s.outboxWorkers = n
+		}
+	}
+}
+
+func (s *Service) retryPolicy(channel string) RetryPolicy {
+	if p, ok := s.retryPolicies[channel]; ok {
+		return p
+	}
+	return DefaultRetryPolicy
+}
+
+// OutboxRun reports the result of one outbox dispatch.
+type OutboxRun struct {
+	Delivered int `json:"delivered"`
+	Failed    int `json:"failed"`
+	// DeadLettered counts failed messages that ran out of attempts.
+	DeadLettered int `json:"dead_lettered"`
+}
+
+// enqueueOutbox stores a side effect of a change in the change's transaction,
+// so that it is delivered exactly when the change is committed.
+func enqueueOutbox(ctx context.Context, q querier, kind, channel string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode outbox message: %w", err)
+	}
+	const query = `INSERT INTO outbox(kind, channel, payload) VALUES ($1, $2, $3)`
+	if _, err := q.ExecContext(ctx, query, kind, channel, data); err != nil {
+		return fmt.Errorf("insert outbox message: %w", err)
+	}
+	return nil
+}
+
+// kickOutbox delivers freshly committed messages without waiting for the
+// periodic dispatch, which retries whatever this misses.
+func (s *Service) kickOutbox() {
+	if len(s.notifiers) == 0 && s.issueTracker == nil && s.webhookSender == nil && s.codeHost == nil {
+		return
+	}
+	s.outboxKicks.Add(1)
+	go func() {
+		defer s.outboxKicks.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), outboxKickTimeout)
+		defer cancel()
+		if _, err := s.DispatchOutbox(ctx); err != nil {
+			s.logger.Error("dispatch outbox", "error", err)
+		}
+	}()
+}
+
+// DrainOutbox waits for the dispatches started after writes and delivers
+// the messages that are due, so that a shutdown does not leave fresh
+// notifications until the next start. It gives up when ctx is done.
+func (s *Service) DrainOutbox(ctx context.Context) (OutboxRun, error) {
+	kicked := make(chan struct{})
+	go func() {
+		s.outboxKicks.Wait()
+		close(kicked)
+	}()
+	select {
+	case <-kicked:
+	case <-ctx.Done():
+		return OutboxRun{}, fmt.Errorf("wait for outbox dispatches: %w", ctx.Err())
+	}
+	if len(s.notifiers) == 0 && s.issueTracker == nil && s.webhookSender == nil && s.codeHost == nil {
+		return OutboxRun{}, nil
+	}
+	return s.DispatchOutbox(ctx)
+}
+
+// outboxMessage is a claimed outbox message.
+type outboxMessage struct {
+	id       int64
+	kind     string
+	channel  string
+	payload  []byte
+	attempts int
+}
+
+// DispatchOutbox claims due outbox messages and delivers them with a pool of
+// workers, so a slow channel does not hold up the others. Failed messages
+// are retried as their channel's RetryPolicy says and end up in the
+// dead-letter table when it gives up; messages claimed by a concurrent
+// dispatch are skipped.
+func (s *Service) DispatchOutbox(ctx context.Context) (OutboxRun, error) {
+	// Messages wait in the outbox while notifications are switched off.
+	enabled, err := s.featureEnabled(ctx, FeatureNotifications)
+	if err != nil || !enabled {
+		return OutboxRun{}, err
+	}
+
+	messages, err := s.claimOutboxMessages(ctx)
+	if err != nil {
+		return OutboxRun{}, err
+	}
+
+	var (
+		mu    sync.Mutex
+		run   OutboxRun
+		errs  []error
+		wg    sync.WaitGroup
+		queue = make(chan outboxMessage)
+	)
+	workers := s.outboxWorkers
+	if workers == 0 {
+		workers = defaultOutboxWorkers
+	}
+	for range min(workers, len(messages)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for m := range queue {
+				outcome, err := s.processOutboxMessage(ctx, m)
+				mu.Lock()
+				switch {
+				case err != nil:
+					errs = append(errs, err)
+				case outcome == outboxDelivered:
+					run.Delivered++
+				case outcome == outboxDeadLettered:
+					run.DeadLettered++
+				default:
+					run.Failed++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, m := range messages {
+		queue <- m
+	}
+	close(queue)
+	wg.Wait()
+
+	return run, errors.Join(errs...)
+}
+
+// claimOutboxMessages picks due messages and leases them to this dispatch.
+func (s *Service) claimOutboxMessages(ctx context.Context) ([]outboxMessage, error) {
+	const query = `
+UPDATE outbox
+SET next_attempt_at = NOW() + $2 * INTERVAL '1 second'
+WHERE message_id IN (
+    SELECT message_id
+    FROM outbox
+    WHERE delivered_at IS NULL
+      AND next_attempt_at <= NOW()
+    ORDER BY message_id
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING message_id, kind, channel, payload, attempts
+`
+	rows, err := s.db.QueryContext(ctx, query, outboxBatchSize, outboxLease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("claim outbox messages: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var messages []outboxMessage
+	for rows.Next() {
+		var m outboxMessage
+		if err := rows.Scan(&m.id, &m.kind, &m.channel, &m.payload, &m.attempts); err != nil {
+			return nil, fmt.Errorf("scan outbox message: %w", err)
+		}
+		messages = append(messages, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("outbox rows: %w", err)
+	}
+	slices.SortFunc(messages, func(a, b outboxMessage) int { return cmp.Compare(a.id, b.id) })
+	return messages, nil
+}
+
+type outboxOutcome int
+
+const (
+	outboxDelivered outboxOutcome = iota
+	outboxRetried
+	outboxDeadLettered
+)
+
+// processOutboxMessage delivers a claimed message and records the outcome.
+func (s *Service) processOutboxMessage(ctx context.Context, m outboxMessage) (outboxOutcome, error) {
+	deliveryErr := s.deliverOutboxMessage(ctx, s.db, m)
+	if deliveryErr == nil {
+		const doneQuery = `UPDATE outbox SET attempts = attempts + 1, delivered_at = NOW() WHERE message_id = $1`
+		if _, err := s.db.ExecContext(ctx, doneQuery, m.id); err != nil {
+			return 0, fmt.Errorf("mark outbox message delivered: %w", err)
+		}
+		return outboxDelivered, nil
+	}
+
+	attempts := m.attempts + 1
+	policy := s.retryPolicy(m.channel)
+	if policy.MaxAttempts > 0 && attempts >= policy.MaxAttempts {
+		const deadLetterQuery = `
+WITH failed AS (
+    DELETE FROM outbox
+    WHERE message_id = $1
+    RETURNING message_id, kind, channel, payload, created_at
+)
+INSERT INTO notification_failures (message_id, kind, channel, payload, attempts, last_error, created_at)
+SELECT message_id, kind, channel, payload, $2, $3, created_at
+FROM failed
+`
+		if _, err := s.db.ExecContext(ctx, deadLetterQuery, m.id, attempts, deliveryErr.Error()); err != nil {
+			return 0, fmt.Errorf("dead-letter outbox message: %w", err)
+		}
+		s.log(ctx).Error("outbox message dead-lettered", "message_id", m.id, "kind", m.kind, "channel", m.channel,
+			"attempts", attempts, "error", deliveryErr)
+		return outboxDeadLettered, nil
+	}
+
+	const failQuery = `
+UPDATE outbox
+SET attempts = $2,
+    next_attempt_at = NOW() + $3 * INTERVAL '1 second',
+    last_error = $4
+WHERE message_id = $1
+`
+	backoff := outboxBackoff(attempts, policy.MaxBackoff)
+	if _, err := s.db.ExecContext(ctx, failQuery, m.id, attempts, backoff.Seconds(), deliveryErr.Error()); err != nil {
+		return 0, fmt.Errorf("record outbox failure: %w", err)
+	}
+	s.log(ctx).Warn("outbox delivery failed", "message_id", m.id, "kind", m.kind, "channel", m.channel,
+		"attempts", attempts, "retry_in", backoff, "error", deliveryErr)
+	return outboxRetried, nil
+}
+
+func (s *Service) deliverOutboxMessage(ctx context.Context, q querier, m outboxMessage) error {
+	switch m.kind {
+	case OutboxKindAssignmentNotice:
+		var msg assignmentNoticeMessage
+		if err := json.Unmarshal(m.payload, &msg); err != nil {
+			return fmt.Errorf("decode assignment notice: %w", err)
+		}
+		return s.deliverAssignmentNotice(ctx, q, m.channel, msg)
+	case OutboxKindJiraMerge:
+		var msg jiraMergeMessage
+		if err := json.Unmarshal(m.payload, &msg); err != nil {
+			return fmt.Errorf("decode jira merge: %w", err)
+		}
+		return s.deliverJiraMerge(ctx, q, msg)
+	case OutboxKindWebhook:
+		var msg webhookMessage
+		if err := json.Unmarshal(m.payload, &msg); err != nil {
+			return fmt.Errorf("decode webhook: %w", err)
+		}
+		return s.deliverWebhook(ctx, q, msg)
+	case OutboxKindCodeHostSync:
+		var msg codeHostSyncMessage
+		if err := json.Unmarshal(m.payload, &msg); err != nil {
+			return fmt.Errorf("decode code host sync: %w", err)
+		}
+		return s.deliverCodeHostSync(ctx, q, msg)
+	}
+	return fmt.Errorf("unknown outbox message kind %q", m.kind)
+}
+
+// outboxBackoff is the delay before the given delivery attempt: 2^attempt
+// seconds, capped by maxBackoff.
+func outboxBackoff(attempt int, maxBackoff time.Duration) time.Duration {
+	if attempt > 30 {
+		return maxBackoff
+	}
+	return min(time.Duration(1<<attempt)*time.Second, maxBackoff)
+}
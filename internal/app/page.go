@@ -0,0 +1,30 @@
+package app
+
+// Page limits a list. A zero Limit returns all rows. After is the cursor key
+// of the last row of the previous page; lists continue right after that row
+// in their current order.
+type Page struct {
+	Limit  int
+	Offset int
+	After  string
+}
+
+// fetchLimit returns the LIMIT argument of the page: one row more than the
+// page size to tell whether another page follows, or nil for all rows.
+func (p Page) fetchLimit() *int {
+	if p.Limit == 0 {
+		return nil
+	}
+	n := p.Limit + 1
+	return &n
+}
+
+// cutPage drops the extra row fetched by fetchLimit and returns the cursor
+// key of the last kept item when another page follows.
+func cutPage[T any](items []T, p Page, key func(T) string) ([]T, string) {
+	if p.Limit == 0 || len(items) <= p.Limit {
+		return items, ""
+	}
+	items = items[:p.Limit]
+	return items, key(items[len(items)-1])
+}
@@ -0,0 +1,32 @@
+package app
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// SQLSTATE codes the service handles explicitly.
+const (
+	// pqUniqueViolation is reported when a concurrent request inserted the
+	// same key first, e.g. two replicas creating one team.
+	pqUniqueViolation = "23505"
+	// pqQueryCanceled is reported for a statement cancelled by the client,
+	// which is how lib/pq reports a query outliving its context.
+	pqQueryCanceled = "57014"
+	// pqUndefinedTable is reported for a missing table.
+	pqUndefinedTable = "42P01"
+)
+
+// hasPQCode reports whether err wraps a PostgreSQL error with the SQLSTATE code.
+func hasPQCode(err error, code string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && string(pqErr.Code) == code
+}
+
+// isUniqueViolation reports whether err comes from a unique constraint.
+// Existence checks before an insert only give a friendly error for the
+// common case; the constraint is what settles races between requests.
+func isUniqueViolation(err error) bool {
+	return hasPQCode(err, pqUniqueViolation)
+}
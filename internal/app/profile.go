@@ -0,0 +1,44 @@
+package app
+
+import (
+	"net/mail"
+	"net/url"
+	"strings"
+)
+
+// MaxSlackHandleLength is the longest Slack handle accepted in a profile.
+const MaxSlackHandleLength = 80
+
+// ValidateProfile checks profile fields and returns a message for the
+// client, or an empty string if the profile is valid. Empty fields are allowed.
+func ValidateProfile(p Profile) string {
+	if errs := profileFieldErrors(p); len(errs) > 0 {
+		return errs[0].Field + " " + errs[0].Message
+	}
+	return ""
+}
+
+func profileFieldErrors(p Profile) []FieldError {
+	var errs []FieldError
+	if p.Email != "" {
+		addr, err := mail.ParseAddress(p.Email)
+		if err != nil || addr.Address != p.Email {
+			errs = append(errs, FieldError{Field: "email", Message: "must be a plain e-mail address"})
+		}
+	}
+	if p.SlackHandle != "" {
+		if len(p.SlackHandle) > MaxSlackHandleLength || strings.ContainsAny(p.SlackHandle, " \t\n") {
+			errs = append(errs, FieldError{Field: "slack_handle", Message: "must be a single word of up to 80 characters"})
+		}
+	}
+	if p.AvatarURL != "" {
+		u, err := url.Parse(p.AvatarURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, FieldError{Field: "avatar_url", Message: "must be an absolute http(s) URL"})
+		}
+	}
+	if p.GitHubLogin != "" && !IsValidGitHubLogin(p.GitHubLogin) {
+		errs = append(errs, FieldError{Field: "github_login", Message: "must be a GitHub login"})
+	}
+	return errs
+}
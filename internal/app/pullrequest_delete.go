@@ -0,0 +1,28 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// DeletePullRequest soft-deletes a pull request. Deleted pull requests are
+// hidden from lists, reviews and stats but kept along with their history.
+// Deleting an already deleted pull request returns it unchanged.
+func (s *Service) DeletePullRequest(ctx context.Context, prID string) (PullRequest, error) {
+	const query = `
+UPDATE pull_requests
+SET deleted_at = COALESCE(deleted_at, NOW())
+WHERE pull_request_id = $1
+RETURNING ` + pullRequestColumns
+	pr, err := s.scanPullRequest(s.db.QueryRowContext(ctx, s.expandReviewers(query), prID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return PullRequest{}, &Error{Code: ErrorCodeNotFound, Message: "pull request not found"}
+		}
+		return PullRequest{}, fmt.Errorf("delete pull request: %w", err)
+	}
+	s.publishQueueChange(queueUsers(pr)...)
+	return pr, nil
+}
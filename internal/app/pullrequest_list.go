@@ -0,0 +1,142 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// priorityRank orders priorities from LOW to HIGH.
+const priorityRank = `CASE priority WHEN 'HIGH' THEN 3 WHEN 'MEDIUM' THEN 2 ELSE 1 END`
+
+var pullRequestSorts = map[string]string{
+	"pull_request_id": "pull_request_id",
+	"created_at":      "created_at",
+	"priority":        priorityRank,
+}
+
+// ListPullRequests returns a page of pull requests matching the filter and
+// the cursor key of the next page, empty on the last page.
+func (s *Service) ListPullRequests(ctx context.Context, f PullRequestFilter, page Page) ([]PullRequest, string, error) {
+	orderBy, err := pullRequestOrderBy(f.Sort)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var where whereBuilder
+	if !f.IncludeDeleted {
+		where.addRaw("deleted_at IS NULL")
+	}
+	if !f.IncludeArchived {
+		where.addRaw("archived_at IS NULL")
+	}
+	if f.Status != "" {
+		where.add("status = ?", f.Status)
+	}
+	if f.AuthorID != "" {
+		where.add("author_id = ?", f.AuthorID)
+	}
+	if f.TeamName != "" {
+		where.add("author_id IN (SELECT user_id FROM users WHERE team_name = ?)", f.TeamName)
+	}
+	if f.ReviewerID != "" {
+		where.add(`(? = ANY({reviewers:pull_requests}) OR EXISTS (
+    SELECT 1
+    FROM pull_request_merge_snapshots ms
+    WHERE ms.pull_request_id = pull_requests.pull_request_id
+      AND ms.user_id = ?
+))`, f.ReviewerID)
+	}
+	if f.Priority != "" {
+		where.add("priority = ?", f.Priority)
+	}
+	if labels := NormalizeLabels(f.Labels); len(labels) > 0 {
+		where.add("labels @> ?", pq.Array(labels))
+	}
+	if f.CreatedFrom != nil {
+		where.add("created_at >= ?", *f.CreatedFrom)
+	}
+	if f.CreatedTo != nil {
+		where.add("created_at < ?", *f.CreatedTo)
+	}
+
+	if page.After != "" {
+		where.add(pullRequestAfter(f.Sort), page.After)
+	}
+
+	args := append(where.args, page.fetchLimit(), page.Offset)
+	query := `SELECT ` + pullRequestColumns + ` FROM pull_requests ` + where.String() + ` ORDER BY ` + orderBy +
+		fmt.Sprintf(` LIMIT $%d OFFSET $%d`, len(args)-1, len(args))
+	rows, err := s.db.QueryContext(ctx, s.expandReviewers(query), args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("list pull requests: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	prs := make([]PullRequest, 0)
+	for rows.Next() {
+		pr, err := s.scanPullRequest(rows)
+		if err != nil {
+			return nil, "", fmt.Errorf("scan pull request: %w", err)
+		}
+		prs = append(prs, pr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("pull requests rows: %w", err)
+	}
+
+	prs, next := cutPage(prs, page, func(pr PullRequest) string { return pr.ID })
+	return prs, next, nil
+}
+
+// IsValidPullRequestSort reports whether sort is accepted by ListPullRequests.
+func IsValidPullRequestSort(sort string) bool {
+	_, ok := pullRequestSorts[strings.TrimPrefix(sort, "-")]
+	return sort == "" || ok
+}
+
+func pullRequestOrderBy(sort string) (string, error) {
+	if sort == "" {
+		return "pull_request_id", nil
+	}
+
+	dir := "ASC"
+	key := sort
+	if strings.HasPrefix(sort, "-") {
+		dir = "DESC"
+		key = sort[1:]
+	}
+
+	expr, ok := pullRequestSorts[key]
+	if !ok {
+		return "", fmt.Errorf("unknown sort field %q", key)
+	}
+	if key == "pull_request_id" {
+		return expr + " " + dir, nil
+	}
+	return expr + " " + dir + ", pull_request_id", nil
+}
+
+// pullRequestAfter returns the condition selecting rows that follow the
+// cursor pull request in the given sort order. Sort keys of the cursor are
+// read from its current row, so the condition takes a single argument.
+func pullRequestAfter(sort string) string {
+	op := ">"
+	key := sort
+	if strings.HasPrefix(sort, "-") {
+		op = "<"
+		key = sort[1:]
+	}
+	if key == "" || key == "pull_request_id" {
+		return "pull_request_id " + op + " ?"
+	}
+
+	expr := pullRequestSorts[key]
+	cursor := `(SELECT ` + expr + ` FROM pull_requests WHERE pull_request_id = ?)`
+	return `(` + expr + ` ` + op + ` ` + cursor + `
+    OR (` + expr + ` = ` + cursor + ` AND pull_request_id > ?))`
+}
@@ -0,0 +1,30 @@
+package app
+
+import (
+	"strconv"
+	"strings"
+)
+
+// whereBuilder assembles a WHERE clause with positional arguments.
+// Conditions use "?" as a placeholder for their single argument.
+type whereBuilder struct {
+	conds []string
+	args  []any
+}
+
+func (b *whereBuilder) add(cond string, arg any) {
+	b.args = append(b.args, arg)
+	b.conds = append(b.conds, strings.ReplaceAll(cond, "?", "$"+strconv.Itoa(len(b.args))))
+}
+
+// addRaw adds a condition without arguments.
+func (b *whereBuilder) addRaw(cond string) {
+	b.conds = append(b.conds, cond)
+}
+
+func (b *whereBuilder) String() string {
+	if len(b.conds) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(b.conds, "\n  AND ")
+}
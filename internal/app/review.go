@@ -0,0 +1,149 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ApprovePullRequest records an approval from an assigned reviewer or the
+// security reviewer. Repeated approvals keep the original timestamp.
+func (s *Service) ApprovePullRequest(ctx context.Context, prID, userID string) (PullRequest, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return PullRequest{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	const selectPRQuery = `
+SELECT status, assigned_reviewers, security_reviewer_id
+FROM pull_requests
+WHERE pull_request_id = $1
+  AND deleted_at IS NULL
+FOR UPDATE
+`
+	var status string
+	var assigned []string
+	var securityReviewerID sql.NullString
+	err = tx.QueryRowContext(ctx, selectPRQuery, prID).Scan(&status, pq.Array(&assigned), &securityReviewerID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return PullRequest{}, &Error{Code: ErrorCodeNotFound, Message: "pull request not found"}
+		}
+		return PullRequest{}, fmt.Errorf("get pull request: %w", err)
+	}
+
+	if status == "MERGED" {
+		return PullRequest{}, &Error{Code: ErrorCodePRMerged, Message: "cannot approve merged PR"}
+	}
+
+	if securityReviewerID.Valid && securityReviewerID.String == userID {
+		const approveSecurityQuery = `
+UPDATE pull_requests
+SET security_approved_at = COALESCE(security_approved_at, NOW())
+WHERE pull_request_id = $1
+`
+		if _, err := tx.ExecContext(ctx, approveSecurityQuery, prID); err != nil {
+			return PullRequest{}, fmt.Errorf("approve security review: %w", err)
+		}
+		return s.commitApproval(ctx, tx, prID)
+	}
+
+	if !isReviewerAssigned(assigned, userID) {
+		return PullRequest{}, &Error{Code: ErrorCodeNotAssigned, Message: "reviewer is not assigned to this PR"}
+	}
+
+	const insertReviewQuery = `
+INSERT INTO pull_request_reviews(pull_request_id, user_id, status)
+VALUES ($1, $2, 'APPROVED')
+ON CONFLICT (pull_request_id, user_id) DO NOTHING
+`
+	if _, err := tx.ExecContext(ctx, insertReviewQuery, prID, userID); err != nil {
+		return PullRequest{}, fmt.Errorf("insert review: %w", err)
+	}
+
+	return s.commitApproval(ctx, tx, prID)
+}
+
+// commitApproval assigns a security reviewer if the approval completed the
+// regular review, then commits tx and returns the updated pull request.
+func (s *Service) commitApproval(ctx context.Context, tx *sql.Tx, prID string) (PullRequest, error) {
+	pr, err := s.getPullRequest(ctx, tx, prID)
+	if err != nil {
+		return PullRequest{}, err
+	}
+
+	pr, err = s.ensureSecurityReviewer(ctx, tx, pr)
+	if err != nil {
+		return PullRequest{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return PullRequest{}, fmt.Errorf("commit tx: %w", err)
+	}
+
+	s.publishQueueChange(queueUsers(pr)...)
+	return pr, nil
+}
+
+// reviewSummary builds the review summary of pr using approvals visible to q.
+func reviewSummary(ctx context.Context, q querier, pr PullRequest) (ReviewSummary, error) {
+	const query = `
+SELECT user_id, reviewed_at
+FROM pull_request_reviews
+WHERE pull_request_id = $1
+  AND status = 'APPROVED'
+`
+	rows, err := q.QueryContext(ctx, query, pr.ID)
+	if err != nil {
+		return ReviewSummary{}, fmt.Errorf("get reviews: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	approvedAt := make(map[string]time.Time)
+	for rows.Next() {
+		var userID string
+		var reviewedAt time.Time
+		if err := rows.Scan(&userID, &reviewedAt); err != nil {
+			return ReviewSummary{}, fmt.Errorf("scan review: %w", err)
+		}
+		approvedAt[userID] = reviewedAt
+	}
+	if err := rows.Err(); err != nil {
+		return ReviewSummary{}, fmt.Errorf("reviews rows: %w", err)
+	}
+
+	summary := ReviewSummary{
+		Reviewers:        make([]ReviewerStatus, 0, len(pr.AssignedReviewers)),
+		PendingReviewers: make([]string, 0),
+	}
+	for _, userID := range pr.AssignedReviewers {
+		st := ReviewerStatus{UserID: userID, Status: ReviewStatusPending}
+		if t, ok := approvedAt[userID]; ok {
+			st.Status = ReviewStatusApproved
+			st.ReviewedAt = &t
+			summary.Approvals++
+		} else {
+			summary.PendingReviewers = append(summary.PendingReviewers, userID)
+		}
+		summary.Reviewers = append(summary.Reviewers, st)
+	}
+
+	end := time.Now()
+	if pr.MergedAt != nil {
+		end = *pr.MergedAt
+	}
+	if pr.CreatedAt != nil {
+		summary.TimeOpenSeconds = end.Sub(*pr.CreatedAt).Seconds()
+	}
+
+	return summary, nil
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// MaxReviewBatchUsers caps how many users one batch review request may name.
+const MaxReviewBatchUsers = 100
+
+// UserReviews is the review queue of one user.
+type UserReviews struct {
+	UserID       string             `json:"user_id"`
+	PullRequests []PullRequestShort `json:"pull_requests"`
+}
+
+// UniqueUserIDs returns userIDs in order with duplicates removed.
+func UniqueUserIDs(userIDs []string) []string {
+	unique := make([]string, 0, len(userIDs))
+	seen := make(map[string]bool, len(userIDs))
+	for _, userID := range userIDs {
+		if !seen[userID] {
+			seen[userID] = true
+			unique = append(unique, userID)
+		}
+	}
+	return unique
+}
+
+// GetUserReviewsBatch returns the full review queues of several users, in
+// the order given and with duplicates removed, e.g. for a team dashboard.
+// Each queue is the same as GetUserReviews returns; all of them are read
+// with one query.
+func (s *Service) GetUserReviewsBatch(ctx context.Context, userIDs []string) ([]UserReviews, error) {
+	userIDs = UniqueUserIDs(userIDs)
+	query := `
+SELECT u.user_id, ` + reviewQueueColumns("u.user_id") + `
+FROM unnest($1::text[]) AS u(user_id)
+JOIN pull_requests p ON ` + reviewQueueMember("u.user_id") + `
+WHERE p.deleted_at IS NULL
+  AND p.archived_at IS NULL
+ORDER BY u.user_id, ` + priorityRank + ` DESC, p.deadline NULLS LAST, p.created_at, p.pull_request_id
+`
+	rows, err := s.db.QueryContext(ctx, s.expandReviewers(query), pq.Array(userIDs))
+	if err != nil {
+		return nil, fmt.Errorf("get user reviews batch: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	queues := make(map[string][]PullRequestShort, len(userIDs))
+	for rows.Next() {
+		var userID string
+		var pr PullRequestShort
+		var createdAt time.Time
+		var deadline sql.NullTime
+		if err := rows.Scan(&userID, &pr.ID, &pr.Name, &pr.AuthorID, &pr.Status, &pr.Priority, &createdAt, &deadline,
+			&pr.ReviewStatus); err != nil {
+			return nil, fmt.Errorf("scan user reviews batch: %w", err)
+		}
+		pr.CreatedAt = &createdAt
+		pr.Deadline = nullTimePtr(deadline)
+		queues[userID] = append(queues[userID], pr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("user reviews batch rows: %w", err)
+	}
+
+	result := make([]UserReviews, 0, len(userIDs))
+	for _, userID := range userIDs {
+		prs := queues[userID]
+		if prs == nil {
+			prs = make([]PullRequestShort, 0)
+		}
+		result = append(result, UserReviews{UserID: userID, PullRequests: prs})
+	}
+	return result, nil
+}
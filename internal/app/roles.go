@@ -0,0 +1,83 @@
+package app
+
+import (
+	"context"
+	"fmt"
+)
+
+// Team member roles.
+const (
+	RoleLead   = "LEAD"
+	RoleSenior = "SENIOR"
+	RoleMember = "MEMBER"
+)
+
+// IsValidRole reports whether r is a known team member role.
+func IsValidRole(r string) bool {
+	switch r {
+	case RoleLead, RoleSenior, RoleMember:
+		return true
+	}
+	return false
+}
+
+// LeadsTeamOf reports whether leadID is an active LEAD of userID's team.
+func (s *Service) LeadsTeamOf(ctx context.Context, leadID, userID string) (bool, error) {
+	const query = `
+SELECT EXISTS (
+    SELECT 1
+    FROM users l
+    JOIN users u ON u.team_name = l.team_name
+    WHERE l.user_id = $1
+      AND l.role = $3
+      AND l.is_active
+      AND l.deleted_at IS NULL
+      AND u.user_id = $2
+      AND u.deleted_at IS NULL
+)
+`
+	var leads bool
+	if err := s.db.QueryRowContext(ctx, query, leadID, userID, RoleLead).Scan(&leads); err != nil {
+		return false, fmt.Errorf("check team lead: %w", err)
+	}
+	return leads, nil
+}
+
+type reviewerCandidate struct {
+	ID   string
+	Role string
+}
+
+// pickReviewers takes up to n candidates in their original order. With
+// requireSenior the first senior candidate is always taken, even if it
+// comes later than n others.
+func pickReviewers(candidates []reviewerCandidate, n int, requireSenior bool) []string {
+	selected := make([]bool, len(candidates))
+	count := 0
+	if requireSenior && n > 0 {
+		for i, c := range candidates {
+			if c.Role == RoleSenior {
+				selected[i] = true
+				count++
+				break
+			}
+		}
+	}
+	for i := range candidates {
+		if count == n {
+			break
+		}
+		if !selected[i] {
+			selected[i] = true
+			count++
+		}
+	}
+
+	var ids []string
+	for i, c := range candidates {
+		if selected[i] {
+			ids = append(ids, c.ID)
+		}
+	}
+	return ids
+}
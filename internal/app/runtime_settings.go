@@ -0,0 +1,310 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+
+	"review-assigner/internal/ratelimit"
+)
+
+// FeatureNotifications turns outbox delivery on and off; while it is off,
+// notifications, issue updates and webhooks wait in the outbox.
+const FeatureNotifications = "notifications"
+
+// Features lists the known feature flags, which are on by default.
+var Features = []string{FeatureNotifications}
+
+// RateLimitClasses lists the route classes of the HTTP API whose rate
+// limits can be set at runtime.
+var RateLimitClasses = []string{"read", "write", "integration", "admin", "auth_failure"}
+
+// runtimeSettingsTTL bounds how long a replica serves settings changed on
+// another replica from its cache.
+const runtimeSettingsTTL = 10 * time.Second
+
+// RuntimeSettings are service-wide settings changed through the admin API
+// without a restart.
+type RuntimeSettings struct {
+	// DefaultStrategy and DefaultSLAHours apply to teams that have not set
+	// their own strategy or sla_hours.
+	DefaultStrategy string `json:"default_strategy"`
+	DefaultSLAHours int    `json:"default_sla_hours"`
+	// Features holds every known feature flag.
+	Features map[string]bool `json:"features"`
+	// RateLimits overrides the rate limits of route classes; the others
+	// keep the limits configured at startup.
+	RateLimits map[string]ratelimit.Limit `json:"rate_limits"`
+}
+
+// RuntimeSettingsUpdate holds settings to change. Nil fields, and flags
+// and rate limits not in the maps, are left unchanged.
+type RuntimeSettingsUpdate struct {
+	DefaultStrategy *string
+	DefaultSLAHours *int
+	Features        map[string]bool
+	// RateLimits maps route classes to limits in the COUNT/PERIOD form; nil
+	// removes the override.
+	RateLimits map[string]*string
+}
+
+func defaultRuntimeSettings() RuntimeSettings {
+	st := RuntimeSettings{
+		DefaultStrategy: AssignmentStrategyTeamOrder,
+		Features:        make(map[string]bool, len(Features)),
+		RateLimits:      make(map[string]ratelimit.Limit),
+	}
+	for _, f := range Features {
+		st.Features[f] = true
+	}
+	return st
+}
+
+// runtimeSettingsCache keeps the settings in memory; updates through this
+// replica invalidate it at once, others within runtimeSettingsTTL.
+type runtimeSettingsCache struct {
+	mu       sync.Mutex
+	settings RuntimeSettings
+	loadedAt time.Time
+}
+
+func (c *runtimeSettingsCache) get(now time.Time) (RuntimeSettings, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.loadedAt.IsZero() || now.Sub(c.loadedAt) >= runtimeSettingsTTL {
+		return RuntimeSettings{}, false
+	}
+	return c.settings, true
+}
+
+func (c *runtimeSettingsCache) put(st RuntimeSettings, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.settings, c.loadedAt = st, now
+}
+
+func (c *runtimeSettingsCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loadedAt = time.Time{}
+}
+
+// GetRuntimeSettings returns the runtime settings.
+func (s *Service) GetRuntimeSettings(ctx context.Context) (RuntimeSettings, error) {
+	st, err := s.runtimeSettings(ctx, s.db)
+	if err != nil {
+		return RuntimeSettings{}, err
+	}
+	// The cached maps must not leak to callers.
+	features := make(map[string]bool, len(st.Features))
+	for k, v := range st.Features {
+		features[k] = v
+	}
+	st.Features = features
+	rateLimits := make(map[string]ratelimit.Limit, len(st.RateLimits))
+	for k, v := range st.RateLimits {
+		rateLimits[k] = v
+	}
+	st.RateLimits = rateLimits
+	return st, nil
+}
+
+// RateLimitOverride returns the rate limit of a route class set at runtime,
+// if there is one.
+func (s *Service) RateLimitOverride(ctx context.Context, class string) (ratelimit.Limit, bool, error) {
+	st, err := s.runtimeSettings(ctx, s.db)
+	if err != nil {
+		return ratelimit.Limit{}, false, err
+	}
+	limit, ok := st.RateLimits[class]
+	return limit, ok, nil
+}
+
+// UpdateRuntimeSettings changes the runtime settings and returns the result.
+func (s *Service) UpdateRuntimeSettings(ctx context.Context, upd RuntimeSettingsUpdate) (RuntimeSettings, error) {
+	if fields := validateRuntimeSettings(upd); len(fields) > 0 {
+		return RuntimeSettings{}, validationError(fields)
+	}
+
+	values := make(map[string]any)
+	if upd.DefaultStrategy != nil {
+		values["default_strategy"] = *upd.DefaultStrategy
+	}
+	if upd.DefaultSLAHours != nil {
+		values["default_sla_hours"] = *upd.DefaultSLAHours
+	}
+	for name, on := range upd.Features {
+		values["feature."+name] = on
+	}
+	var removed []string
+	for class, limit := range upd.RateLimits {
+		if limit == nil {
+			removed = append(removed, "rate_limit."+class)
+			continue
+		}
+		// Validated above.
+		parsed, _ := ratelimit.ParseLimit(*limit)
+		values["rate_limit."+class] = parsed
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return RuntimeSettings{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	const query = `
+INSERT INTO runtime_settings (name, value, updated_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (name) DO UPDATE
+SET value = EXCLUDED.value,
+    updated_at = EXCLUDED.updated_at
+`
+	for name, value := range values {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return RuntimeSettings{}, fmt.Errorf("encode setting %s: %w", name, err)
+		}
+		if _, err := tx.ExecContext(ctx, query, name, data); err != nil {
+			return RuntimeSettings{}, fmt.Errorf("update setting %s: %w", name, err)
+		}
+	}
+	if len(removed) > 0 {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM runtime_settings WHERE name = ANY($1)`,
+			pq.Array(removed)); err != nil {
+			return RuntimeSettings{}, fmt.Errorf("remove settings: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return RuntimeSettings{}, fmt.Errorf("commit tx: %w", err)
+	}
+
+	s.settingsCache.invalidate()
+	return s.GetRuntimeSettings(ctx)
+}
+
+func validateRuntimeSettings(upd RuntimeSettingsUpdate) []FieldError {
+	var fields []FieldError
+	if upd.DefaultStrategy != nil && !IsValidTeamStrategy(*upd.DefaultStrategy) {
+		fields = append(fields, FieldError{Field: "default_strategy",
+			Message: "default_strategy must be one of TEAM_ORDER, RANDOM"})
+	}
+	if upd.DefaultSLAHours != nil && *upd.DefaultSLAHours < 0 {
+		fields = append(fields, FieldError{Field: "default_sla_hours", Message: "default_sla_hours must not be negative"})
+	}
+	names := make([]string, 0, len(upd.Features))
+	for name := range upd.Features {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !slices.Contains(Features, name) {
+			fields = append(fields, FieldError{Field: "features." + name,
+				Message: "unknown feature " + name + ", expected one of " + strings.Join(Features, ", ")})
+		}
+	}
+	classes := make([]string, 0, len(upd.RateLimits))
+	for class := range upd.RateLimits {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	for _, class := range classes {
+		field := "rate_limits." + class
+		if !slices.Contains(RateLimitClasses, class) {
+			fields = append(fields, FieldError{Field: field,
+				Message: "unknown route class " + class + ", expected one of " + strings.Join(RateLimitClasses, ", ")})
+			continue
+		}
+		if limit := upd.RateLimits[class]; limit != nil {
+			if _, err := ratelimit.ParseLimit(*limit); err != nil {
+				fields = append(fields, FieldError{Field: field, Message: err.Error()})
+			}
+		}
+	}
+	return fields
+}
+
+// runtimeSettings returns the cached settings, loading them with q when the
+// cache is stale.
+func (s *Service) runtimeSettings(ctx context.Context, q querier) (RuntimeSettings, error) {
+	now := time.Now()
+	if st, ok := s.settingsCache.get(now); ok {
+		return st, nil
+	}
+
+	rows, err := q.QueryContext(ctx, `SELECT name, value FROM runtime_settings`)
+	if err != nil {
+		return RuntimeSettings{}, fmt.Errorf("select runtime settings: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	st := defaultRuntimeSettings()
+	for rows.Next() {
+		var name string
+		var value []byte
+		if err := rows.Scan(&name, &value); err != nil {
+			return RuntimeSettings{}, fmt.Errorf("scan runtime setting: %w", err)
+		}
+		var dst any
+		switch {
+		case name == "default_strategy":
+			dst = &st.DefaultStrategy
+		case name == "default_sla_hours":
+			dst = &st.DefaultSLAHours
+		case strings.HasPrefix(name, "feature."):
+			feature := strings.TrimPrefix(name, "feature.")
+			if !slices.Contains(Features, feature) {
+				// A flag retired in a newer version.
+				continue
+			}
+			on := true
+			if err := json.Unmarshal(value, &on); err != nil {
+				return RuntimeSettings{}, fmt.Errorf("decode runtime setting %s: %w", name, err)
+			}
+			st.Features[feature] = on
+			continue
+		case strings.HasPrefix(name, "rate_limit."):
+			class := strings.TrimPrefix(name, "rate_limit.")
+			if !slices.Contains(RateLimitClasses, class) {
+				continue
+			}
+			var limit ratelimit.Limit
+			if err := json.Unmarshal(value, &limit); err != nil {
+				return RuntimeSettings{}, fmt.Errorf("decode runtime setting %s: %w", name, err)
+			}
+			st.RateLimits[class] = limit
+			continue
+		default:
+			continue
+		}
+		if err := json.Unmarshal(value, dst); err != nil {
+			return RuntimeSettings{}, fmt.Errorf("decode runtime setting %s: %w", name, err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return RuntimeSettings{}, fmt.Errorf("runtime settings rows: %w", err)
+	}
+
+	s.settingsCache.put(st, now)
+	return st, nil
+}
+
+// featureEnabled reports whether a feature flag is on.
+func (s *Service) featureEnabled(ctx context.Context, feature string) (bool, error) {
+	st, err := s.runtimeSettings(ctx, s.db)
+	if err != nil {
+		return false, err
+	}
+	return st.Features[feature], nil
+}
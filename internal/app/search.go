@@ -0,0 +1,96 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Search result limits per group.
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+// SearchResults groups the entities matching a search query.
+type SearchResults struct {
+	Query        string             `json:"query"`
+	PullRequests []PullRequestShort `json:"pull_requests"`
+	Users        []User             `json:"users"`
+}
+
+// likeEscaper escapes LIKE wildcards so the query is matched literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// Search finds pull requests by ID or name and users by ID or username with
+// a case-insensitive substring match. Deleted entities are skipped; exact
+// ID matches come first in each group, which holds at most limit entities
+// (DefaultSearchLimit when zero).
+func (s *Service) Search(ctx context.Context, query string, limit int) (SearchResults, error) {
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	pattern := "%" + likeEscaper.Replace(query) + "%"
+	results := SearchResults{Query: query}
+
+	const prQuery = `
+SELECT pull_request_id, pull_request_name, author_id, status, priority, created_at
+FROM pull_requests
+WHERE deleted_at IS NULL
+  AND (pull_request_id ILIKE $1 OR pull_request_name ILIKE $1)
+ORDER BY lower(pull_request_id) = lower($2) DESC, created_at DESC, pull_request_id
+LIMIT $3
+`
+	rows, err := s.db.QueryContext(ctx, prQuery, pattern, query, limit)
+	if err != nil {
+		return SearchResults{}, fmt.Errorf("search pull requests: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	results.PullRequests = make([]PullRequestShort, 0)
+	for rows.Next() {
+		var pr PullRequestShort
+		var createdAt time.Time
+		if err := rows.Scan(&pr.ID, &pr.Name, &pr.AuthorID, &pr.Status, &pr.Priority, &createdAt); err != nil {
+			return SearchResults{}, fmt.Errorf("scan pull request match: %w", err)
+		}
+		pr.CreatedAt = &createdAt
+		results.PullRequests = append(results.PullRequests, pr)
+	}
+	if err := rows.Err(); err != nil {
+		return SearchResults{}, fmt.Errorf("pull request match rows: %w", err)
+	}
+
+	const userQuery = `
+SELECT user_id, username, COALESCE(team_name, ''), is_active, role, email, slack_handle, avatar_url, github_login
+FROM users
+WHERE deleted_at IS NULL
+  AND (user_id ILIKE $1 OR username ILIKE $1)
+ORDER BY lower(user_id) = lower($2) DESC, user_id
+LIMIT $3
+`
+	userRows, err := s.db.QueryContext(ctx, userQuery, pattern, query, limit)
+	if err != nil {
+		return SearchResults{}, fmt.Errorf("search users: %w", err)
+	}
+	defer func() {
+		_ = userRows.Close()
+	}()
+
+	results.Users = make([]User, 0)
+	for userRows.Next() {
+		var u User
+		if err := userRows.Scan(&u.ID, &u.Name, &u.TeamName, &u.IsActive, &u.Role, &u.Email, &u.SlackHandle, &u.AvatarURL, &u.GitHubLogin); err != nil {
+			return SearchResults{}, fmt.Errorf("scan user match: %w", err)
+		}
+		results.Users = append(results.Users, u)
+	}
+	if err := userRows.Err(); err != nil {
+		return SearchResults{}, fmt.Errorf("user match rows: %w", err)
+	}
+
+	return results, nil
+}
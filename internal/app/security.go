@@ -0,0 +1,119 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// Security review states reported in PullRequest.SecurityReviewStatus.
+const (
+	// SecurityReviewWaiting means regular reviewers have not approved yet.
+	SecurityReviewWaiting = "WAITING_FOR_APPROVALS"
+	// SecurityReviewUnassigned means no active security reviewer could be assigned yet.
+	SecurityReviewUnassigned = "UNASSIGNED"
+	SecurityReviewPending    = "PENDING"
+	SecurityReviewApproved   = "APPROVED"
+)
+
+// AssignmentReasonSecurityReview marks assignments of security reviewers.
+const AssignmentReasonSecurityReview = "SECURITY_REVIEW"
+
+// WithSecurityTeam sets the team whose members perform second-stage security reviews.
+func WithSecurityTeam(name string) Option {
+	return func(s *Service) {
+		s.securityTeam = name
+	}
+}
+
+// SecurityTeam returns the configured security team; empty means security review is disabled.
+func (s *Service) SecurityTeam() string {
+	return s.securityTeam
+}
+
+func securityReviewStatus(pr PullRequest) string {
+	switch {
+	case !pr.NeedsSecurityReview:
+		return ""
+	case pr.SecurityApprovedAt != nil:
+		return SecurityReviewApproved
+	case pr.SecurityReviewerID != nil:
+		return SecurityReviewPending
+	case pr.ApprovedAt == nil && len(pr.AssignedReviewers) > 0:
+		// Without regular reviewers there is no approval to wait for.
+		return SecurityReviewWaiting
+	default:
+		return SecurityReviewUnassigned
+	}
+}
+
+// ensureSecurityReviewer assigns a security reviewer once every regular
+// reviewer has approved pr, or at once when pr has none. It is a no-op when no reviewer is needed yet or
+// no active security team member is available; the next approve or merge
+// attempt retries.
+func (s *Service) ensureSecurityReviewer(ctx context.Context, q querier, pr PullRequest) (PullRequest, error) {
+	if securityReviewStatus(pr) != SecurityReviewUnassigned || s.securityTeam == "" {
+		return pr, nil
+	}
+
+	const selectCandidateQuery = `
+SELECT user_id
+FROM users
+WHERE team_name = $1
+  AND is_active = TRUE
+  AND user_id <> $2
+  AND NOT (user_id = ANY($3))
+  AND NOT (user_id = ANY($4))
+ORDER BY random()
+LIMIT 1
+`
+	var userID string
+	err := q.QueryRowContext(ctx, selectCandidateQuery, s.securityTeam, pr.AuthorID,
+		pq.Array(pr.CoAuthorIDs), pq.Array(pr.AssignedReviewers)).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return pr, nil
+		}
+		return PullRequest{}, fmt.Errorf("select security reviewer: %w", err)
+	}
+
+	const updateQuery = `UPDATE pull_requests SET security_reviewer_id = $2 WHERE pull_request_id = $1`
+	if _, err := q.ExecContext(ctx, updateQuery, pr.ID, userID); err != nil {
+		return PullRequest{}, fmt.Errorf("assign security reviewer: %w", err)
+	}
+
+	if err := recordAssignments(ctx, q, pr.ID, []string{userID}, AssignmentReasonSecurityReview, AssignmentStrategyRandom); err != nil {
+		return PullRequest{}, err
+	}
+
+	return s.getPullRequest(ctx, q, pr.ID)
+}
+
+// removeSecurityReviewers unassigns the given users from pending security
+// reviews of open pull requests. A new reviewer is picked on the next merge attempt.
+func removeSecurityReviewers(ctx context.Context, q querier, userIDs []string, reason string) error {
+	const where = `
+WHERE status <> 'MERGED'
+  AND deleted_at IS NULL
+  AND security_approved_at IS NULL
+  AND security_reviewer_id = ANY($1)
+`
+	const recordQuery = `
+INSERT INTO assignment_events(pull_request_id, event_type, user_id, reason)
+SELECT pull_request_id, 'REMOVED', security_reviewer_id, $2
+FROM pull_requests` + where + `
+ORDER BY pull_request_id
+`
+	if _, err := q.ExecContext(ctx, recordQuery, pq.Array(userIDs), reason); err != nil {
+		return fmt.Errorf("record security reviewer removals: %w", err)
+	}
+
+	const updateQuery = `UPDATE pull_requests SET security_reviewer_id = NULL` + where
+	if _, err := q.ExecContext(ctx, updateQuery, pq.Array(userIDs)); err != nil {
+		return fmt.Errorf("remove security reviewers: %w", err)
+	}
+	return nil
+}
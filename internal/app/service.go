@@ -5,23 +5,78 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/lib/pq"
+
+	"review-assigner/internal/secretbox"
 )
 
+// RequiredReviewers is the default number of reviewers assigned to a new
+// pull request; teams can override it with reviewer_count.
+const RequiredReviewers = 2
+
 // Service provides application business operations backed by a SQL database.
 type Service struct {
-	db *sql.DB
+	db              *sql.DB
+	reviewerStorage ReviewerStorage
+	usage           *usageRecorder
+	mergedRetention time.Duration
+	securityTeam    string
+	statsCache      *statsCache
+	notifiers       []Notifier
+	issueTracker    IssueTracker
+	codeHost        CodeHost
+	webhookSender   WebhookSender
+	credentialsBox  *secretbox.Box
+	retryPolicies   map[string]RetryPolicy
+	outboxWorkers   int
+	outboxKicks     sync.WaitGroup
+	settingsCache   runtimeSettingsCache
+	jobs            []Job
+	queueEvents     *queueEvents
+	queueRelayDSN   string
+	relayID         string
+	logger          *slog.Logger
+}
+
+// Option configures optional Service behavior.
+type Option func(*Service)
+
+// WithReviewerStorage sets how assigned reviewers are persisted and read.
+func WithReviewerStorage(mode ReviewerStorage) Option {
+	return func(s *Service) {
+		s.reviewerStorage = mode
+	}
 }
 
 // NewService creates a new Service using the provided database handle.
-func NewService(db *sql.DB) *Service {
-	return &Service{db: db}
+func NewService(db *sql.DB, opts ...Option) *Service {
+	s := &Service{
+		db:              db,
+		reviewerStorage: ReviewerStorageArray,
+		usage:           newUsageRecorder(),
+		statsCache:      newStatsCache(),
+		queueEvents:     newQueueEvents(),
+		logger:          slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // CreateTeam creates a new team and upserts its members in the database.
-func (s *Service) CreateTeam(ctx context.Context, team Team) (Team, error) {
+// Members that already belong to another team are moved only if transfer
+// is set; otherwise USER_IN_OTHER_TEAM is returned.
+func (s *Service) CreateTeam(ctx context.Context, team Team, transfer bool) (Team, error) {
+	if fields := ValidateTeam(team); len(fields) > 0 {
+		return Team{}, validationError(fields)
+	}
+
 	const selectTeamQuery = `SELECT team_name FROM teams WHERE team_name = $1`
 	var existing string
 	err := s.db.QueryRowContext(ctx, selectTeamQuery, team.Name).Scan(&existing)
@@ -32,6 +87,12 @@ func (s *Service) CreateTeam(ctx context.Context, team Team) (Team, error) {
 		return Team{}, &Error{Code: ErrorCodeTeamExists, Message: "team_name already exists"}
 	}
 
+	if team.OrgName != "" {
+		if err := checkOrganizationExists(ctx, s.db, team.OrgName); err != nil {
+			return Team{}, err
+		}
+	}
+
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return Team{}, fmt.Errorf("begin tx: %w", err)
@@ -40,23 +101,95 @@ func (s *Service) CreateTeam(ctx context.Context, team Team) (Team, error) {
 		_ = tx.Rollback()
 	}()
 
-	const insertTeamQuery = `INSERT INTO teams(team_name) VALUES ($1)`
-	if _, err := tx.ExecContext(ctx, insertTeamQuery, team.Name); err != nil {
+	const insertTeamQuery = `INSERT INTO teams(team_name, org_name) VALUES ($1, NULLIF($2, ''))`
+	if _, err := tx.ExecContext(ctx, insertTeamQuery, team.Name, team.OrgName); err != nil {
+		if isUniqueViolation(err) {
+			return Team{}, &Error{Code: ErrorCodeTeamExists, Message: "team_name already exists"}
+		}
 		return Team{}, fmt.Errorf("insert team: %w", err)
 	}
 
+	if !transfer {
+		if err := checkMembersInOtherTeams(ctx, tx, team); err != nil {
+			return Team{}, err
+		}
+	}
+
+	const selectPreviousTeamsQuery = `
+SELECT user_id, COALESCE(team_name, ''), deleted_at IS NOT NULL
+FROM users
+WHERE user_id = ANY($1)
+ORDER BY user_id
+`
+	rows, err := tx.QueryContext(ctx, selectPreviousTeamsQuery, pq.Array(teamMemberIDs(team)))
+	if err != nil {
+		return Team{}, fmt.Errorf("select previous teams: %w", err)
+	}
+	previousTeams := make(map[string]string)
+	var deleted []string
+	for rows.Next() {
+		var userID, teamName string
+		var isDeleted bool
+		if err := rows.Scan(&userID, &teamName, &isDeleted); err != nil {
+			_ = rows.Close()
+			return Team{}, fmt.Errorf("scan previous team: %w", err)
+		}
+		previousTeams[userID] = teamName
+		if isDeleted {
+			deleted = append(deleted, userID)
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return Team{}, fmt.Errorf("previous teams rows: %w", err)
+	}
+	// Deleted users keep their rows for history, so their IDs cannot be reused.
+	if len(deleted) > 0 {
+		return Team{}, &Error{
+			Code:    ErrorCodeUserExists,
+			Message: "user_id belongs to a deleted user: " + strings.Join(deleted, ", "),
+		}
+	}
+
+	// The check above does not lock users that had no team or did not
+	// exist yet, so the upsert itself refuses to take a member another
+	// request has attached to its team in the meantime.
 	const upsertUserQuery = `
-INSERT INTO users(user_id, username, team_name, is_active)
-VALUES ($1, $2, $3, $4)
+INSERT INTO users(user_id, username, team_name, is_active, role, email, slack_handle, avatar_url, github_login)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 ON CONFLICT (user_id) DO UPDATE
 SET username = EXCLUDED.username,
     team_name = EXCLUDED.team_name,
-    is_active = EXCLUDED.is_active
+    is_active = EXCLUDED.is_active,
+    role = EXCLUDED.role,
+    email = EXCLUDED.email,
+    slack_handle = EXCLUDED.slack_handle,
+    avatar_url = EXCLUDED.avatar_url,
+    github_login = EXCLUDED.github_login
+WHERE $10 OR users.team_name IS NULL OR users.team_name = EXCLUDED.team_name
 `
-	for _, m := range team.Members {
-		if _, err := tx.ExecContext(ctx, upsertUserQuery, m.ID, m.Name, team.Name, m.IsActive); err != nil {
+	for i, m := range team.Members {
+		if m.Role == "" {
+			m.Role = RoleMember
+			team.Members[i].Role = RoleMember
+		}
+		res, err := tx.ExecContext(ctx, upsertUserQuery, m.ID, m.Name, team.Name, m.IsActive, m.Role,
+			m.Email, m.SlackHandle, m.AvatarURL, m.GitHubLogin, transfer)
+		if err != nil {
 			return Team{}, fmt.Errorf("upsert user %s: %w", m.ID, err)
 		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return Team{}, fmt.Errorf("upsert user %s: %w", m.ID, err)
+		}
+		if n == 0 {
+			if err := checkMembersInOtherTeams(ctx, tx, Team{Name: team.Name, Members: []TeamMember{m}}); err != nil {
+				return Team{}, err
+			}
+			return Team{}, fmt.Errorf("upsert user %s: no row affected", m.ID)
+		}
+		if err := recordJoin(ctx, tx, team.Name, m.ID, previousTeams[m.ID], m.IsActive); err != nil {
+			return Team{}, err
+		}
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -66,11 +199,60 @@ SET username = EXCLUDED.username,
 	return team, nil
 }
 
+// checkMembersInOtherTeams returns USER_IN_OTHER_TEAM if any member of team
+// is attached to a different team. Rows are locked until the transaction ends.
+func checkMembersInOtherTeams(ctx context.Context, q querier, team Team) error {
+	const query = `
+SELECT user_id, team_name
+FROM users
+WHERE user_id = ANY($1)
+  AND team_name IS NOT NULL
+  AND team_name <> $2
+ORDER BY user_id
+FOR UPDATE
+`
+	rows, err := q.QueryContext(ctx, query, pq.Array(teamMemberIDs(team)), team.Name)
+	if err != nil {
+		return fmt.Errorf("check member teams: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var conflicts []string
+	for rows.Next() {
+		var userID, teamName string
+		if err := rows.Scan(&userID, &teamName); err != nil {
+			return fmt.Errorf("scan member team: %w", err)
+		}
+		conflicts = append(conflicts, userID+" ("+teamName+")")
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("member teams rows: %w", err)
+	}
+
+	if len(conflicts) > 0 {
+		return &Error{
+			Code:    ErrorCodeUserInOtherTeam,
+			Message: "users already belong to other teams: " + strings.Join(conflicts, ", "),
+		}
+	}
+	return nil
+}
+
+func teamMemberIDs(team Team) []string {
+	ids := make([]string, 0, len(team.Members))
+	for _, m := range team.Members {
+		ids = append(ids, m.ID)
+	}
+	return ids
+}
+
 // GetTeam returns a team and its members by team name.
 func (s *Service) GetTeam(ctx context.Context, name string) (Team, error) {
-	const selectTeamQuery = `SELECT team_name FROM teams WHERE team_name = $1`
-	var teamName string
-	err := s.db.QueryRowContext(ctx, selectTeamQuery, name).Scan(&teamName)
+	const selectTeamQuery = `SELECT COALESCE(org_name, '') FROM teams WHERE team_name = $1 AND archived_at IS NULL`
+	var orgName string
+	err := s.db.QueryRowContext(ctx, selectTeamQuery, name).Scan(&orgName)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return Team{}, &Error{Code: ErrorCodeNotFound, Message: "team not found"}
@@ -78,7 +260,12 @@ func (s *Service) GetTeam(ctx context.Context, name string) (Team, error) {
 		return Team{}, fmt.Errorf("get team: %w", err)
 	}
 
-	const selectMembersQuery = `SELECT user_id, username, is_active FROM users WHERE team_name = $1 ORDER BY user_id`
+	const selectMembersQuery = `
+SELECT user_id, username, is_active, role, email, slack_handle, avatar_url, github_login
+FROM users
+WHERE team_name = $1
+ORDER BY user_id
+`
 	rows, err := s.db.QueryContext(ctx, selectMembersQuery, name)
 	if err != nil {
 		return Team{}, fmt.Errorf("get team members: %w", err)
@@ -90,7 +277,7 @@ func (s *Service) GetTeam(ctx context.Context, name string) (Team, error) {
 	var members []TeamMember
 	for rows.Next() {
 		var m TeamMember
-		if err := rows.Scan(&m.ID, &m.Name, &m.IsActive); err != nil {
+		if err := rows.Scan(&m.ID, &m.Name, &m.IsActive, &m.Role, &m.Email, &m.SlackHandle, &m.AvatarURL, &m.GitHubLogin); err != nil {
 			return Team{}, fmt.Errorf("scan member: %w", err)
 		}
 		members = append(members, m)
@@ -102,12 +289,62 @@ func (s *Service) GetTeam(ctx context.Context, name string) (Team, error) {
 
 	return Team{
 		Name:    name,
+		OrgName: orgName,
 		Members: members,
 	}, nil
 }
 
+// Limits for PullRequestInput.Wait.
+const (
+	MaxCreateWait       = 5 * time.Second
+	createRetryInterval = 250 * time.Millisecond
+)
+
 // CreatePullRequest creates a new pull request and assigns initial reviewers.
-func (s *Service) CreatePullRequest(ctx context.Context, id, name, authorID string) (PullRequest, error) {
+// With in.Wait set, a NOT_ENOUGH_REVIEWERS rejection of a strict mode team is
+// retried until reviewers become available or the wait runs out.
+func (s *Service) CreatePullRequest(ctx context.Context, in PullRequestInput) (PullRequest, error) {
+	pr, err := s.createPullRequest(ctx, in)
+	if in.Wait <= 0 {
+		return pr, err
+	}
+
+	timer := time.NewTimer(min(in.Wait, MaxCreateWait))
+	defer timer.Stop()
+	ticker := time.NewTicker(createRetryInterval)
+	defer ticker.Stop()
+	for {
+		var appErr *Error
+		if !errors.As(err, &appErr) || appErr.Code != ErrorCodeNotEnoughReviewers {
+			return pr, err
+		}
+		select {
+		case <-ctx.Done():
+			return pr, err
+		case <-timer.C:
+			return pr, err
+		case <-ticker.C:
+		}
+		pr, err = s.createPullRequest(ctx, in)
+	}
+}
+
+func (s *Service) createPullRequest(ctx context.Context, in PullRequestInput) (PullRequest, error) {
+	if in.Priority == "" {
+		in.Priority = PriorityMedium
+	}
+	id, authorID, priority := in.ID, in.AuthorID, in.Priority
+
+	if in.IdempotencyKey != "" {
+		pr, found, err := s.findIdempotentPullRequest(ctx, in)
+		if err != nil {
+			return PullRequest{}, err
+		}
+		if found {
+			return pr, nil
+		}
+	}
+
 	const selectPRQuery = `SELECT pull_request_id FROM pull_requests WHERE pull_request_id = $1`
 	var existing string
 	err := s.db.QueryRowContext(ctx, selectPRQuery, id).Scan(&existing)
@@ -118,7 +355,7 @@ func (s *Service) CreatePullRequest(ctx context.Context, id, name, authorID stri
 		return PullRequest{}, &Error{Code: ErrorCodePRExists, Message: "PR id already exists"}
 	}
 
-	const selectAuthorTeamQuery = `SELECT team_name FROM users WHERE user_id = $1`
+	const selectAuthorTeamQuery = `SELECT team_name FROM users WHERE user_id = $1 AND team_name IS NOT NULL`
 	var teamName string
 	err = s.db.QueryRowContext(ctx, selectAuthorTeamQuery, authorID).Scan(&teamName)
 	if err != nil {
@@ -128,15 +365,44 @@ func (s *Service) CreatePullRequest(ctx context.Context, id, name, authorID stri
 		return PullRequest{}, fmt.Errorf("get author team: %w", err)
 	}
 
+	coAuthors, err := s.checkCoAuthors(ctx, authorID, in.CoAuthorIDs)
+	if err != nil {
+		return PullRequest{}, err
+	}
+
+	settings, err := s.teamSettings(ctx, s.db, teamName)
+	if err != nil {
+		return PullRequest{}, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return PullRequest{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// Candidates are share-locked so that a concurrent deactivation waits
+	// for the assignment and then cleans it up instead of missing it. Rows
+	// are locked in user_id order like everywhere else to avoid deadlocks.
 	const selectReviewersQuery = `
-SELECT user_id
-FROM users
-WHERE team_name = $1
-  AND user_id <> $2
-  AND is_active = TRUE
-ORDER BY user_id
+SELECT user_id, role
+FROM (
+    SELECT user_id, role
+    FROM users
+    WHERE team_name = $1
+      AND user_id <> $2
+      AND NOT (user_id = ANY($3))
+      AND is_active = TRUE
+      AND NOT ($4 AND role = 'LEAD')
+    ORDER BY user_id
+    FOR SHARE
+) c
+ORDER BY CASE WHEN $5 = 'RANDOM' THEN random() END, user_id
 `
-	rows, err := s.db.QueryContext(ctx, selectReviewersQuery, teamName, authorID)
+	rows, err := tx.QueryContext(ctx, selectReviewersQuery, teamName, authorID, pq.Array(coAuthors), settings.ExcludeLeads,
+		settings.Strategy)
 	if err != nil {
 		return PullRequest{}, fmt.Errorf("select reviewers: %w", err)
 	}
@@ -144,123 +410,301 @@ ORDER BY user_id
 		_ = rows.Close()
 	}()
 
-	var reviewers []string
+	var candidates []reviewerCandidate
 	for rows.Next() {
-		var uid string
-		if err := rows.Scan(&uid); err != nil {
+		var c reviewerCandidate
+		if err := rows.Scan(&c.ID, &c.Role); err != nil {
 			return PullRequest{}, fmt.Errorf("scan reviewer: %w", err)
 		}
-		reviewers = append(reviewers, uid)
-		if len(reviewers) == 2 {
-			break
-		}
+		candidates = append(candidates, c)
 	}
 	if err := rows.Err(); err != nil {
 		return PullRequest{}, fmt.Errorf("scan reviewers: %w", err)
 	}
 
+	reviewers := pickReviewers(candidates, settings.ReviewerCount, settings.RequireSenior)
+	if len(reviewers) < settings.ReviewerCount && settings.OrgFallback {
+		exclude := append(append([]string{authorID}, coAuthors...), reviewers...)
+		extra, err := orgFallbackReviewers(ctx, tx, teamName, exclude, settings.ExcludeLeads, settings.ReviewerCount-len(reviewers))
+		if err != nil {
+			return PullRequest{}, err
+		}
+		reviewers = append(reviewers, extra...)
+	}
+	if len(reviewers) < settings.ReviewerCount && settings.StrictMode {
+		return PullRequest{}, &Error{
+			Code:    ErrorCodeNotEnoughReviewers,
+			Message: fmt.Sprintf("only %d of %d reviewers can be assigned", len(reviewers), settings.ReviewerCount),
+		}
+	}
+
+	// The SLA deadline is not part of the input, so idempotent retries still match.
+	deadline := in.Deadline
+	if deadline == nil && settings.SLAHours > 0 {
+		d := time.Now().Add(time.Duration(settings.SLAHours) * time.Hour)
+		deadline = &d
+	}
+
 	assigned := reviewers
 	if assigned == nil {
 		assigned = []string{}
 	}
 
 	const insertPRQuery = `
-INSERT INTO pull_requests(pull_request_id, pull_request_name, author_id, status, assigned_reviewers)
-VALUES ($1, $2, $3, 'OPEN', $4)
-RETURNING pull_request_id, pull_request_name, author_id, status, assigned_reviewers, created_at, merged_at
+INSERT INTO pull_requests(pull_request_id, pull_request_name, author_id, status, assigned_reviewers, priority,
+                          co_author_ids, labels, jira_issues, github_repo, github_number, deadline,
+                          needs_security_review)
+VALUES ($1, $2, $3, 'OPEN', $4, $5, $6, $7, $8, $9, $10, $11, $12)
 `
-	var pr PullRequest
-	var createdAt time.Time
-	var mergedAt sql.NullTime
-	err = s.db.QueryRowContext(ctx, insertPRQuery, id, name, authorID, pq.Array(assigned)).
-		Scan(&pr.ID, &pr.Name, &pr.AuthorID, &pr.Status, pq.Array(&pr.AssignedReviewers), &createdAt, &mergedAt)
+	_, err = tx.ExecContext(ctx, insertPRQuery, id, in.Name, authorID, pq.Array(assigned), priority,
+		pq.Array(coAuthors), pq.Array(NormalizeLabels(in.Labels)), pq.Array(NormalizeJiraIssues(in.JiraIssues)),
+		in.GitHubRepo, in.GitHubNumber, deadline, in.NeedsSecurityReview)
 	if err != nil {
+		if isUniqueViolation(err) {
+			_ = tx.Rollback()
+			return s.duplicatePullRequest(ctx, in)
+		}
 		return PullRequest{}, fmt.Errorf("insert pull request: %w", err)
 	}
 
-	pr.CreatedAt = &createdAt
-	if mergedAt.Valid {
-		t := mergedAt.Time
-		pr.MergedAt = &t
+	if err := s.syncReviewers(ctx, tx, id, assigned); err != nil {
+		return PullRequest{}, err
 	}
 
+	if err := recordAssignments(ctx, tx, id, assigned, AssignmentReasonCreated, settings.Strategy); err != nil {
+		return PullRequest{}, err
+	}
+
+	if err := s.enqueueAssigned(ctx, tx, id, assigned, AssignmentReasonCreated); err != nil {
+		return PullRequest{}, err
+	}
+
+	if in.GitHubRepo != "" {
+		if err := s.enqueueCodeHostSync(ctx, tx, id, nil); err != nil {
+			return PullRequest{}, err
+		}
+	}
+
+	if in.IdempotencyKey != "" {
+		if err := saveIdempotencyKey(ctx, tx, in); err != nil {
+			if isUniqueViolation(err) {
+				_ = tx.Rollback()
+				return s.duplicatePullRequest(ctx, in)
+			}
+			return PullRequest{}, err
+		}
+	}
+
+	pr, err := s.getPullRequest(ctx, tx, id)
+	if err != nil {
+		return PullRequest{}, err
+	}
+	if pr, err = s.ensureSecurityReviewer(ctx, tx, pr); err != nil {
+		return PullRequest{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return PullRequest{}, fmt.Errorf("commit tx: %w", err)
+	}
+
+	s.kickOutbox()
+	s.publishQueueChange(queueUsers(pr)...)
 	return pr, nil
 }
 
-// MergePullRequest marks a pull request as merged.
-func (s *Service) MergePullRequest(ctx context.Context, prID string) (PullRequest, error) {
+// MergePullRequest marks a pull request as merged and returns the final review summary,
+// computed in the same transaction as the status change.
+func (s *Service) MergePullRequest(ctx context.Context, prID string) (PullRequest, ReviewSummary, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return PullRequest{}, ReviewSummary{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	const selectPRQuery = `
+SELECT status,
+       needs_security_review AND security_approved_at IS NULL,
+       (SELECT COALESCE(u.team_name, '') FROM users u WHERE u.user_id = pull_requests.author_id),
+       cardinality({reviewers:pull_requests}),
+       (SELECT COUNT(*)
+        FROM pull_request_reviews r
+        WHERE r.pull_request_id = pull_requests.pull_request_id
+          AND r.user_id = ANY({reviewers:pull_requests})
+          AND r.status = 'APPROVED')
+FROM pull_requests
+WHERE pull_request_id = $1
+  AND deleted_at IS NULL
+FOR UPDATE
+`
+	var status, authorTeam string
+	var blocked bool
+	var assignedCount, approvals int
+	err = tx.QueryRowContext(ctx, s.expandReviewers(selectPRQuery), prID).Scan(&status, &blocked, &authorTeam, &assignedCount, &approvals)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return PullRequest{}, ReviewSummary{}, &Error{Code: ErrorCodeNotFound, Message: "pull request not found"}
+		}
+		return PullRequest{}, ReviewSummary{}, fmt.Errorf("get pull request: %w", err)
+	}
+
+	// Repeated merges keep the original snapshot and merged_at.
+	merging := status != "MERGED"
+	var settings TeamSettings
+	if merging {
+		settings, err = s.teamSettings(ctx, tx, authorTeam)
+		if err != nil {
+			return PullRequest{}, ReviewSummary{}, err
+		}
+		if required := min(settings.RequiredApprovals, assignedCount); approvals < required {
+			return PullRequest{}, ReviewSummary{}, &Error{
+				Code:    ErrorCodeNotEnoughApprovals,
+				Message: fmt.Sprintf("%d of %d required approvals", approvals, required),
+			}
+		}
+
+		if blocked {
+			// Retry the security reviewer assignment in case it was not possible on the last approval.
+			pr, err := s.getPullRequest(ctx, tx, prID)
+			if err != nil {
+				return PullRequest{}, ReviewSummary{}, err
+			}
+			if _, err := s.ensureSecurityReviewer(ctx, tx, pr); err != nil {
+				return PullRequest{}, ReviewSummary{}, err
+			}
+			if err := tx.Commit(); err != nil {
+				return PullRequest{}, ReviewSummary{}, fmt.Errorf("commit tx: %w", err)
+			}
+			return PullRequest{}, ReviewSummary{}, &Error{
+				Code:    ErrorCodeSecurityReviewRequired,
+				Message: "security review is required before merge",
+			}
+		}
+
+		if err := snapshotMerge(ctx, tx, prID); err != nil {
+			return PullRequest{}, ReviewSummary{}, err
+		}
+		if err := s.syncReviewers(ctx, tx, prID, []string{}); err != nil {
+			return PullRequest{}, ReviewSummary{}, err
+		}
+	}
+
+	// Reviewers are unassigned on merge; merged pull requests read them from the snapshot.
 	const query = `
 UPDATE pull_requests
 SET status = 'MERGED',
-    merged_at = COALESCE(merged_at, NOW())
+    merged_at = COALESCE(merged_at, NOW()),
+    assigned_reviewers = '{}'
 WHERE pull_request_id = $1
-RETURNING pull_request_id, pull_request_name, author_id, status, assigned_reviewers, created_at, merged_at
-`
-	var pr PullRequest
-	var createdAt time.Time
-	var mergedAt sql.NullTime
-	err := s.db.QueryRowContext(ctx, query, prID).
-		Scan(&pr.ID, &pr.Name, &pr.AuthorID, &pr.Status, pq.Array(&pr.AssignedReviewers), &createdAt, &mergedAt)
+  AND deleted_at IS NULL
+RETURNING ` + pullRequestColumns
+	pr, err := s.scanPullRequest(tx.QueryRowContext(ctx, s.expandReviewers(query), prID))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return PullRequest{}, &Error{Code: ErrorCodeNotFound, Message: "pull request not found"}
+			return PullRequest{}, ReviewSummary{}, &Error{Code: ErrorCodeNotFound, Message: "pull request not found"}
 		}
-		return PullRequest{}, fmt.Errorf("merge pull request: %w", err)
+		return PullRequest{}, ReviewSummary{}, fmt.Errorf("merge pull request: %w", err)
 	}
-	pr.CreatedAt = &createdAt
-	if mergedAt.Valid {
-		t := mergedAt.Time
-		pr.MergedAt = &t
+
+	summary, err := reviewSummary(ctx, tx, pr)
+	if err != nil {
+		return PullRequest{}, ReviewSummary{}, err
 	}
-	return pr, nil
+	if merging {
+		if err := s.enqueueJiraMerge(ctx, tx, pr, settings); err != nil {
+			return PullRequest{}, ReviewSummary{}, err
+		}
+		data := map[string]any{"pull_request_id": pr.ID, "author_id": pr.AuthorID, "merged_at": pr.MergedAt}
+		if err := s.enqueueWebhookEvent(ctx, tx, WebhookEventPullRequestMerged, data); err != nil {
+			return PullRequest{}, ReviewSummary{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return PullRequest{}, ReviewSummary{}, fmt.Errorf("commit tx: %w", err)
+	}
+	if merging {
+		s.kickOutbox()
+	}
+
+	reviewers := queueUsers(pr)
+	for _, r := range summary.Reviewers {
+		reviewers = append(reviewers, r.UserID)
+	}
+	s.publishQueueChange(reviewers...)
+	return pr, summary, nil
+}
+
+// ReassignReviewer reassigns a reviewer on a pull request to another active
+// teammate. It also returns every reviewer previously unassigned from the
+// pull request, including oldUserID.
+func (s *Service) ReassignReviewer(ctx context.Context, prID, oldUserID string) (PullRequest, string, []PreviousReviewer, error) {
+	return s.replaceReviewer(ctx, prID, oldUserID, AssignmentReasonReassigned)
+}
+
+// DeclineReview lets an assigned reviewer refuse a pull request. The reviewer
+// is replaced the same way as on reassignment, but the replacement is recorded
+// as DECLINED so that declines can be told apart in stats.
+func (s *Service) DeclineReview(ctx context.Context, prID, userID string) (PullRequest, string, []PreviousReviewer, error) {
+	return s.replaceReviewer(ctx, prID, userID, AssignmentReasonDeclined)
 }
 
-// ReassignReviewer reassigns a reviewer on a pull request to another active teammate.
-func (s *Service) ReassignReviewer(ctx context.Context, prID, oldUserID string) (PullRequest, string, error) {
+// replaceReviewer swaps oldUserID for another active teammate and records the
+// replacement with the given reason.
+func (s *Service) replaceReviewer(ctx context.Context, prID, oldUserID, reason string) (PullRequest, string, []PreviousReviewer, error) {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return PullRequest{}, "", fmt.Errorf("begin tx: %w", err)
+		return PullRequest{}, "", nil, fmt.Errorf("begin tx: %w", err)
 	}
 	defer func() {
 		_ = tx.Rollback()
 	}()
 
 	const selectPRQuery = `
-SELECT author_id, status, assigned_reviewers
+SELECT author_id, status, assigned_reviewers, co_author_ids
 FROM pull_requests
 WHERE pull_request_id = $1
+  AND deleted_at IS NULL
 FOR UPDATE
 `
 	var authorID string
 	var status string
-	var assigned []string
-	err = tx.QueryRowContext(ctx, selectPRQuery, prID).
-		Scan(&authorID, &status, pq.Array(&assigned))
+	var assigned, coAuthors []string
+	err = tx.QueryRowContext(ctx, s.expandReviewers(selectPRQuery), prID).
+		Scan(&authorID, &status, pq.Array(&assigned), pq.Array(&coAuthors))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return PullRequest{}, "", &Error{Code: ErrorCodeNotFound, Message: "pull request not found"}
+			return PullRequest{}, "", nil, &Error{Code: ErrorCodeNotFound, Message: "pull request not found"}
 		}
-		return PullRequest{}, "", fmt.Errorf("get pull request: %w", err)
+		return PullRequest{}, "", nil, fmt.Errorf("get pull request: %w", err)
 	}
 
 	if status == "MERGED" {
-		return PullRequest{}, "", &Error{Code: ErrorCodePRMerged, Message: "cannot reassign on merged PR"}
+		return PullRequest{}, "", nil, &Error{Code: ErrorCodePRMerged, Message: "cannot reassign on merged PR"}
 	}
 
 	if !isReviewerAssigned(assigned, oldUserID) {
-		return PullRequest{}, "", &Error{Code: ErrorCodeNotAssigned, Message: "reviewer is not assigned to this PR"}
+		return PullRequest{}, "", nil, &Error{Code: ErrorCodeNotAssigned, Message: "reviewer is not assigned to this PR"}
 	}
 
-	const selectUserTeamQuery = `SELECT team_name FROM users WHERE user_id = $1`
+	// A reviewer without a team has no replacement candidates.
+	const selectUserTeamQuery = `SELECT COALESCE(team_name, '') FROM users WHERE user_id = $1`
 	var teamName string
 	err = tx.QueryRowContext(ctx, selectUserTeamQuery, oldUserID).Scan(&teamName)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return PullRequest{}, "", &Error{Code: ErrorCodeNotFound, Message: "user not found"}
+			return PullRequest{}, "", nil, &Error{Code: ErrorCodeNotFound, Message: "user not found"}
 		}
-		return PullRequest{}, "", fmt.Errorf("get user team: %w", err)
+		return PullRequest{}, "", nil, fmt.Errorf("get user team: %w", err)
+	}
+
+	settings, err := s.teamSettings(ctx, tx, teamName)
+	if err != nil {
+		return PullRequest{}, "", nil, err
 	}
 
+	// With require_senior a senior is preferred when no remaining reviewer is one.
 	const selectCandidateQuery = `
 SELECT user_id
 FROM users
@@ -269,17 +713,35 @@ WHERE team_name = $1
   AND user_id <> $2
   AND user_id <> $3
   AND NOT (user_id = ANY($4))
-ORDER BY random()
+  AND NOT (user_id = ANY($5))
+  AND NOT ($6 AND role = 'LEAD')
+ORDER BY ($7 AND role = 'SENIOR' AND NOT EXISTS (
+             SELECT 1 FROM users a
+             WHERE a.user_id = ANY($4) AND a.user_id <> $2 AND a.role = 'SENIOR'
+         )) DESC,
+         random()
 LIMIT 1
 `
 	var newUserID string
-	err = tx.QueryRowContext(ctx, selectCandidateQuery, teamName, oldUserID, authorID, pq.Array(assigned)).
+	err = tx.QueryRowContext(ctx, selectCandidateQuery, teamName, oldUserID, authorID, pq.Array(assigned), pq.Array(coAuthors),
+		settings.ExcludeLeads, settings.RequireSenior).
 		Scan(&newUserID)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return PullRequest{}, "", &Error{Code: ErrorCodeNoCandidate, Message: "no active replacement candidate in team"}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return PullRequest{}, "", nil, fmt.Errorf("select replacement reviewer: %w", err)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		var fallback []string
+		if settings.OrgFallback {
+			exclude := append(append([]string{authorID}, coAuthors...), assigned...)
+			fallback, err = orgFallbackReviewers(ctx, tx, teamName, exclude, settings.ExcludeLeads, 1)
+			if err != nil {
+				return PullRequest{}, "", nil, err
+			}
+		}
+		if len(fallback) == 0 {
+			return PullRequest{}, "", nil, &Error{Code: ErrorCodeNoCandidate, Message: "no active replacement candidate in team"}
 		}
-		return PullRequest{}, "", fmt.Errorf("select replacement reviewer: %w", err)
+		newUserID = fallback[0]
 	}
 
 	newAssigned := replaceReviewer(assigned, oldUserID, newUserID)
@@ -288,41 +750,70 @@ LIMIT 1
 UPDATE pull_requests
 SET assigned_reviewers = $2
 WHERE pull_request_id = $1
-RETURNING pull_request_id, pull_request_name, author_id, status, assigned_reviewers, created_at, merged_at
 `
-	var pr PullRequest
-	var createdAt time.Time
-	var mergedAt sql.NullTime
-	err = tx.QueryRowContext(ctx, updatePRQuery, prID, pq.Array(newAssigned)).
-		Scan(&pr.ID, &pr.Name, &pr.AuthorID, &pr.Status, pq.Array(&pr.AssignedReviewers), &createdAt, &mergedAt)
+	if _, err := tx.ExecContext(ctx, updatePRQuery, prID, pq.Array(newAssigned)); err != nil {
+		return PullRequest{}, "", nil, fmt.Errorf("update pull request reviewers: %w", err)
+	}
+
+	if err := s.syncReviewers(ctx, tx, prID, newAssigned); err != nil {
+		return PullRequest{}, "", nil, err
+	}
+
+	if err := recordReplacement(ctx, tx, prID, oldUserID, newUserID, reason, AssignmentStrategyRandom); err != nil {
+		return PullRequest{}, "", nil, err
+	}
+
+	if err := s.enqueueReplaced(ctx, tx, prID, oldUserID, newUserID, reason); err != nil {
+		return PullRequest{}, "", nil, err
+	}
+
+	if err := s.enqueueCodeHostSync(ctx, tx, prID, []string{oldUserID}); err != nil {
+		return PullRequest{}, "", nil, err
+	}
+
+	pr, err := s.getPullRequest(ctx, tx, prID)
 	if err != nil {
-		return PullRequest{}, "", fmt.Errorf("update pull request reviewers: %w", err)
+		return PullRequest{}, "", nil, err
 	}
 
-	if err := tx.Commit(); err != nil {
-		return PullRequest{}, "", fmt.Errorf("commit tx: %w", err)
+	events, err := assignmentEvents(ctx, tx, prID)
+	if err != nil {
+		return PullRequest{}, "", nil, err
 	}
 
-	pr.CreatedAt = &createdAt
-	if mergedAt.Valid {
-		t := mergedAt.Time
-		pr.MergedAt = &t
+	if err := tx.Commit(); err != nil {
+		return PullRequest{}, "", nil, fmt.Errorf("commit tx: %w", err)
 	}
 
-	return pr, newUserID, nil
+	s.kickOutbox()
+	s.publishQueueChange(oldUserID, newUserID)
+	return pr, newUserID, previousReviewers(events), nil
 }
 
-// GetUserReviews returns pull requests where the user is assigned as a reviewer.
-func (s *Service) GetUserReviews(ctx context.Context, userID string) ([]PullRequestShort, error) {
-	const query = `
-SELECT pull_request_id, pull_request_name, author_id, status
-FROM pull_requests
-WHERE $1 = ANY(assigned_reviewers)
-ORDER BY pull_request_id
+// GetUserReviews returns a page of pull requests where the user is assigned as
+// a reviewer or as the security reviewer, and the cursor key of the next page.
+func (s *Service) GetUserReviews(ctx context.Context, userID string, page Page) ([]PullRequestShort, string, error) {
+	// The queue is ordered for triage: higher priority first, then the
+	// closest deadline, then the oldest pull request.
+	const orderBy = `ORDER BY ` + priorityRank + ` DESC, p.deadline NULLS LAST, p.created_at, p.pull_request_id
+LIMIT $3 OFFSET $4`
+	// The cursor condition compares the same order as an ascending row.
+	const after = `  AND ($2 = '' OR (-` + priorityRank + `, COALESCE(p.deadline, 'infinity'), p.created_at, p.pull_request_id) > (
+      SELECT -` + priorityRank + `, COALESCE(deadline, 'infinity'), created_at, pull_request_id
+      FROM pull_requests
+      WHERE pull_request_id = $2))
 `
-	rows, err := s.db.QueryContext(ctx, query, userID)
+
+	query := `
+SELECT ` + reviewQueueColumns("$1") + `
+FROM pull_requests p
+WHERE ` + reviewQueueMember("$1") + `
+  AND p.deleted_at IS NULL
+  AND p.archived_at IS NULL
+` + after + orderBy
+	rows, err := s.db.QueryContext(ctx, s.expandReviewers(query), userID, page.After, page.fetchLimit(), page.Offset)
 	if err != nil {
-		return nil, fmt.Errorf("get user reviews: %w", err)
+		return nil, "", fmt.Errorf("get user reviews: %w", err)
 	}
 	defer func() {
 		_ = rows.Close()
@@ -332,28 +823,99 @@ ORDER BY pull_request_id
 
 	for rows.Next() {
 		var pr PullRequestShort
-		if err := rows.Scan(&pr.ID, &pr.Name, &pr.AuthorID, &pr.Status); err != nil {
-			return nil, fmt.Errorf("scan user reviews: %w", err)
+		var createdAt time.Time
+		var deadline sql.NullTime
+		if err := rows.Scan(&pr.ID, &pr.Name, &pr.AuthorID, &pr.Status, &pr.Priority, &createdAt, &deadline,
+			&pr.ReviewStatus); err != nil {
+			return nil, "", fmt.Errorf("scan user reviews: %w", err)
 		}
+		pr.CreatedAt = &createdAt
+		pr.Deadline = nullTimePtr(deadline)
 		prs = append(prs, pr)
 	}
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("user reviews rows: %w", err)
+		return nil, "", fmt.Errorf("user reviews rows: %w", err)
 	}
 
-	return prs, nil
+	prs, next := cutPage(prs, page, func(pr PullRequestShort) string { return pr.ID })
+	return prs, next, nil
+}
+
+// reviewQueueColumns selects the review queue entry of pull request p for
+// the user given by the SQL expression user.
+func reviewQueueColumns(user string) string {
+	return `p.pull_request_id, p.pull_request_name, p.author_id, p.status, p.priority, p.created_at, p.deadline,
+    CASE
+        WHEN p.security_reviewer_id = ` + user + ` THEN
+            CASE WHEN p.security_approved_at IS NULL THEN 'PENDING' ELSE 'APPROVED' END
+        WHEN EXISTS (
+            SELECT 1
+            FROM pull_request_reviews rv
+            WHERE rv.pull_request_id = p.pull_request_id
+              AND rv.user_id = ` + user + `
+              AND rv.status = 'APPROVED'
+        ) THEN 'APPROVED'
+        ELSE 'PENDING'
+    END`
+}
+
+// reviewQueueMember matches the pull requests p in the review queue of the
+// user given by the SQL expression user.
+func reviewQueueMember(user string) string {
+	return `(` + user + ` = ANY({reviewers:p})
+       OR p.security_reviewer_id = ` + user + `
+       OR EXISTS (
+           SELECT 1
+           FROM pull_request_merge_snapshots ms
+           WHERE ms.pull_request_id = p.pull_request_id
+             AND ms.user_id = ` + user + `
+       ))`
 }
 
 // SetUserIsActive updates the is_active flag for a user and cleans up assignments if needed.
 func (s *Service) SetUserIsActive(ctx context.Context, userID string, isActive bool) (User, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return User{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	u, err := s.setUserIsActive(ctx, tx, userID, isActive)
+	if err != nil {
+		return User{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return User{}, fmt.Errorf("commit tx: %w", err)
+	}
+
+	// Removed assignments may be handed to anyone.
+	if !isActive {
+		s.publishQueueChange()
+	}
+	return u, nil
+}
+
+// setUserIsActive updates the is_active flag in the caller's transaction,
+// recording the membership change and removing a deactivated user from open
+// pull requests.
+func (s *Service) setUserIsActive(ctx context.Context, q querier, userID string, isActive bool) (User, error) {
 	const query = `
-UPDATE users SET is_active = $2
-WHERE user_id = $1
-RETURNING user_id, username, team_name, is_active
+WITH prev AS (
+    SELECT user_id, is_active FROM users WHERE user_id = $1 AND deleted_at IS NULL FOR UPDATE
+)
+UPDATE users u SET is_active = $2
+FROM prev
+WHERE u.user_id = prev.user_id
+RETURNING u.user_id, u.username, COALESCE(u.team_name, ''), u.is_active, u.role, u.email, u.slack_handle, u.avatar_url, u.github_login,
+          prev.is_active
 `
 	var u User
-	err := s.db.QueryRowContext(ctx, query, userID, isActive).
-		Scan(&u.ID, &u.Name, &u.TeamName, &u.IsActive)
+	var wasActive bool
+	err := q.QueryRowContext(ctx, query, userID, isActive).
+		Scan(&u.ID, &u.Name, &u.TeamName, &u.IsActive, &u.Role, &u.Email, &u.SlackHandle, &u.AvatarURL, &u.GitHubLogin, &wasActive)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return User{}, &Error{Code: ErrorCodeNotFound, Message: "user not found"}
@@ -361,26 +923,28 @@ RETURNING user_id, username, team_name, is_active
 		return User{}, fmt.Errorf("set is_active: %w", err)
 	}
 
-	if isActive {
-		return u, nil
+	if u.TeamName != "" && wasActive != isActive {
+		eventType := MembershipDeactivated
+		if isActive {
+			eventType = MembershipActivated
+		}
+		if err := recordMembershipEvent(ctx, q, u.TeamName, userID, eventType, ""); err != nil {
+			return User{}, err
+		}
 	}
 
-	const updatePRsQuery = `
-UPDATE pull_requests
-SET assigned_reviewers = array_remove(assigned_reviewers, $1)
-WHERE $1 = ANY(assigned_reviewers)
-  AND status <> 'MERGED'
-`
-	_, err = s.db.ExecContext(ctx, updatePRsQuery, userID)
-	if err != nil {
-		return User{}, fmt.Errorf("remove inactive reviewer from pull requests: %w", err)
+	if !isActive {
+		if err := s.removeOpenAssignments(ctx, q, []string{userID}, AssignmentReasonUserDeactivated); err != nil {
+			return User{}, fmt.Errorf("remove inactive reviewer from pull requests: %w", err)
+		}
 	}
-
 	return u, nil
 }
 
-// DeactivateTeamMembers deactivates all members of a team and cleans up their assignments.
-func (s *Service) DeactivateTeamMembers(ctx context.Context, teamName string) (Team, error) {
+// DeactivateTeamMembers deactivates members of a team and cleans up their
+// assignments. A nil userIDs deactivates every member; otherwise all listed
+// users must belong to the team. The whole team is returned.
+func (s *Service) DeactivateTeamMembers(ctx context.Context, teamName string, userIDs []string) (Team, error) {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return Team{}, fmt.Errorf("begin tx: %w", err)
@@ -389,147 +953,136 @@ func (s *Service) DeactivateTeamMembers(ctx context.Context, teamName string) (T
 		_ = tx.Rollback()
 	}()
 
-	const selectTeamQuery = `SELECT team_name FROM teams WHERE team_name = $1`
-	var existing string
-	err = tx.QueryRowContext(ctx, selectTeamQuery, teamName).Scan(&existing)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return Team{}, &Error{Code: ErrorCodeNotFound, Message: "team not found"}
-		}
-		return Team{}, fmt.Errorf("get team: %w", err)
+	if err := lockTeam(ctx, tx, teamName); err != nil {
+		return Team{}, err
 	}
 
-	const selectMembersQuery = `SELECT user_id, username, is_active FROM users WHERE team_name = $1 ORDER BY user_id`
-	rows, err := tx.QueryContext(ctx, selectMembersQuery, teamName)
+	members, err := s.deactivateMembers(ctx, tx, teamName, userIDs, AssignmentReasonTeamDeactivated)
 	if err != nil {
-		return Team{}, fmt.Errorf("select team members: %w", err)
-	}
-	defer func() {
-		_ = rows.Close()
-	}()
-
-	var members []TeamMember
-	var userIDs []string
-	for rows.Next() {
-		var m TeamMember
-		if err := rows.Scan(&m.ID, &m.Name, &m.IsActive); err != nil {
-			return Team{}, fmt.Errorf("scan team member: %w", err)
-		}
-		userIDs = append(userIDs, m.ID)
-		m.IsActive = false
-		members = append(members, m)
-	}
-	if err := rows.Err(); err != nil {
-		return Team{}, fmt.Errorf("members rows: %w", err)
-	}
-
-	_, err = tx.ExecContext(ctx, `UPDATE users SET is_active = FALSE WHERE team_name = $1`, teamName)
-	if err != nil {
-		return Team{}, fmt.Errorf("deactivate users: %w", err)
-	}
-
-	if len(userIDs) > 0 {
-		const updatePRsQuery = `
-UPDATE pull_requests
-SET assigned_reviewers = array(
-    SELECT reviewer
-    FROM unnest(assigned_reviewers) AS reviewer
-    WHERE NOT (reviewer = ANY($1))
-)
-WHERE status <> 'MERGED'
-  AND assigned_reviewers && $1
-`
-		_, err = tx.ExecContext(ctx, updatePRsQuery, pq.Array(userIDs))
-		if err != nil {
-			return Team{}, fmt.Errorf("cleanup pull requests: %w", err)
-		}
+		return Team{}, err
 	}
 
 	if err := tx.Commit(); err != nil {
 		return Team{}, fmt.Errorf("commit tx: %w", err)
 	}
 
+	s.publishQueueChange()
 	return Team{
 		Name:    teamName,
 		Members: members,
 	}, nil
 }
 
-// UserAssignmentStat represents assignment statistics per user.
-type UserAssignmentStat struct {
-	UserID      string `json:"user_id"`
-	Assignments int    `json:"assignments"`
-}
-
-// PRAssignmentStat represents assignment statistics per pull request.
-type PRAssignmentStat struct {
-	PullRequestID string `json:"pull_request_id"`
-	Assignments   int    `json:"assignments"`
-}
-
-// AssignmentStats aggregates assignment statistics by user and by pull request.
-type AssignmentStats struct {
-	ByUser []UserAssignmentStat `json:"by_user"`
-	ByPR   []PRAssignmentStat   `json:"by_pr"`
+// lockTeam locks an active team row for the rest of the transaction.
+func lockTeam(ctx context.Context, q querier, teamName string) error {
+	const selectTeamQuery = `SELECT team_name FROM teams WHERE team_name = $1 AND archived_at IS NULL FOR UPDATE`
+	var existing string
+	err := q.QueryRowContext(ctx, selectTeamQuery, teamName).Scan(&existing)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &Error{Code: ErrorCodeNotFound, Message: "team not found"}
+		}
+		return fmt.Errorf("get team: %w", err)
+	}
+	return nil
 }
 
-// GetAssignmentStats returns aggregated assignment statistics.
-func (s *Service) GetAssignmentStats(ctx context.Context) (AssignmentStats, error) {
-	var stats AssignmentStats
+// deactivateMembers deactivates the listed members of a team (all of them if
+// userIDs is nil), removes them from open pull requests and returns every
+// member of the team.
+func (s *Service) deactivateMembers(ctx context.Context, q querier, teamName string, userIDs []string, reason string) ([]TeamMember, error) {
+	selected := make(map[string]bool, len(userIDs))
+	for _, id := range userIDs {
+		selected[id] = true
+	}
 
-	const byUserQuery = `
-SELECT reviewer_id, COUNT(*)
-FROM (
-  SELECT unnest(assigned_reviewers) AS reviewer_id
-  FROM pull_requests
-) t
-GROUP BY reviewer_id
-ORDER BY reviewer_id
+	const selectMembersQuery = `
+SELECT user_id, username, is_active, role, email, slack_handle, avatar_url, github_login
+FROM users
+WHERE team_name = $1
+ORDER BY user_id
 `
-	rows, err := s.db.QueryContext(ctx, byUserQuery)
+	rows, err := q.QueryContext(ctx, selectMembersQuery, teamName)
 	if err != nil {
-		return stats, fmt.Errorf("stats by user: %w", err)
+		return nil, fmt.Errorf("select team members: %w", err)
 	}
 	defer func() {
 		_ = rows.Close()
 	}()
 
+	var members []TeamMember
+	var deactivated, wasActive []string
 	for rows.Next() {
-		var st UserAssignmentStat
-		if err := rows.Scan(&st.UserID, &st.Assignments); err != nil {
-			return stats, fmt.Errorf("scan stats by user: %w", err)
+		var m TeamMember
+		if err := rows.Scan(&m.ID, &m.Name, &m.IsActive, &m.Role, &m.Email, &m.SlackHandle, &m.AvatarURL, &m.GitHubLogin); err != nil {
+			return nil, fmt.Errorf("scan team member: %w", err)
 		}
-		stats.ByUser = append(stats.ByUser, st)
+		if userIDs == nil || selected[m.ID] {
+			if m.IsActive {
+				wasActive = append(wasActive, m.ID)
+			}
+			deactivated = append(deactivated, m.ID)
+			m.IsActive = false
+			delete(selected, m.ID)
+		}
+		members = append(members, m)
 	}
 	if err := rows.Err(); err != nil {
-		return stats, fmt.Errorf("stats by user rows: %w", err)
+		return nil, fmt.Errorf("members rows: %w", err)
 	}
 
-	const byPRQuery = `
-SELECT pull_request_id, cardinality(assigned_reviewers) AS cnt
-FROM pull_requests
-ORDER BY pull_request_id
-`
-	rows2, err := s.db.QueryContext(ctx, byPRQuery)
-	if err != nil {
-		return stats, fmt.Errorf("stats by pr: %w", err)
+	if len(selected) > 0 {
+		missing := make([]string, 0, len(selected))
+		for _, id := range userIDs {
+			if selected[id] {
+				missing = append(missing, id)
+			}
+		}
+		return nil, &Error{Code: ErrorCodeNotFound, Message: "users are not members of the team: " + strings.Join(missing, ", ")}
 	}
-	defer func() {
-		_ = rows2.Close()
-	}()
 
-	for rows2.Next() {
-		var st PRAssignmentStat
-		if err := rows2.Scan(&st.PullRequestID, &st.Assignments); err != nil {
-			return stats, fmt.Errorf("scan stats by pr: %w", err)
+	const deactivateQuery = `UPDATE users SET is_active = FALSE WHERE team_name = $1 AND user_id = ANY($2)`
+	if _, err := q.ExecContext(ctx, deactivateQuery, teamName, pq.Array(deactivated)); err != nil {
+		return nil, fmt.Errorf("deactivate users: %w", err)
+	}
+	for _, id := range wasActive {
+		if err := recordMembershipEvent(ctx, q, teamName, id, MembershipDeactivated, ""); err != nil {
+			return nil, err
 		}
-		stats.ByPR = append(stats.ByPR, st)
 	}
-	if err := rows2.Err(); err != nil {
-		return stats, fmt.Errorf("stats by pr rows: %w", err)
+
+	if err := s.removeOpenAssignments(ctx, q, deactivated, reason); err != nil {
+		return nil, fmt.Errorf("cleanup pull requests: %w", err)
 	}
 
-	return stats, nil
+	return members, nil
+}
+
+// checkCoAuthors deduplicates co-author IDs, drops the author and verifies
+// that every co-author exists.
+func (s *Service) checkCoAuthors(ctx context.Context, authorID string, ids []string) ([]string, error) {
+	coAuthors := make([]string, 0, len(ids))
+	seen := map[string]bool{authorID: true}
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		coAuthors = append(coAuthors, id)
+	}
+	if len(coAuthors) == 0 {
+		return coAuthors, nil
+	}
+
+	const query = `SELECT COUNT(*) FROM users WHERE user_id = ANY($1)`
+	var found int
+	if err := s.db.QueryRowContext(ctx, query, pq.Array(coAuthors)).Scan(&found); err != nil {
+		return nil, fmt.Errorf("check co-authors: %w", err)
+	}
+	if found != len(coAuthors) {
+		return nil, &Error{Code: ErrorCodeNotFound, Message: "co-author not found"}
+	}
+	return coAuthors, nil
 }
 
 func isReviewerAssigned(assigned []string, oldUserID string) bool {
@@ -552,3 +1105,128 @@ func replaceReviewer(assigned []string, oldUserID, newUserID string) []string {
 	}
 	return newAssigned
 }
+
+// pullRequestColumns lists the columns scanned by scanPullRequest. It is used
+// both in SELECT lists and RETURNING clauses, so pull_requests must not be
+// aliased. Queries using it go through expandReviewers.
+const pullRequestColumns = `pull_request_id, pull_request_name, author_id, status,
+    CASE WHEN status = 'MERGED' THEN ` + snapshotReviewers + ` ELSE {reviewers:pull_requests} END AS assigned_reviewers,
+    created_at, merged_at, priority,
+    co_author_ids, labels, jira_issues, github_repo, github_number, deleted_at, deadline, archived_at,
+    needs_security_review, security_reviewer_id, security_approved_at,
+    (SELECT MIN(r.reviewed_at)
+     FROM pull_request_reviews r
+     WHERE r.pull_request_id = pull_requests.pull_request_id
+       AND r.status = 'APPROVED') AS first_review_at,
+    CASE
+        WHEN status = 'MERGED' THEN (
+            SELECT CASE WHEN bool_and(ms.review_status = 'APPROVED') THEN MAX(ms.reviewed_at) END
+            FROM pull_request_merge_snapshots ms
+            WHERE ms.pull_request_id = pull_requests.pull_request_id
+        )
+        WHEN cardinality({reviewers:pull_requests}) > 0 AND NOT EXISTS (
+            SELECT 1
+            FROM unnest({reviewers:pull_requests}) AS a(user_id)
+            WHERE NOT EXISTS (
+                SELECT 1
+                FROM pull_request_reviews r
+                WHERE r.pull_request_id = pull_requests.pull_request_id
+                  AND r.user_id = a.user_id
+                  AND r.status = 'APPROVED'
+            )
+        )
+        THEN (SELECT MAX(r.reviewed_at)
+              FROM pull_request_reviews r
+              WHERE r.pull_request_id = pull_requests.pull_request_id
+                AND r.user_id = ANY({reviewers:pull_requests})
+                AND r.status = 'APPROVED')
+    END AS approved_at
+`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanFunc adapts a function to rowScanner, which lets callers append
+// their own columns after pullRequestColumns.
+type scanFunc func(dest ...any) error
+
+func (f scanFunc) Scan(dest ...any) error {
+	return f(dest...)
+}
+
+// scanPullRequest reads a row selected with pullRequestColumns.
+func (s *Service) scanPullRequest(row rowScanner) (PullRequest, error) {
+	var pr PullRequest
+	var createdAt time.Time
+	var mergedAt, deletedAt, deadline, archivedAt, securityApprovedAt, firstReviewAt, approvedAt sql.NullTime
+	var securityReviewerID sql.NullString
+	err := row.Scan(&pr.ID, &pr.Name, &pr.AuthorID, &pr.Status, pq.Array(&pr.AssignedReviewers),
+		&createdAt, &mergedAt, &pr.Priority, pq.Array(&pr.CoAuthorIDs), pq.Array(&pr.Labels), pq.Array(&pr.JiraIssues),
+		&pr.GitHubRepo, &pr.GitHubNumber, &deletedAt, &deadline, &archivedAt,
+		&pr.NeedsSecurityReview, &securityReviewerID, &securityApprovedAt,
+		&firstReviewAt, &approvedAt)
+	if err != nil {
+		return PullRequest{}, err
+	}
+
+	pr.CreatedAt = &createdAt
+	pr.MergedAt = nullTimePtr(mergedAt)
+	pr.DeletedAt = nullTimePtr(deletedAt)
+	pr.Deadline = nullTimePtr(deadline)
+	pr.ArchivedAt = nullTimePtr(archivedAt)
+	pr.FirstReviewAt = nullTimePtr(firstReviewAt)
+	pr.ApprovedAt = nullTimePtr(approvedAt)
+	pr.SecurityReviewerID = nullStringPtr(securityReviewerID)
+	pr.SecurityApprovedAt = nullTimePtr(securityApprovedAt)
+	pr.SecurityReviewStatus = securityReviewStatus(pr)
+	return pr, nil
+}
+
+// GetPullRequest returns a pull request by ID, including merged ones.
+// Deleted pull requests are not found unless includeDeleted is set.
+func (s *Service) GetPullRequest(ctx context.Context, prID string, includeDeleted bool) (PullRequest, error) {
+	pr, err := s.getPullRequest(ctx, s.db, prID)
+	if err != nil {
+		return PullRequest{}, err
+	}
+	if pr.DeletedAt != nil && !includeDeleted {
+		return PullRequest{}, &Error{Code: ErrorCodeNotFound, Message: "pull request not found"}
+	}
+	return pr, nil
+}
+
+func (s *Service) getPullRequest(ctx context.Context, q querier, prID string) (PullRequest, error) {
+	const query = `SELECT ` + pullRequestColumns + ` FROM pull_requests WHERE pull_request_id = $1`
+	pr, err := s.scanPullRequest(q.QueryRowContext(ctx, s.expandReviewers(query), prID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return PullRequest{}, &Error{Code: ErrorCodeNotFound, Message: "pull request not found"}
+		}
+		return PullRequest{}, fmt.Errorf("get pull request: %w", err)
+	}
+	return pr, nil
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	v := t.Time
+	return &v
+}
+
+func nullStringPtr(v sql.NullString) *string {
+	if !v.Valid {
+		return nil
+	}
+	return &v.String
+}
+
+func nullFloatPtr(f sql.NullFloat64) *float64 {
+	if !f.Valid {
+		return nil
+	}
+	v := f.Float64
+	return &v
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"context"
+	"fmt"
+)
+
+// AssignmentReasonMerged marks reviewers unassigned because the pull request was merged.
+const AssignmentReasonMerged = "PR_MERGED"
+
+// snapshotReviewers selects the reviewers frozen at merge time, in assignment order.
+const snapshotReviewers = `ARRAY(
+        SELECT ms.user_id
+        FROM pull_request_merge_snapshots ms
+        WHERE ms.pull_request_id = pull_requests.pull_request_id
+        ORDER BY ms.position
+    )`
+
+// snapshotMerge freezes the reviewers of a pull request that is about to be
+// merged together with their review status, and records their unassignment.
+// Merged pull requests are read from the snapshot, so later deactivations
+// cannot change their history.
+func snapshotMerge(ctx context.Context, q querier, prID string) error {
+	const snapshotQuery = `
+INSERT INTO pull_request_merge_snapshots(pull_request_id, user_id, position, review_status, reviewed_at)
+SELECT p.pull_request_id,
+       a.user_id,
+       a.position,
+       CASE WHEN r.user_id IS NULL THEN 'PENDING' ELSE 'APPROVED' END,
+       r.reviewed_at
+FROM pull_requests p
+CROSS JOIN LATERAL unnest(p.assigned_reviewers) WITH ORDINALITY AS a(user_id, position)
+LEFT JOIN pull_request_reviews r
+       ON r.pull_request_id = p.pull_request_id
+      AND r.user_id = a.user_id
+      AND r.status = 'APPROVED'
+WHERE p.pull_request_id = $1
+`
+	if _, err := q.ExecContext(ctx, snapshotQuery, prID); err != nil {
+		return fmt.Errorf("snapshot merged reviewers: %w", err)
+	}
+
+	const recordQuery = `
+INSERT INTO assignment_events(pull_request_id, event_type, user_id, reason)
+SELECT pull_request_id, 'REMOVED', user_id, $2
+FROM pull_request_merge_snapshots
+WHERE pull_request_id = $1
+ORDER BY position
+`
+	if _, err := q.ExecContext(ctx, recordQuery, prID, AssignmentReasonMerged); err != nil {
+		return fmt.Errorf("record merge unassignments: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,360 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// StatsFilter narrows assignment statistics.
+type StatsFilter struct {
+	// OrgName limits statistics to pull requests authored by members of the organization.
+	OrgName string
+	// TeamName limits statistics to pull requests authored by members of the team.
+	TeamName string
+	// From and To bound the window [From, To) in which assignments were made
+	// and pull requests were created. Nil means unbounded.
+	From *time.Time
+	To   *time.Time
+	// Status limits statistics to OPEN or MERGED pull requests; empty means both.
+	Status string
+}
+
+// UserAssignmentStat represents assignment statistics per user.
+// OpenAssignments counts current load on open pull requests while
+// TotalAssignments also includes merged ones; Assignments equals
+// TotalAssignments and is kept for existing clients. Declines,
+// ReassignedAway and Removed count assignments the user lost: declined by
+// themselves, reassigned to someone else, or removed on deactivation.
+type UserAssignmentStat struct {
+	UserID           string `json:"user_id"`
+	Assignments      int    `json:"assignments"`
+	OpenAssignments  int    `json:"open_assignments"`
+	TotalAssignments int    `json:"total_assignments"`
+	Declines         int    `json:"declines"`
+	ReassignedAway   int    `json:"reassigned_away"`
+	Removed          int    `json:"removed"`
+}
+
+// PRAssignmentStat represents assignment statistics per pull request.
+type PRAssignmentStat struct {
+	PullRequestID            string   `json:"pull_request_id"`
+	Assignments              int      `json:"assignments"`
+	TimeToFirstReviewSeconds *float64 `json:"time_to_first_review_seconds,omitempty"`
+	TimeToApprovalSeconds    *float64 `json:"time_to_approval_seconds,omitempty"`
+}
+
+// TeamAssignmentStat represents assignment statistics per author team.
+type TeamAssignmentStat struct {
+	TeamName                   string  `json:"team_name"`
+	Assignments                int     `json:"assignments"`
+	PullRequests               int     `json:"pull_requests"`
+	OpenPullRequests           int     `json:"open_pull_requests"`
+	AvgReviewersPerPullRequest float64 `json:"avg_reviewers_per_pull_request"`
+}
+
+// AssignmentStats aggregates assignment statistics by user, by pull request
+// and by the team of the pull request author, plus review load fairness of
+// every team.
+type AssignmentStats struct {
+	ByUser   []UserAssignmentStat `json:"by_user"`
+	ByPR     []PRAssignmentStat   `json:"by_pr"`
+	ByTeam   []TeamAssignmentStat `json:"by_team"`
+	Fairness []TeamFairness       `json:"fairness"`
+	// TotalUsers and TotalPullRequests are the sizes of ByUser and ByPR
+	// before pagination.
+	TotalUsers        int `json:"total_users"`
+	TotalPullRequests int `json:"total_pull_requests"`
+	// ComputedAt is when the stats were calculated; cached stats keep the
+	// time of the original calculation.
+	ComputedAt time.Time `json:"computed_at"`
+}
+
+// statsAuthorsQuery selects authors matching the organization ($1) and team
+// ($4) of a StatsFilter; empty values match everyone. Queries built on it also
+// take the window bounds as $2/$3 and the pull request status as $5.
+const statsAuthorsQuery = `
+SELECT u.user_id
+FROM users u
+LEFT JOIN teams t ON t.team_name = u.team_name
+WHERE ($1 = '' OR t.org_name = $1)
+  AND ($4 = '' OR u.team_name = $4)`
+
+// statsAssignmentsQuery lists every current and merged assignment together
+// with the time it was made. The time comes from the latest ASSIGNED or
+// REPLACED event for the reviewer and falls back to the pull request creation
+// time for assignments that predate assignment history.
+const statsAssignmentsQuery = `
+SELECT a.pull_request_id, a.author_id, a.status, a.user_id, COALESCE(ev.assigned_at, a.created_at) AS assigned_at
+FROM (
+  SELECT p.pull_request_id, p.author_id, p.status, r.user_id, p.created_at
+  FROM pull_requests p
+  CROSS JOIN LATERAL unnest({reviewers:p}) AS r(user_id)
+  WHERE p.deleted_at IS NULL
+    AND p.author_id IN (` + statsAuthorsQuery + `)
+    AND ($5 = '' OR p.status = $5)
+  UNION ALL
+  SELECT ms.pull_request_id, p.author_id, p.status, ms.user_id, p.created_at
+  FROM pull_request_merge_snapshots ms
+  JOIN pull_requests p ON p.pull_request_id = ms.pull_request_id
+  WHERE p.deleted_at IS NULL
+    AND p.author_id IN (` + statsAuthorsQuery + `)
+    AND ($5 = '' OR p.status = $5)
+) a
+CROSS JOIN LATERAL (
+  SELECT MAX(e.created_at) AS assigned_at
+  FROM assignment_events e
+  WHERE e.pull_request_id = a.pull_request_id
+    AND ((e.event_type = 'ASSIGNED' AND e.user_id = a.user_id)
+      OR (e.event_type = 'REPLACED' AND e.replacement_user_id = a.user_id))
+) ev`
+
+// statsArgs checks that the organization and team of the filter exist and
+// returns the query arguments expected by statsAuthorsQuery and the $2/$3
+// window bounds.
+func (s *Service) statsArgs(ctx context.Context, f StatsFilter) ([]any, error) {
+	if f.OrgName != "" {
+		if err := checkOrganizationExists(ctx, s.db, f.OrgName); err != nil {
+			return nil, err
+		}
+	}
+	if f.TeamName != "" {
+		if err := s.checkTeamExists(ctx, f.TeamName); err != nil {
+			return nil, err
+		}
+	}
+	return []any{f.OrgName, f.From, f.To, f.TeamName, f.Status}, nil
+}
+
+// GetAssignmentStats returns aggregated assignment statistics matching the
+// filter, served from the stats cache when it is enabled and fresh.
+func (s *Service) GetAssignmentStats(ctx context.Context, f StatsFilter) (AssignmentStats, error) {
+	key := newStatsCacheKey(f)
+	now := time.Now()
+	if stats, ok := s.statsCache.get(key, now); ok {
+		return stats, nil
+	}
+
+	stats, err := s.computeAssignmentStats(ctx, f)
+	if err != nil {
+		return stats, err
+	}
+	stats.ComputedAt = now
+	s.statsCache.put(key, stats)
+	return stats, nil
+}
+
+func (s *Service) computeAssignmentStats(ctx context.Context, f StatsFilter) (AssignmentStats, error) {
+	var stats AssignmentStats
+
+	args, err := s.statsArgs(ctx, f)
+	if err != nil {
+		return stats, err
+	}
+
+	// Reviewers who only declined or lost their assignments still show up,
+	// hence the full join.
+	const byUserQuery = `
+WITH assigned AS (
+  SELECT user_id, COUNT(*) FILTER (WHERE status = 'OPEN') AS open, COUNT(*) AS total
+  FROM (` + statsAssignmentsQuery + `
+  ) t
+  WHERE ($2::timestamptz IS NULL OR assigned_at >= $2)
+    AND ($3::timestamptz IS NULL OR assigned_at < $3)
+  GROUP BY user_id
+),
+unassigned AS (
+  SELECT e.user_id,
+         COUNT(*) FILTER (WHERE e.event_type = 'REPLACED' AND e.reason = 'DECLINED') AS declines,
+         COUNT(*) FILTER (WHERE e.event_type = 'REPLACED' AND e.reason <> 'DECLINED') AS reassigned_away,
+         COUNT(*) FILTER (WHERE e.event_type = 'REMOVED') AS removed
+  FROM assignment_events e
+  JOIN pull_requests p ON p.pull_request_id = e.pull_request_id
+  WHERE e.event_type IN ('REPLACED', 'REMOVED')
+    AND p.deleted_at IS NULL
+    AND p.author_id IN (` + statsAuthorsQuery + `)
+    AND ($5 = '' OR p.status = $5)
+    AND ($2::timestamptz IS NULL OR e.created_at >= $2)
+    AND ($3::timestamptz IS NULL OR e.created_at < $3)
+  GROUP BY e.user_id
+)
+SELECT COALESCE(a.user_id, u.user_id),
+       COALESCE(a.open, 0),
+       COALESCE(a.total, 0),
+       COALESCE(u.declines, 0),
+       COALESCE(u.reassigned_away, 0),
+       COALESCE(u.removed, 0)
+FROM assigned a
+FULL JOIN unassigned u ON u.user_id = a.user_id
+ORDER BY 1
+`
+	rows, err := s.db.QueryContext(ctx, s.expandReviewers(byUserQuery), args...)
+	if err != nil {
+		return stats, fmt.Errorf("stats by user: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	for rows.Next() {
+		var st UserAssignmentStat
+		if err := rows.Scan(&st.UserID, &st.OpenAssignments, &st.TotalAssignments,
+			&st.Declines, &st.ReassignedAway, &st.Removed); err != nil {
+			return stats, fmt.Errorf("scan stats by user: %w", err)
+		}
+		st.Assignments = st.TotalAssignments
+		stats.ByUser = append(stats.ByUser, st)
+	}
+	if err := rows.Err(); err != nil {
+		return stats, fmt.Errorf("stats by user rows: %w", err)
+	}
+
+	const byPRQuery = `
+SELECT pull_request_id,
+       cardinality(assigned_reviewers) AS cnt,
+       EXTRACT(EPOCH FROM first_review_at - created_at),
+       EXTRACT(EPOCH FROM approved_at - created_at)
+FROM (
+  SELECT ` + pullRequestColumns + `
+  FROM pull_requests
+  WHERE deleted_at IS NULL
+    AND author_id IN (` + statsAuthorsQuery + `)
+    AND ($5 = '' OR status = $5)
+    AND ($2::timestamptz IS NULL OR created_at >= $2)
+    AND ($3::timestamptz IS NULL OR created_at < $3)
+) t
+ORDER BY pull_request_id
+`
+	rows2, err := s.db.QueryContext(ctx, s.expandReviewers(byPRQuery), args...)
+	if err != nil {
+		return stats, fmt.Errorf("stats by pr: %w", err)
+	}
+	defer func() {
+		_ = rows2.Close()
+	}()
+
+	for rows2.Next() {
+		var st PRAssignmentStat
+		var toFirstReview, toApproval sql.NullFloat64
+		if err := rows2.Scan(&st.PullRequestID, &st.Assignments, &toFirstReview, &toApproval); err != nil {
+			return stats, fmt.Errorf("scan stats by pr: %w", err)
+		}
+		st.TimeToFirstReviewSeconds = nullFloatPtr(toFirstReview)
+		st.TimeToApprovalSeconds = nullFloatPtr(toApproval)
+		stats.ByPR = append(stats.ByPR, st)
+	}
+	if err := rows2.Err(); err != nil {
+		return stats, fmt.Errorf("stats by pr rows: %w", err)
+	}
+
+	stats.TotalUsers = len(stats.ByUser)
+	stats.TotalPullRequests = len(stats.ByPR)
+
+	stats.ByTeam, err = s.teamAssignmentStats(ctx, args)
+	if err != nil {
+		return stats, err
+	}
+
+	stats.Fairness, err = s.teamFairness(ctx, args)
+	if err != nil {
+		return stats, err
+	}
+
+	return stats, nil
+}
+
+// teamAssignmentStats groups assignments and pull requests by the current team
+// of their author. Authors without a team are left out.
+func (s *Service) teamAssignmentStats(ctx context.Context, args []any) ([]TeamAssignmentStat, error) {
+	const query = `
+WITH prs AS (
+  SELECT u.team_name, p.status, cardinality(p.assigned_reviewers) AS reviewers
+  FROM (
+    SELECT ` + pullRequestColumns + `
+    FROM pull_requests
+    WHERE deleted_at IS NULL
+      AND author_id IN (` + statsAuthorsQuery + `)
+      AND ($5 = '' OR status = $5)
+      AND ($2::timestamptz IS NULL OR created_at >= $2)
+      AND ($3::timestamptz IS NULL OR created_at < $3)
+  ) p
+  JOIN users u ON u.user_id = p.author_id
+  WHERE u.team_name IS NOT NULL
+),
+pr_counts AS (
+  SELECT team_name,
+         COUNT(*) AS pull_requests,
+         COUNT(*) FILTER (WHERE status = 'OPEN') AS open_pull_requests,
+         AVG(reviewers)::float8 AS avg_reviewers
+  FROM prs
+  GROUP BY team_name
+),
+assignment_counts AS (
+  SELECT u.team_name, COUNT(*) AS assignments
+  FROM (` + statsAssignmentsQuery + `
+  ) a
+  JOIN users u ON u.user_id = a.author_id
+  WHERE u.team_name IS NOT NULL
+    AND ($2::timestamptz IS NULL OR a.assigned_at >= $2)
+    AND ($3::timestamptz IS NULL OR a.assigned_at < $3)
+  GROUP BY u.team_name
+)
+SELECT COALESCE(pc.team_name, ac.team_name),
+       COALESCE(ac.assignments, 0),
+       COALESCE(pc.pull_requests, 0),
+       COALESCE(pc.open_pull_requests, 0),
+       COALESCE(pc.avg_reviewers, 0)
+FROM pr_counts pc
+FULL JOIN assignment_counts ac ON ac.team_name = pc.team_name
+ORDER BY 1
+`
+	rows, err := s.db.QueryContext(ctx, s.expandReviewers(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("stats by team: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	teams := make([]TeamAssignmentStat, 0)
+	for rows.Next() {
+		var st TeamAssignmentStat
+		if err := rows.Scan(&st.TeamName, &st.Assignments, &st.PullRequests, &st.OpenPullRequests, &st.AvgReviewersPerPullRequest); err != nil {
+			return nil, fmt.Errorf("scan stats by team: %w", err)
+		}
+		teams = append(teams, st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("stats by team rows: %w", err)
+	}
+	return teams, nil
+}
+
+// Paginate returns a copy of stats with the per-user and per-pull-request
+// sections cut to page. With topN > 0 users are ordered by total assignments,
+// heaviest first, and at most topN of them are kept. Stats may come from the
+// cache, so the original slices are never modified.
+func (st AssignmentStats) Paginate(page Page, topN int) AssignmentStats {
+	users := st.ByUser
+	if topN > 0 {
+		users = append([]UserAssignmentStat(nil), users...)
+		sort.SliceStable(users, func(i, j int) bool {
+			return users[i].TotalAssignments > users[j].TotalAssignments
+		})
+		users = users[:min(topN, len(users))]
+	}
+	st.ByUser = pageSlice(users, page)
+	st.ByPR = pageSlice(st.ByPR, page)
+	return st
+}
+
+func pageSlice[T any](items []T, page Page) []T {
+	start := min(page.Offset, len(items))
+	end := len(items)
+	if page.Limit > 0 {
+		end = min(start+page.Limit, end)
+	}
+	return items[start:end]
+}
@@ -0,0 +1,93 @@
+package app
+
+import (
+	"sync"
+	"time"
+)
+
+// WithStatsCacheTTL caches assignment stats in memory for d. Zero disables caching.
+func WithStatsCacheTTL(d time.Duration) Option {
+	return func(s *Service) {
+		s.statsCache.ttl = d
+	}
+}
+
+type statsCacheKey struct {
+	orgName  string
+	teamName string
+	status   string
+	from     time.Time
+	to       time.Time
+}
+
+func newStatsCacheKey(f StatsFilter) statsCacheKey {
+	k := statsCacheKey{orgName: f.OrgName, teamName: f.TeamName, status: f.Status}
+	if f.From != nil {
+		k.from = f.From.UTC()
+	}
+	if f.To != nil {
+		k.to = f.To.UTC()
+	}
+	return k
+}
+
+// statsCache keeps recently computed assignment stats per filter so that
+// dashboards polling /stats/assignments do not rescan the tables every time.
+type statsCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[statsCacheKey]AssignmentStats
+}
+
+func newStatsCache() *statsCache {
+	return &statsCache{entries: make(map[statsCacheKey]AssignmentStats)}
+}
+
+func (c *statsCache) get(key statsCacheKey, now time.Time) (AssignmentStats, bool) {
+	if c.ttl <= 0 {
+		return AssignmentStats{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats, ok := c.entries[key]
+	if !ok || now.Sub(stats.ComputedAt) >= c.ttl {
+		return AssignmentStats{}, false
+	}
+	return stats, true
+}
+
+// put stores stats and drops expired entries, which keeps the map bounded by
+// the number of distinct filters used within one TTL.
+func (c *statsCache) put(key statsCacheKey, stats AssignmentStats) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k, v := range c.entries {
+		if stats.ComputedAt.Sub(v.ComputedAt) >= c.ttl {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = stats
+}
+
+// clear drops all cached stats and returns how many entries were dropped.
+func (c *statsCache) clear() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := len(c.entries)
+	c.entries = make(map[statsCacheKey]AssignmentStats)
+	return n
+}
+
+// RefreshStats drops cached assignment stats so that the next request
+// recomputes them. It returns the number of dropped entries.
+func (s *Service) RefreshStats() int {
+	return s.statsCache.clear()
+}
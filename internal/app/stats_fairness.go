@@ -0,0 +1,102 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// TeamFairness describes how evenly reviews are spread across the active
+// members of a team. Gini is 0 for a perfectly even load and approaches 1
+// when a single member gets every assignment.
+type TeamFairness struct {
+	TeamName    string  `json:"team_name"`
+	Members     int     `json:"members"`
+	Assignments int     `json:"assignments"`
+	MeanLoad    float64 `json:"mean_load"`
+	StdDev      float64 `json:"stddev"`
+	Gini        float64 `json:"gini"`
+}
+
+// teamFairness computes review load fairness for every team matching the
+// filter arguments, counting assignments made within the window.
+func (s *Service) teamFairness(ctx context.Context, args []any) ([]TeamFairness, error) {
+	const query = `
+SELECT u.team_name, COUNT(a.user_id)
+FROM users u
+JOIN teams t ON t.team_name = u.team_name
+LEFT JOIN (` + statsAssignmentsQuery + `
+) a ON a.user_id = u.user_id
+   AND ($2::timestamptz IS NULL OR a.assigned_at >= $2)
+   AND ($3::timestamptz IS NULL OR a.assigned_at < $3)
+WHERE u.is_active
+  AND t.archived_at IS NULL
+  AND ($1 = '' OR t.org_name = $1)
+  AND ($4 = '' OR u.team_name = $4)
+GROUP BY u.team_name, u.user_id
+ORDER BY u.team_name, u.user_id
+`
+	rows, err := s.db.QueryContext(ctx, s.expandReviewers(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("stats fairness: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var order []string
+	loads := make(map[string][]int)
+	for rows.Next() {
+		var team string
+		var load int
+		if err := rows.Scan(&team, &load); err != nil {
+			return nil, fmt.Errorf("scan stats fairness: %w", err)
+		}
+		if _, ok := loads[team]; !ok {
+			order = append(order, team)
+		}
+		loads[team] = append(loads[team], load)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("stats fairness rows: %w", err)
+	}
+
+	result := make([]TeamFairness, 0, len(order))
+	for _, team := range order {
+		result = append(result, fairnessOf(team, loads[team]))
+	}
+	return result, nil
+}
+
+// fairnessOf computes the population standard deviation and the Gini
+// coefficient of the given per-member loads.
+func fairnessOf(team string, loads []int) TeamFairness {
+	f := TeamFairness{TeamName: team, Members: len(loads)}
+	if len(loads) == 0 {
+		return f
+	}
+
+	sorted := append([]int(nil), loads...)
+	sort.Ints(sorted)
+
+	var weighted int
+	for i, l := range sorted {
+		f.Assignments += l
+		weighted += (i + 1) * l
+	}
+	n := float64(len(sorted))
+	f.MeanLoad = float64(f.Assignments) / n
+
+	var variance float64
+	for _, l := range sorted {
+		d := float64(l) - f.MeanLoad
+		variance += d * d
+	}
+	f.StdDev = math.Sqrt(variance / n)
+
+	if f.Assignments > 0 {
+		f.Gini = 2*float64(weighted)/(n*float64(f.Assignments)) - (n+1)/n
+	}
+	return f
+}
@@ -0,0 +1,159 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultForecastWeeks is the lookback used to estimate PR creation rates.
+	DefaultForecastWeeks = 4
+	// MaxForecastWeeks limits the forecast lookback.
+	MaxForecastWeeks = 52
+)
+
+// ForecastFilter selects teams for the workload forecast. Weeks is the
+// lookback used to estimate PR creation rates, DefaultForecastWeeks when 0.
+type ForecastFilter struct {
+	OrgName  string
+	TeamName string
+	Weeks    int
+}
+
+// UserForecast is the expected number of review assignments of a user next week.
+type UserForecast struct {
+	UserID              string  `json:"user_id"`
+	ExpectedAssignments float64 `json:"expected_assignments"`
+}
+
+// TeamForecast is the expected review load of a team next week under its
+// current settings.
+type TeamForecast struct {
+	TeamName           string         `json:"team_name"`
+	Strategy           string         `json:"strategy"`
+	ReviewerCount      int            `json:"reviewer_count"`
+	WeeklyPullRequests float64        `json:"weekly_pull_requests"`
+	Users              []UserForecast `json:"users"`
+}
+
+type forecastMember struct {
+	reviewerCandidate
+	eligible bool
+	created  int
+}
+
+// GetWorkloadForecast estimates next week's assignments per user from the
+// average weekly PR creation rate of every author over the lookback and the
+// team's current strategy. Org fallback and security reviews are not modelled.
+func (s *Service) GetWorkloadForecast(ctx context.Context, f ForecastFilter) ([]TeamForecast, error) {
+	weeks := f.Weeks
+	if weeks == 0 {
+		weeks = DefaultForecastWeeks
+	}
+	since := time.Now().AddDate(0, 0, -7*weeks)
+	args, err := s.statsArgs(ctx, StatsFilter{OrgName: f.OrgName, TeamName: f.TeamName, From: &since})
+	if err != nil {
+		return nil, err
+	}
+
+	const query = `
+SELECT u.team_name, u.user_id, u.role, u.is_active, COUNT(p.pull_request_id)
+FROM users u
+JOIN teams t ON t.team_name = u.team_name
+LEFT JOIN pull_requests p ON p.author_id = u.user_id
+  AND p.deleted_at IS NULL
+  AND p.created_at >= $2
+  AND ($3::timestamptz IS NULL OR p.created_at < $3)
+  AND ($5 = '' OR p.status = $5)
+WHERE t.archived_at IS NULL
+  AND ($1 = '' OR t.org_name = $1)
+  AND ($4 = '' OR u.team_name = $4)
+GROUP BY u.team_name, u.user_id, u.role, u.is_active
+ORDER BY u.team_name, u.user_id
+`
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("workload forecast: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var order []string
+	members := make(map[string][]forecastMember)
+	for rows.Next() {
+		var team string
+		var m forecastMember
+		if err := rows.Scan(&team, &m.ID, &m.Role, &m.eligible, &m.created); err != nil {
+			return nil, fmt.Errorf("scan workload forecast: %w", err)
+		}
+		if _, ok := members[team]; !ok {
+			order = append(order, team)
+		}
+		members[team] = append(members[team], m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("workload forecast rows: %w", err)
+	}
+
+	result := make([]TeamForecast, 0, len(order))
+	for _, team := range order {
+		settings, err := s.teamSettings(ctx, s.db, team)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, forecastTeam(settings, members[team], weeks))
+	}
+	return result, nil
+}
+
+// forecastTeam spreads the weekly PR rate of every author over the reviewers
+// the strategy would pick: the same reviewers every time for TEAM_ORDER and
+// an even share of the candidates for RANDOM.
+func forecastTeam(settings TeamSettings, members []forecastMember, weeks int) TeamForecast {
+	tf := TeamForecast{
+		TeamName:      settings.TeamName,
+		Strategy:      settings.Strategy,
+		ReviewerCount: settings.ReviewerCount,
+		Users:         make([]UserForecast, 0),
+	}
+
+	expected := make(map[string]float64)
+	for _, author := range members {
+		if author.created == 0 {
+			continue
+		}
+		rate := float64(author.created) / float64(weeks)
+		tf.WeeklyPullRequests += rate
+
+		var candidates []reviewerCandidate
+		for _, m := range members {
+			if m.ID == author.ID || !m.eligible || (settings.ExcludeLeads && m.Role == RoleLead) {
+				continue
+			}
+			candidates = append(candidates, m.reviewerCandidate)
+		}
+		if len(candidates) == 0 {
+			continue
+		}
+
+		if settings.Strategy == AssignmentStrategyRandom {
+			share := rate * float64(min(settings.ReviewerCount, len(candidates))) / float64(len(candidates))
+			for _, c := range candidates {
+				expected[c.ID] += share
+			}
+			continue
+		}
+		for _, id := range pickReviewers(candidates, settings.ReviewerCount, settings.RequireSenior) {
+			expected[id] += rate
+		}
+	}
+
+	for _, m := range members {
+		if m.eligible && !(settings.ExcludeLeads && m.Role == RoleLead) {
+			tf.Users = append(tf.Users, UserForecast{UserID: m.ID, ExpectedAssignments: expected[m.ID]})
+		}
+	}
+	return tf
+}
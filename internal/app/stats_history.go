@@ -0,0 +1,129 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// statsSnapshotDateLayout is the format of snapshot dates in requests and responses.
+const statsSnapshotDateLayout = "2006-01-02"
+
+// StatsSnapshot describes a single daily snapshot of assignment stats.
+type StatsSnapshot struct {
+	Date  string `json:"date"`
+	Users int    `json:"users"`
+}
+
+// StatsHistoryFilter narrows the stats time series. From and To bound
+// snapshot dates as [From, To); nil means unbounded.
+type StatsHistoryFilter struct {
+	TeamName string
+	UserID   string
+	From     *time.Time
+	To       *time.Time
+}
+
+// StatsHistoryPoint holds assignment stats of a single day.
+type StatsHistoryPoint struct {
+	Date             string               `json:"date"`
+	OpenAssignments  int                  `json:"open_assignments"`
+	TotalAssignments int                  `json:"total_assignments"`
+	ByUser           []UserAssignmentStat `json:"by_user"`
+}
+
+// SnapshotStats stores per-user assignment counts for the UTC day of at.
+// Running it again on the same day overwrites that day's snapshot, so the
+// last run of a day wins.
+func (s *Service) SnapshotStats(ctx context.Context, at time.Time) (StatsSnapshot, error) {
+	snapshot := StatsSnapshot{Date: at.UTC().Format(statsSnapshotDateLayout)}
+
+	const query = `
+INSERT INTO stats_history (snapshot_date, user_id, team_name, open_assignments, total_assignments)
+SELECT $1::date, u.user_id, u.team_name, COALESCE(a.open, 0), COALESCE(a.total, 0)
+FROM users u
+LEFT JOIN (
+  SELECT user_id, COUNT(*) FILTER (WHERE status = 'OPEN') AS open, COUNT(*) AS total
+  FROM (
+    SELECT p.status, r.user_id
+    FROM pull_requests p
+    CROSS JOIN LATERAL unnest({reviewers:p}) AS r(user_id)
+    WHERE p.deleted_at IS NULL
+    UNION ALL
+    SELECT p.status, ms.user_id
+    FROM pull_request_merge_snapshots ms
+    JOIN pull_requests p ON p.pull_request_id = ms.pull_request_id
+    WHERE p.deleted_at IS NULL
+  ) t
+  GROUP BY user_id
+) a ON a.user_id = u.user_id
+WHERE u.team_name IS NOT NULL OR a.total > 0
+ON CONFLICT (snapshot_date, user_id) DO UPDATE
+SET team_name = EXCLUDED.team_name,
+    open_assignments = EXCLUDED.open_assignments,
+    total_assignments = EXCLUDED.total_assignments
+`
+	res, err := s.db.ExecContext(ctx, s.expandReviewers(query), snapshot.Date)
+	if err != nil {
+		return snapshot, fmt.Errorf("snapshot stats: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return snapshot, fmt.Errorf("snapshot stats rows: %w", err)
+	}
+	snapshot.Users = int(n)
+	return snapshot, nil
+}
+
+// GetStatsHistory returns daily snapshots matching the filter, oldest first.
+// The team filter uses the team a user belonged to on the snapshot day.
+func (s *Service) GetStatsHistory(ctx context.Context, f StatsHistoryFilter) ([]StatsHistoryPoint, error) {
+	var from, to *string
+	if f.From != nil {
+		d := f.From.UTC().Format(statsSnapshotDateLayout)
+		from = &d
+	}
+	if f.To != nil {
+		d := f.To.UTC().Format(statsSnapshotDateLayout)
+		to = &d
+	}
+
+	const query = `
+SELECT to_char(snapshot_date, 'YYYY-MM-DD'), user_id, open_assignments, total_assignments
+FROM stats_history
+WHERE ($1 = '' OR team_name = $1)
+  AND ($2 = '' OR user_id = $2)
+  AND ($3::date IS NULL OR snapshot_date >= $3::date)
+  AND ($4::date IS NULL OR snapshot_date < $4::date)
+ORDER BY snapshot_date, user_id
+`
+	rows, err := s.db.QueryContext(ctx, query, f.TeamName, f.UserID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("stats history: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	points := make([]StatsHistoryPoint, 0)
+	for rows.Next() {
+		var date string
+		var st UserAssignmentStat
+		if err := rows.Scan(&date, &st.UserID, &st.OpenAssignments, &st.TotalAssignments); err != nil {
+			return nil, fmt.Errorf("scan stats history: %w", err)
+		}
+		st.Assignments = st.TotalAssignments
+
+		if len(points) == 0 || points[len(points)-1].Date != date {
+			points = append(points, StatsHistoryPoint{Date: date})
+		}
+		p := &points[len(points)-1]
+		p.OpenAssignments += st.OpenAssignments
+		p.TotalAssignments += st.TotalAssignments
+		p.ByUser = append(p.ByUser, st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("stats history rows: %w", err)
+	}
+	return points, nil
+}
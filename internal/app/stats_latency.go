@@ -0,0 +1,130 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// LatencySummary describes a distribution of durations in seconds.
+// The aggregates are nil when there are no samples.
+type LatencySummary struct {
+	Samples       int      `json:"samples"`
+	AvgSeconds    *float64 `json:"avg_seconds,omitempty"`
+	MedianSeconds *float64 `json:"median_seconds,omitempty"`
+	P90Seconds    *float64 `json:"p90_seconds,omitempty"`
+}
+
+// TeamLatency holds review latencies of pull requests authored by a team.
+type TeamLatency struct {
+	TeamName            string         `json:"team_name"`
+	TimeToFirstApproval LatencySummary `json:"time_to_first_approval"`
+	TimeToMerge         LatencySummary `json:"time_to_merge"`
+}
+
+// UserLatency holds review latencies of pull requests authored by a user.
+type UserLatency struct {
+	UserID              string         `json:"user_id"`
+	TimeToFirstApproval LatencySummary `json:"time_to_first_approval"`
+	TimeToMerge         LatencySummary `json:"time_to_merge"`
+}
+
+// LatencyStats groups review latencies by author team and by author.
+type LatencyStats struct {
+	ByTeam []TeamLatency `json:"by_team"`
+	ByUser []UserLatency `json:"by_user"`
+}
+
+// GetLatencyStats returns time from pull request creation to the first
+// approval and to merge for pull requests created within the filter window.
+func (s *Service) GetLatencyStats(ctx context.Context, f StatsFilter) (LatencyStats, error) {
+	stats := LatencyStats{
+		ByTeam: make([]TeamLatency, 0),
+		ByUser: make([]UserLatency, 0),
+	}
+
+	args, err := s.statsArgs(ctx, f)
+	if err != nil {
+		return stats, err
+	}
+
+	const query = `
+WITH prs AS (
+  SELECT p.author_id,
+         u.team_name,
+         EXTRACT(EPOCH FROM (
+           SELECT MIN(r.reviewed_at)
+           FROM pull_request_reviews r
+           WHERE r.pull_request_id = p.pull_request_id
+             AND r.status = 'APPROVED'
+         ) - p.created_at)::float8 AS to_approval,
+         EXTRACT(EPOCH FROM p.merged_at - p.created_at)::float8 AS to_merge
+  FROM pull_requests p
+  JOIN users u ON u.user_id = p.author_id
+  WHERE p.deleted_at IS NULL
+    AND p.author_id IN (` + statsAuthorsQuery + `)
+    AND ($5 = '' OR p.status = $5)
+    AND ($2::timestamptz IS NULL OR p.created_at >= $2)
+    AND ($3::timestamptz IS NULL OR p.created_at < $3)
+)
+SELECT GROUPING(team_name) = 0 AS by_team,
+       team_name,
+       author_id,
+       COUNT(to_approval),
+       AVG(to_approval),
+       percentile_cont(0.5) WITHIN GROUP (ORDER BY to_approval),
+       percentile_cont(0.9) WITHIN GROUP (ORDER BY to_approval),
+       COUNT(to_merge),
+       AVG(to_merge),
+       percentile_cont(0.5) WITHIN GROUP (ORDER BY to_merge),
+       percentile_cont(0.9) WITHIN GROUP (ORDER BY to_merge)
+FROM prs
+GROUP BY GROUPING SETS ((team_name), (author_id))
+ORDER BY by_team DESC, team_name, author_id
+`
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return stats, fmt.Errorf("latency stats: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	for rows.Next() {
+		var byTeam bool
+		var teamName, authorID sql.NullString
+		var approval, merge LatencySummary
+		var approvalAvg, approvalMedian, approvalP90, mergeAvg, mergeMedian, mergeP90 sql.NullFloat64
+		if err := rows.Scan(&byTeam, &teamName, &authorID,
+			&approval.Samples, &approvalAvg, &approvalMedian, &approvalP90,
+			&merge.Samples, &mergeAvg, &mergeMedian, &mergeP90); err != nil {
+			return stats, fmt.Errorf("scan latency stats: %w", err)
+		}
+		approval.AvgSeconds = nullFloatPtr(approvalAvg)
+		approval.MedianSeconds = nullFloatPtr(approvalMedian)
+		approval.P90Seconds = nullFloatPtr(approvalP90)
+		merge.AvgSeconds = nullFloatPtr(mergeAvg)
+		merge.MedianSeconds = nullFloatPtr(mergeMedian)
+		merge.P90Seconds = nullFloatPtr(mergeP90)
+
+		switch {
+		case byTeam && teamName.Valid:
+			stats.ByTeam = append(stats.ByTeam, TeamLatency{
+				TeamName:            teamName.String,
+				TimeToFirstApproval: approval,
+				TimeToMerge:         merge,
+			})
+		case !byTeam:
+			stats.ByUser = append(stats.ByUser, UserLatency{
+				UserID:              authorID.String,
+				TimeToFirstApproval: approval,
+				TimeToMerge:         merge,
+			})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return stats, fmt.Errorf("latency stats rows: %w", err)
+	}
+
+	return stats, nil
+}
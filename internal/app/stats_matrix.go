@@ -0,0 +1,107 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// TeamReviewMatrix counts how often each reviewer was assigned to pull
+// requests of each author of a team. Counts[i][j] is the number of
+// assignments of Reviewers[j] to pull requests authored by Authors[i].
+type TeamReviewMatrix struct {
+	TeamName  string   `json:"team_name"`
+	Authors   []string `json:"authors"`
+	Reviewers []string `json:"reviewers"`
+	Counts    [][]int  `json:"counts"`
+}
+
+type reviewPair struct {
+	author   string
+	reviewer string
+	count    int
+}
+
+// GetReviewMatrix returns an author by reviewer assignment matrix for every
+// author team matching the filter.
+func (s *Service) GetReviewMatrix(ctx context.Context, f StatsFilter) ([]TeamReviewMatrix, error) {
+	args, err := s.statsArgs(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+
+	const query = `
+SELECT u.team_name, a.author_id, a.user_id, COUNT(*)
+FROM (` + statsAssignmentsQuery + `
+) a
+JOIN users u ON u.user_id = a.author_id
+WHERE u.team_name IS NOT NULL
+  AND ($2::timestamptz IS NULL OR a.assigned_at >= $2)
+  AND ($3::timestamptz IS NULL OR a.assigned_at < $3)
+GROUP BY u.team_name, a.author_id, a.user_id
+ORDER BY u.team_name, a.author_id, a.user_id
+`
+	rows, err := s.db.QueryContext(ctx, s.expandReviewers(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("review matrix: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var teams []string
+	pairs := make(map[string][]reviewPair)
+	for rows.Next() {
+		var team string
+		var p reviewPair
+		if err := rows.Scan(&team, &p.author, &p.reviewer, &p.count); err != nil {
+			return nil, fmt.Errorf("scan review matrix: %w", err)
+		}
+		if _, ok := pairs[team]; !ok {
+			teams = append(teams, team)
+		}
+		pairs[team] = append(pairs[team], p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("review matrix rows: %w", err)
+	}
+
+	matrices := make([]TeamReviewMatrix, 0, len(teams))
+	for _, team := range teams {
+		matrices = append(matrices, buildReviewMatrix(team, pairs[team]))
+	}
+	return matrices, nil
+}
+
+func buildReviewMatrix(team string, pairs []reviewPair) TeamReviewMatrix {
+	authorIdx := make(map[string]int)
+	reviewerIdx := make(map[string]int)
+	m := TeamReviewMatrix{TeamName: team, Authors: []string{}, Reviewers: []string{}}
+	for _, p := range pairs {
+		if _, ok := authorIdx[p.author]; !ok {
+			authorIdx[p.author] = 0
+			m.Authors = append(m.Authors, p.author)
+		}
+		if _, ok := reviewerIdx[p.reviewer]; !ok {
+			reviewerIdx[p.reviewer] = 0
+			m.Reviewers = append(m.Reviewers, p.reviewer)
+		}
+	}
+	sort.Strings(m.Authors)
+	sort.Strings(m.Reviewers)
+	for i, id := range m.Authors {
+		authorIdx[id] = i
+	}
+	for j, id := range m.Reviewers {
+		reviewerIdx[id] = j
+	}
+
+	m.Counts = make([][]int, len(m.Authors))
+	for i := range m.Counts {
+		m.Counts[i] = make([]int, len(m.Reviewers))
+	}
+	for _, p := range pairs {
+		m.Counts[authorIdx[p.author]][reviewerIdx[p.reviewer]] = p.count
+	}
+	return m
+}
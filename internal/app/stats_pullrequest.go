@@ -0,0 +1,63 @@
+package app
+
+import (
+	"context"
+	"fmt"
+)
+
+// PullRequestStats is a health check of a single pull request.
+type PullRequestStats struct {
+	PullRequestID string `json:"pull_request_id"`
+	AuthorID      string `json:"author_id"`
+	Status        string `json:"status"`
+	ReviewSummary
+	TimeToFirstReviewSeconds *float64 `json:"time_to_first_review_seconds,omitempty"`
+	// ReviewerChanges counts every change of the reviewer list after the
+	// pull request was created: replacements, removals and late additions.
+	ReviewerChanges int `json:"reviewer_changes"`
+	Reassignments   int `json:"reassignments"`
+	Declines        int `json:"declines"`
+	Removals        int `json:"removals"`
+}
+
+// GetPullRequestStats returns review statistics of a pull request.
+func (s *Service) GetPullRequestStats(ctx context.Context, prID string) (PullRequestStats, error) {
+	pr, err := s.getPullRequest(ctx, s.db, prID)
+	if err != nil {
+		return PullRequestStats{}, err
+	}
+	if pr.DeletedAt != nil {
+		return PullRequestStats{}, &Error{Code: ErrorCodeNotFound, Message: "pull request not found"}
+	}
+
+	summary, err := reviewSummary(ctx, s.db, pr)
+	if err != nil {
+		return PullRequestStats{}, err
+	}
+
+	stats := PullRequestStats{
+		PullRequestID: pr.ID,
+		AuthorID:      pr.AuthorID,
+		Status:        pr.Status,
+		ReviewSummary: summary,
+	}
+	if pr.FirstReviewAt != nil && pr.CreatedAt != nil {
+		d := pr.FirstReviewAt.Sub(*pr.CreatedAt).Seconds()
+		stats.TimeToFirstReviewSeconds = &d
+	}
+
+	const eventsQuery = `
+SELECT COUNT(*) FILTER (WHERE event_type <> 'ASSIGNED' OR reason <> 'PR_CREATED'),
+       COUNT(*) FILTER (WHERE event_type = 'REPLACED' AND reason <> 'DECLINED'),
+       COUNT(*) FILTER (WHERE event_type = 'REPLACED' AND reason = 'DECLINED'),
+       COUNT(*) FILTER (WHERE event_type = 'REMOVED')
+FROM assignment_events
+WHERE pull_request_id = $1
+`
+	err = s.db.QueryRowContext(ctx, eventsQuery, prID).Scan(&stats.ReviewerChanges, &stats.Reassignments, &stats.Declines, &stats.Removals)
+	if err != nil {
+		return PullRequestStats{}, fmt.Errorf("count assignment events: %w", err)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,123 @@
+package app
+
+import (
+	"context"
+	"fmt"
+)
+
+// slaWorstOffendersLimit caps the offenders listed per team.
+const slaWorstOffendersLimit = 5
+
+// SLAOffender is a pull request that waited for its first review longer than
+// the team SLA. Reviewed is false when it is still waiting or was merged
+// without a review; the wait of the latter ends at the merge.
+type SLAOffender struct {
+	PullRequestID string  `json:"pull_request_id"`
+	AuthorID      string  `json:"author_id"`
+	WaitSeconds   float64 `json:"wait_seconds"`
+	Reviewed      bool    `json:"reviewed"`
+}
+
+// TeamSLACompliance reports how many pull requests of a team got their first
+// approval within sla_hours. Pull requests still waiting but within the SLA
+// are pending and do not count either way, nor do those merged without a
+// review within the SLA.
+type TeamSLACompliance struct {
+	TeamName  string `json:"team_name"`
+	SLAHours  int    `json:"sla_hours"`
+	Evaluated int    `json:"evaluated"`
+	WithinSLA int    `json:"within_sla"`
+	Breached  int    `json:"breached"`
+	Pending   int    `json:"pending"`
+	// CompliancePercent is nil when no pull request could be evaluated yet.
+	CompliancePercent *float64      `json:"compliance_percent"`
+	WorstOffenders    []SLAOffender `json:"worst_offenders"`
+}
+
+// GetSLACompliance returns SLA compliance of pull requests created within the
+// filter window, grouped by the author's team. Teams without an SLA are skipped.
+func (s *Service) GetSLACompliance(ctx context.Context, f StatsFilter) ([]TeamSLACompliance, error) {
+	args, err := s.statsArgs(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	defaults, err := s.runtimeSettings(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, defaults.DefaultSLAHours)
+
+	const query = `
+SELECT u.team_name,
+       COALESCE(ts.sla_hours, $6) AS sla_hours,
+       p.pull_request_id,
+       p.author_id,
+       EXTRACT(EPOCH FROM COALESCE(fr.first_review_at, p.merged_at, NOW()) - p.created_at)::float8 AS wait_seconds,
+       fr.first_review_at IS NOT NULL AS reviewed,
+       p.merged_at IS NOT NULL AS merged
+FROM pull_requests p
+JOIN users u ON u.user_id = p.author_id
+LEFT JOIN team_settings ts ON ts.team_name = u.team_name
+CROSS JOIN LATERAL (
+  SELECT MIN(r.reviewed_at) AS first_review_at
+  FROM pull_request_reviews r
+  WHERE r.pull_request_id = p.pull_request_id
+    AND r.status = 'APPROVED'
+) fr
+WHERE COALESCE(ts.sla_hours, $6) > 0
+  AND p.deleted_at IS NULL
+  AND p.author_id IN (` + statsAuthorsQuery + `)
+  AND ($5 = '' OR p.status = $5)
+  AND ($2::timestamptz IS NULL OR p.created_at >= $2)
+  AND ($3::timestamptz IS NULL OR p.created_at < $3)
+ORDER BY u.team_name, wait_seconds DESC, p.pull_request_id
+`
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sla compliance: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	teams := make([]TeamSLACompliance, 0)
+	for rows.Next() {
+		var team string
+		var slaHours int
+		var o SLAOffender
+		var merged bool
+		if err := rows.Scan(&team, &slaHours, &o.PullRequestID, &o.AuthorID, &o.WaitSeconds, &o.Reviewed, &merged); err != nil {
+			return nil, fmt.Errorf("scan sla compliance: %w", err)
+		}
+		if len(teams) == 0 || teams[len(teams)-1].TeamName != team {
+			teams = append(teams, TeamSLACompliance{TeamName: team, SLAHours: slaHours, WorstOffenders: make([]SLAOffender, 0)})
+		}
+		t := &teams[len(teams)-1]
+
+		switch {
+		case o.WaitSeconds > float64(slaHours)*3600:
+			t.Breached++
+			// Rows come slowest first, so the first breaches are the worst.
+			if len(t.WorstOffenders) < slaWorstOffendersLimit {
+				t.WorstOffenders = append(t.WorstOffenders, o)
+			}
+		case o.Reviewed:
+			t.WithinSLA++
+		case !merged:
+			t.Pending++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sla compliance rows: %w", err)
+	}
+
+	for i := range teams {
+		t := &teams[i]
+		t.Evaluated = t.WithinSLA + t.Breached
+		if t.Evaluated > 0 {
+			pct := float64(t.WithinSLA) * 100 / float64(t.Evaluated)
+			t.CompliancePercent = &pct
+		}
+	}
+	return teams, nil
+}
@@ -0,0 +1,123 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultStaleDays is the idle period after which an open PR is reported as stale.
+	DefaultStaleDays = 7
+	// MaxStaleDays limits the stale report idle period.
+	MaxStaleDays = 365
+)
+
+// StaleFilter selects pull requests for the stale report. Days is the idle
+// period, DefaultStaleDays when 0.
+type StaleFilter struct {
+	OrgName  string
+	TeamName string
+	Days     int
+}
+
+// StalePullRequest is an open pull request without recent reviewer activity.
+type StalePullRequest struct {
+	PullRequestID   string    `json:"pull_request_id"`
+	PullRequestName string    `json:"pull_request_name"`
+	LastActivityAt  time.Time `json:"last_activity_at"`
+	IdleDays        int       `json:"idle_days"`
+}
+
+// StaleAuthor groups stale pull requests of one author, oldest activity first.
+type StaleAuthor struct {
+	AuthorID     string             `json:"author_id"`
+	Count        int                `json:"count"`
+	PullRequests []StalePullRequest `json:"pull_requests"`
+}
+
+// StaleTeam groups stale pull requests by author within the author's team.
+type StaleTeam struct {
+	TeamName string        `json:"team_name"`
+	Count    int           `json:"count"`
+	Authors  []StaleAuthor `json:"authors"`
+}
+
+// StaleReport lists open pull requests idle for more than Days days.
+type StaleReport struct {
+	Days  int         `json:"days"`
+	Total int         `json:"total"`
+	Teams []StaleTeam `json:"teams"`
+}
+
+// GetStalePullRequests returns open pull requests whose last reviewer
+// activity (an assignment change or an approval, or the creation when there
+// was none) is older than the idle period, grouped by the author's team.
+func (s *Service) GetStalePullRequests(ctx context.Context, f StaleFilter) (StaleReport, error) {
+	report := StaleReport{Days: f.Days, Teams: make([]StaleTeam, 0)}
+	if report.Days == 0 {
+		report.Days = DefaultStaleDays
+	}
+
+	args, err := s.statsArgs(ctx, StatsFilter{OrgName: f.OrgName, TeamName: f.TeamName, Status: "OPEN"})
+	if err != nil {
+		return report, err
+	}
+
+	const query = `
+WITH activity AS (
+  SELECT u.team_name,
+         p.author_id,
+         p.pull_request_id,
+         p.pull_request_name,
+         GREATEST(p.created_at,
+                  (SELECT MAX(e.created_at) FROM assignment_events e WHERE e.pull_request_id = p.pull_request_id),
+                  (SELECT MAX(r.reviewed_at) FROM pull_request_reviews r WHERE r.pull_request_id = p.pull_request_id)
+         ) AS last_activity_at
+  FROM pull_requests p
+  JOIN users u ON u.user_id = p.author_id
+  WHERE p.deleted_at IS NULL
+    AND u.team_name IS NOT NULL
+    AND p.author_id IN (` + statsAuthorsQuery + `)
+    AND p.status = $5
+    AND ($2::timestamptz IS NULL OR p.created_at >= $2)
+    AND ($3::timestamptz IS NULL OR p.created_at < $3)
+)
+SELECT team_name, author_id, pull_request_id, pull_request_name, last_activity_at,
+       EXTRACT(DAY FROM NOW() - last_activity_at)::int
+FROM activity
+WHERE last_activity_at < NOW() - make_interval(days => $6)
+ORDER BY team_name, author_id, last_activity_at, pull_request_id
+`
+	rows, err := s.db.QueryContext(ctx, query, append(args, report.Days)...)
+	if err != nil {
+		return report, fmt.Errorf("stale pull requests: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	for rows.Next() {
+		var team, author string
+		var pr StalePullRequest
+		if err := rows.Scan(&team, &author, &pr.PullRequestID, &pr.PullRequestName, &pr.LastActivityAt, &pr.IdleDays); err != nil {
+			return report, fmt.Errorf("scan stale pull request: %w", err)
+		}
+		if len(report.Teams) == 0 || report.Teams[len(report.Teams)-1].TeamName != team {
+			report.Teams = append(report.Teams, StaleTeam{TeamName: team})
+		}
+		t := &report.Teams[len(report.Teams)-1]
+		if len(t.Authors) == 0 || t.Authors[len(t.Authors)-1].AuthorID != author {
+			t.Authors = append(t.Authors, StaleAuthor{AuthorID: author})
+		}
+		a := &t.Authors[len(t.Authors)-1]
+		a.PullRequests = append(a.PullRequests, pr)
+		a.Count++
+		t.Count++
+		report.Total++
+	}
+	if err := rows.Err(); err != nil {
+		return report, fmt.Errorf("stale pull requests rows: %w", err)
+	}
+	return report, nil
+}
@@ -0,0 +1,102 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// StrategyStats describes the outcome of assignments made by one strategy.
+// Load is counted over reviewers who got at least one assignment from the
+// strategy; TimeToReview runs from the assignment to the reviewer's approval.
+type StrategyStats struct {
+	Strategy     string         `json:"strategy"`
+	Assignments  int            `json:"assignments"`
+	PullRequests int            `json:"pull_requests"`
+	Reviewers    int            `json:"reviewers"`
+	MeanLoad     float64        `json:"mean_load"`
+	LoadStdDev   float64        `json:"load_stddev"`
+	TimeToReview LatencySummary `json:"time_to_review"`
+}
+
+// GetStrategyStats groups assignments by the strategy that produced them.
+// Initial assignments carry the team strategy, replacements and security
+// reviews are RANDOM; events recorded before strategies were tracked are
+// skipped. The window applies to the assignment time.
+func (s *Service) GetStrategyStats(ctx context.Context, f StatsFilter) ([]StrategyStats, error) {
+	args, err := s.statsArgs(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+
+	const query = `
+WITH assigned AS (
+  SELECT e.strategy,
+         e.pull_request_id,
+         COALESCE(e.replacement_user_id, e.user_id) AS user_id,
+         EXTRACT(EPOCH FROM r.reviewed_at - e.created_at)::float8 AS to_review
+  FROM assignment_events e
+  JOIN pull_requests p ON p.pull_request_id = e.pull_request_id
+  LEFT JOIN pull_request_reviews r ON r.pull_request_id = e.pull_request_id
+   AND r.user_id = COALESCE(e.replacement_user_id, e.user_id)
+   AND r.status = 'APPROVED'
+   AND r.reviewed_at >= e.created_at
+  WHERE e.strategy IS NOT NULL
+    AND e.event_type IN ('ASSIGNED', 'REPLACED')
+    AND p.deleted_at IS NULL
+    AND p.author_id IN (` + statsAuthorsQuery + `)
+    AND ($5 = '' OR p.status = $5)
+    AND ($2::timestamptz IS NULL OR e.created_at >= $2)
+    AND ($3::timestamptz IS NULL OR e.created_at < $3)
+),
+loads AS (
+  SELECT strategy, COUNT(*) AS load
+  FROM assigned
+  GROUP BY strategy, user_id
+),
+load_stats AS (
+  SELECT strategy, COUNT(*) AS reviewers, AVG(load)::float8 AS mean_load, STDDEV_POP(load)::float8 AS load_stddev
+  FROM loads
+  GROUP BY strategy
+)
+SELECT a.strategy,
+       COUNT(*),
+       COUNT(DISTINCT a.pull_request_id),
+       l.reviewers,
+       l.mean_load,
+       l.load_stddev,
+       COUNT(a.to_review),
+       AVG(a.to_review),
+       percentile_cont(0.5) WITHIN GROUP (ORDER BY a.to_review),
+       percentile_cont(0.9) WITHIN GROUP (ORDER BY a.to_review)
+FROM assigned a
+JOIN load_stats l ON l.strategy = a.strategy
+GROUP BY a.strategy, l.reviewers, l.mean_load, l.load_stddev
+ORDER BY a.strategy
+`
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("strategy stats: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	result := make([]StrategyStats, 0)
+	for rows.Next() {
+		var st StrategyStats
+		var avg, median, p90 sql.NullFloat64
+		if err := rows.Scan(&st.Strategy, &st.Assignments, &st.PullRequests, &st.Reviewers, &st.MeanLoad, &st.LoadStdDev,
+			&st.TimeToReview.Samples, &avg, &median, &p90); err != nil {
+			return nil, fmt.Errorf("scan strategy stats: %w", err)
+		}
+		st.TimeToReview.AvgSeconds = nullFloatPtr(avg)
+		st.TimeToReview.MedianSeconds = nullFloatPtr(median)
+		st.TimeToReview.P90Seconds = nullFloatPtr(p90)
+		result = append(result, st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("strategy stats rows: %w", err)
+	}
+	return result, nil
+}
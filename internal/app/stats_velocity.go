@@ -0,0 +1,84 @@
+package app
+
+import (
+	"context"
+	"fmt"
+)
+
+// WeekVelocity counts pull request activity of a team within one week.
+// Closed pull requests are the ones deleted without being merged.
+type WeekVelocity struct {
+	WeekStart string `json:"week_start"`
+	Created   int    `json:"created"`
+	Merged    int    `json:"merged"`
+	Closed    int    `json:"closed"`
+}
+
+// TeamVelocity is the weekly throughput of a team. Weeks without activity are omitted.
+type TeamVelocity struct {
+	TeamName string         `json:"team_name"`
+	Weeks    []WeekVelocity `json:"weeks"`
+}
+
+// GetTeamVelocity returns weekly counts of created, merged and closed pull
+// requests grouped by the author's team. Weeks start on Monday in UTC, and
+// every event is matched against the filter window by its own timestamp.
+func (s *Service) GetTeamVelocity(ctx context.Context, f StatsFilter) ([]TeamVelocity, error) {
+	args, err := s.statsArgs(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+
+	const query = `
+WITH prs AS (
+  SELECT u.team_name, p.status, p.created_at, p.merged_at,
+         CASE WHEN p.status <> 'MERGED' THEN p.deleted_at END AS closed_at
+  FROM pull_requests p
+  JOIN users u ON u.user_id = p.author_id
+  WHERE u.team_name IS NOT NULL
+    AND p.author_id IN (` + statsAuthorsQuery + `)
+    AND ($5 = '' OR p.status = $5)
+),
+events AS (
+  SELECT team_name, created_at AS at, 'created' AS kind FROM prs
+  UNION ALL
+  SELECT team_name, merged_at, 'merged' FROM prs WHERE merged_at IS NOT NULL
+  UNION ALL
+  SELECT team_name, closed_at, 'closed' FROM prs WHERE closed_at IS NOT NULL
+)
+SELECT team_name,
+       to_char(date_trunc('week', at AT TIME ZONE 'UTC'), 'YYYY-MM-DD') AS week_start,
+       COUNT(*) FILTER (WHERE kind = 'created'),
+       COUNT(*) FILTER (WHERE kind = 'merged'),
+       COUNT(*) FILTER (WHERE kind = 'closed')
+FROM events
+WHERE ($2::timestamptz IS NULL OR at >= $2)
+  AND ($3::timestamptz IS NULL OR at < $3)
+GROUP BY team_name, week_start
+ORDER BY team_name, week_start
+`
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("team velocity: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	teams := make([]TeamVelocity, 0)
+	for rows.Next() {
+		var team string
+		var w WeekVelocity
+		if err := rows.Scan(&team, &w.WeekStart, &w.Created, &w.Merged, &w.Closed); err != nil {
+			return nil, fmt.Errorf("scan team velocity: %w", err)
+		}
+		if len(teams) == 0 || teams[len(teams)-1].TeamName != team {
+			teams = append(teams, TeamVelocity{TeamName: team})
+		}
+		teams[len(teams)-1].Weeks = append(teams[len(teams)-1].Weeks, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("team velocity rows: %w", err)
+	}
+	return teams, nil
+}
@@ -0,0 +1,250 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+
+	"github.com/lib/pq"
+)
+
+// ReviewerStorage selects how assigned reviewers are persisted while the
+// assigned_reviewers column is migrated to the pull_request_reviewers table.
+type ReviewerStorage string
+
+// Supported reviewer storage modes. Rollout goes array -> dual -> table and
+// can be rolled back step by step because both representations stay written
+// in dual and table modes.
+const (
+	ReviewerStorageArray ReviewerStorage = "array"
+	ReviewerStorageDual  ReviewerStorage = "dual"
+	ReviewerStorageTable ReviewerStorage = "table"
+)
+
+// ParseReviewerStorage validates a reviewer storage mode name.
+func ParseReviewerStorage(s string) (ReviewerStorage, error) {
+	switch m := ReviewerStorage(s); m {
+	case ReviewerStorageArray, ReviewerStorageDual, ReviewerStorageTable:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown reviewer storage mode %q", s)
+	}
+}
+
+func (m ReviewerStorage) writesTable() bool {
+	return m == ReviewerStorageDual || m == ReviewerStorageTable
+}
+
+// reviewersRef matches the {reviewers:<table>} placeholder that read queries
+// use for the open reviewers of a pull_requests row, where <table> is the
+// name or alias the row is selected under.
+var reviewersRef = regexp.MustCompile(`\{reviewers:(\w+)\}`)
+
+// expandReviewers replaces reviewer placeholders in a read query: table mode
+// reads the join table, the other modes read the assigned_reviewers column.
+func (s *Service) expandReviewers(query string) string {
+	if s.reviewerStorage != ReviewerStorageTable {
+		return reviewersRef.ReplaceAllString(query, "$1.assigned_reviewers")
+	}
+	return reviewersRef.ReplaceAllString(query, `ARRAY(
+        SELECT prr.user_id
+        FROM pull_request_reviewers prr
+        WHERE prr.pull_request_id = $1.pull_request_id
+        ORDER BY prr.position
+    )`)
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// syncReviewers mirrors the reviewer list of a single pull request into the join table.
+func (s *Service) syncReviewers(ctx context.Context, q querier, prID string, reviewers []string) error {
+	if !s.reviewerStorage.writesTable() {
+		return nil
+	}
+	return writeReviewerRows(ctx, q, prID, reviewers)
+}
+
+func writeReviewerRows(ctx context.Context, q querier, prID string, reviewers []string) error {
+	const deleteQuery = `
+DELETE FROM pull_request_reviewers
+WHERE pull_request_id = $1
+  AND NOT (user_id = ANY($2))
+`
+	if _, err := q.ExecContext(ctx, deleteQuery, prID, pq.Array(reviewers)); err != nil {
+		return fmt.Errorf("delete stale reviewers: %w", err)
+	}
+
+	const upsertQuery = `
+INSERT INTO pull_request_reviewers(pull_request_id, user_id, position)
+SELECT $1, r.user_id, r.position
+FROM unnest($2::text[]) WITH ORDINALITY AS r(user_id, position)
+ON CONFLICT (pull_request_id, user_id) DO UPDATE
+SET position = EXCLUDED.position
+`
+	if _, err := q.ExecContext(ctx, upsertQuery, prID, pq.Array(reviewers)); err != nil {
+		return fmt.Errorf("upsert reviewers: %w", err)
+	}
+	return nil
+}
+
+// removeOpenAssignments unassigns the given users from every pull request that is not merged
+// and records the removals with the given reason.
+func (s *Service) removeOpenAssignments(ctx context.Context, q querier, userIDs []string, reason string) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	if err := recordOpenRemovals(ctx, q, userIDs, reason); err != nil {
+		return err
+	}
+
+	if err := removeSecurityReviewers(ctx, q, userIDs, reason); err != nil {
+		return err
+	}
+
+	if err := s.enqueueCodeHostRemovals(ctx, q, userIDs); err != nil {
+		return err
+	}
+
+	const updatePRsQuery = `
+UPDATE pull_requests
+SET assigned_reviewers = array(
+    SELECT reviewer
+    FROM unnest(assigned_reviewers) AS reviewer
+    WHERE NOT (reviewer = ANY($1))
+)
+WHERE status <> 'MERGED'
+  AND deleted_at IS NULL
+  AND assigned_reviewers && $1
+`
+	if _, err := q.ExecContext(ctx, updatePRsQuery, pq.Array(userIDs)); err != nil {
+		return fmt.Errorf("remove reviewers from pull requests: %w", err)
+	}
+
+	if !s.reviewerStorage.writesTable() {
+		return nil
+	}
+
+	const deleteQuery = `
+DELETE FROM pull_request_reviewers r
+USING pull_requests p
+WHERE r.pull_request_id = p.pull_request_id
+  AND p.status <> 'MERGED'
+  AND p.deleted_at IS NULL
+  AND r.user_id = ANY($1)
+`
+	if _, err := q.ExecContext(ctx, deleteQuery, pq.Array(userIDs)); err != nil {
+		return fmt.Errorf("remove reviewers from join table: %w", err)
+	}
+	return nil
+}
+
+// ReviewerStorageMismatch describes a pull request whose reviewer representations differ.
+type ReviewerStorageMismatch struct {
+	PullRequestID  string   `json:"pull_request_id"`
+	ArrayReviewers []string `json:"array_reviewers"`
+	TableReviewers []string `json:"table_reviewers"`
+}
+
+// ReviewerStorageReport is the result of comparing both reviewer representations.
+type ReviewerStorageReport struct {
+	Mode       ReviewerStorage           `json:"mode"`
+	Checked    int                       `json:"checked"`
+	Mismatches []ReviewerStorageMismatch `json:"mismatches"`
+	Repaired   int                       `json:"repaired"`
+}
+
+// VerifyReviewerStorage compares assigned_reviewers with the join table.
+// When repair is true, mismatching rows are rewritten from the array column,
+// which stays the source of truth until the migration is finished.
+func (s *Service) VerifyReviewerStorage(ctx context.Context, repair bool) (ReviewerStorageReport, error) {
+	report := ReviewerStorageReport{
+		Mode:       s.reviewerStorage,
+		Mismatches: make([]ReviewerStorageMismatch, 0),
+	}
+
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM pull_requests`).Scan(&report.Checked); err != nil {
+		return report, fmt.Errorf("count pull requests: %w", err)
+	}
+
+	const mismatchQuery = `
+SELECT pull_request_id, assigned_reviewers, table_reviewers
+FROM (
+  SELECT p.pull_request_id,
+         p.assigned_reviewers,
+         ARRAY(
+           SELECT r.user_id
+           FROM pull_request_reviewers r
+           WHERE r.pull_request_id = p.pull_request_id
+           ORDER BY r.position
+         ) AS table_reviewers
+  FROM pull_requests p
+) t
+WHERE assigned_reviewers <> table_reviewers
+ORDER BY pull_request_id
+`
+	rows, err := s.db.QueryContext(ctx, mismatchQuery)
+	if err != nil {
+		return report, fmt.Errorf("compare reviewers: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	for rows.Next() {
+		var m ReviewerStorageMismatch
+		if err := rows.Scan(&m.PullRequestID, pq.Array(&m.ArrayReviewers), pq.Array(&m.TableReviewers)); err != nil {
+			return report, fmt.Errorf("scan reviewer mismatch: %w", err)
+		}
+		report.Mismatches = append(report.Mismatches, m)
+	}
+	if err := rows.Err(); err != nil {
+		return report, fmt.Errorf("reviewer mismatch rows: %w", err)
+	}
+
+	if !repair {
+		return report, nil
+	}
+
+	for _, m := range report.Mismatches {
+		if err := s.repairReviewers(ctx, m.PullRequestID); err != nil {
+			return report, err
+		}
+		report.Repaired++
+	}
+
+	return report, nil
+}
+
+func (s *Service) repairReviewers(ctx context.Context, prID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var reviewers []string
+	const selectQuery = `SELECT assigned_reviewers FROM pull_requests WHERE pull_request_id = $1 FOR UPDATE`
+	if err := tx.QueryRowContext(ctx, selectQuery, prID).Scan(pq.Array(&reviewers)); err != nil {
+		return fmt.Errorf("get reviewers of %s: %w", prID, err)
+	}
+
+	// Repair runs regardless of the current mode so the table can be
+	// backfilled before switching to dual writes.
+	if err := writeReviewerRows(ctx, tx, prID, reviewers); err != nil {
+		return fmt.Errorf("repair reviewers of %s: %w", prID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// MemberCapacity is the review load of a single team member.
+type MemberCapacity struct {
+	UserID      string `json:"user_id"`
+	Username    string `json:"username"`
+	IsActive    bool   `json:"is_active"`
+	OpenReviews int    `json:"open_reviews"`
+	// RemainingCapacity is set for active members when the team has max_open_reviews.
+	RemainingCapacity *int `json:"remaining_capacity,omitempty"`
+}
+
+// TeamCapacity reports how loaded a team is. The capacity fields are only
+// set when the team has max_open_reviews configured.
+type TeamCapacity struct {
+	TeamName        string `json:"team_name"`
+	ActiveMembers   int    `json:"active_members"`
+	OpenAssignments int    `json:"open_assignments"`
+	MaxOpenReviews  int    `json:"max_open_reviews"`
+	// RemainingCapacity sums the remaining capacity of active members.
+	RemainingCapacity *int `json:"remaining_capacity,omitempty"`
+	// AbsorbablePullRequests estimates how many new pull requests the team
+	// can take with reviewer_count reviewers each.
+	AbsorbablePullRequests *int             `json:"absorbable_pull_requests,omitempty"`
+	Members                []MemberCapacity `json:"members"`
+}
+
+// GetTeamCapacity returns the open review load of every team member.
+// Pending security reviews count as open reviews.
+func (s *Service) GetTeamCapacity(ctx context.Context, teamName string) (TeamCapacity, error) {
+	if err := s.checkTeamExists(ctx, teamName); err != nil {
+		return TeamCapacity{}, err
+	}
+
+	settings, err := s.teamSettings(ctx, s.db, teamName)
+	if err != nil {
+		return TeamCapacity{}, err
+	}
+
+	const query = `
+SELECT u.user_id, u.username, u.is_active,
+       (SELECT COUNT(*)
+        FROM pull_requests p
+        WHERE p.status = 'OPEN'
+          AND p.deleted_at IS NULL
+          AND (u.user_id = ANY({reviewers:p})
+               OR (p.security_reviewer_id = u.user_id AND p.security_approved_at IS NULL)))
+FROM users u
+WHERE u.team_name = $1
+ORDER BY u.user_id
+`
+	rows, err := s.db.QueryContext(ctx, s.expandReviewers(query), teamName)
+	if err != nil {
+		return TeamCapacity{}, fmt.Errorf("get team capacity: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	capacity := TeamCapacity{
+		TeamName:       teamName,
+		MaxOpenReviews: settings.MaxOpenReviews,
+		Members:        make([]MemberCapacity, 0),
+	}
+	remaining := 0
+	for rows.Next() {
+		var m MemberCapacity
+		if err := rows.Scan(&m.UserID, &m.Username, &m.IsActive, &m.OpenReviews); err != nil {
+			return TeamCapacity{}, fmt.Errorf("scan member capacity: %w", err)
+		}
+		capacity.OpenAssignments += m.OpenReviews
+		if m.IsActive {
+			capacity.ActiveMembers++
+			if settings.MaxOpenReviews > 0 {
+				left := max(settings.MaxOpenReviews-m.OpenReviews, 0)
+				m.RemainingCapacity = &left
+				remaining += left
+			}
+		}
+		capacity.Members = append(capacity.Members, m)
+	}
+	if err := rows.Err(); err != nil {
+		return TeamCapacity{}, fmt.Errorf("member capacity rows: %w", err)
+	}
+
+	if settings.MaxOpenReviews > 0 {
+		capacity.RemainingCapacity = &remaining
+		// Every pull request needs reviewer_count distinct members with spare capacity.
+		absorbable := 0
+		if settings.ReviewerCount > 0 {
+			absorbable = absorbablePullRequests(capacity.Members, settings.ReviewerCount)
+		}
+		capacity.AbsorbablePullRequests = &absorbable
+	}
+
+	return capacity, nil
+}
+
+// TeamMemberLoad is a team member with its current open review count.
+type TeamMemberLoad struct {
+	TeamMember
+	OpenReviews int `json:"open_reviews"`
+}
+
+// TeamWithLoad is a team whose members carry their review load.
+type TeamWithLoad struct {
+	Name    string           `json:"team_name"`
+	OrgName string           `json:"org_name,omitempty"`
+	Members []TeamMemberLoad `json:"members"`
+}
+
+// GetTeamWithLoad returns a team like GetTeam with each member's open
+// reviews counted as in GetTeamCapacity.
+func (s *Service) GetTeamWithLoad(ctx context.Context, teamName string) (TeamWithLoad, error) {
+	team, err := s.GetTeam(ctx, teamName)
+	if err != nil {
+		return TeamWithLoad{}, err
+	}
+	capacity, err := s.GetTeamCapacity(ctx, teamName)
+	if err != nil {
+		return TeamWithLoad{}, err
+	}
+
+	load := make(map[string]int, len(capacity.Members))
+	for _, m := range capacity.Members {
+		load[m.UserID] = m.OpenReviews
+	}
+	result := TeamWithLoad{
+		Name:    team.Name,
+		OrgName: team.OrgName,
+		Members: make([]TeamMemberLoad, 0, len(team.Members)),
+	}
+	for _, m := range team.Members {
+		result.Members = append(result.Members, TeamMemberLoad{TeamMember: m, OpenReviews: load[m.ID]})
+	}
+	return result, nil
+}
+
+// absorbablePullRequests counts how many pull requests can be assigned
+// greedily, each to perPR distinct members with remaining capacity.
+func absorbablePullRequests(members []MemberCapacity, perPR int) int {
+	var left []int
+	for _, m := range members {
+		if m.RemainingCapacity != nil && *m.RemainingCapacity > 0 {
+			left = append(left, *m.RemainingCapacity)
+		}
+	}
+
+	count := 0
+	for {
+		sort.Sort(sort.Reverse(sort.IntSlice(left)))
+		if len(left) < perPR || left[perPR-1] == 0 {
+			return count
+		}
+		for i := 0; i < perPR; i++ {
+			left[i]--
+		}
+		count++
+	}
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// TeamDeletion is the result of DeleteTeam.
+type TeamDeletion struct {
+	TeamName   string       `json:"team_name"`
+	Members    []TeamMember `json:"members"`
+	ArchivedAt time.Time    `json:"archived_at"`
+	// OpenPullRequests counts open pull requests authored by members that
+	// were left open because the deletion was forced.
+	OpenPullRequests int `json:"open_pull_requests"`
+}
+
+// DeleteTeam deactivates all members of a team, removes them from open
+// pull requests and archives the team. Archived teams are hidden but their
+// name stays reserved, since users and pull requests keep referencing it.
+// Unless force is set, a team whose members still author open pull
+// requests is not deleted.
+func (s *Service) DeleteTeam(ctx context.Context, teamName string, force bool) (TeamDeletion, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return TeamDeletion{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if err := lockTeam(ctx, tx, teamName); err != nil {
+		return TeamDeletion{}, err
+	}
+
+	const countOpenQuery = `
+SELECT COUNT(*)
+FROM pull_requests p
+JOIN users u ON u.user_id = p.author_id
+WHERE u.team_name = $1
+  AND p.status = 'OPEN'
+  AND p.deleted_at IS NULL
+`
+	result := TeamDeletion{TeamName: teamName}
+	if err := tx.QueryRowContext(ctx, countOpenQuery, teamName).Scan(&result.OpenPullRequests); err != nil {
+		return TeamDeletion{}, fmt.Errorf("count open pull requests: %w", err)
+	}
+	if result.OpenPullRequests > 0 && !force {
+		return TeamDeletion{}, &Error{
+			Code:    ErrorCodeTeamHasOpenPRs,
+			Message: fmt.Sprintf("team members author %d open pull requests", result.OpenPullRequests),
+		}
+	}
+
+	result.Members, err = s.deactivateMembers(ctx, tx, teamName, nil, AssignmentReasonTeamDeleted)
+	if err != nil {
+		return TeamDeletion{}, err
+	}
+	if result.Members == nil {
+		result.Members = []TeamMember{}
+	}
+
+	const archiveQuery = `UPDATE teams SET archived_at = NOW() WHERE team_name = $1 RETURNING archived_at`
+	if err := tx.QueryRowContext(ctx, archiveQuery, teamName).Scan(&result.ArchivedAt); err != nil {
+		return TeamDeletion{}, fmt.Errorf("archive team: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return TeamDeletion{}, fmt.Errorf("commit tx: %w", err)
+	}
+
+	s.publishQueueChange()
+	return result, nil
+}
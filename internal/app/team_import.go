@@ -0,0 +1,52 @@
+package app
+
+import (
+	"context"
+	"errors"
+)
+
+// Team import statuses.
+const (
+	TeamImportCreated = "CREATED"
+	TeamImportFailed  = "FAILED"
+)
+
+// TeamImportResult reports the outcome of importing a single team.
+type TeamImportResult struct {
+	TeamName string `json:"team_name"`
+	Status   string `json:"status"`
+	Members  int    `json:"members"`
+	// Code, Message and Fields describe why the team was not imported.
+	Code    ErrorCode    `json:"code,omitempty"`
+	Message string       `json:"message,omitempty"`
+	Fields  []FieldError `json:"fields,omitempty"`
+}
+
+// ImportTeams creates several teams with their members. Every team is
+// created in its own transaction, so a rejected team (for example, one that
+// already exists) does not prevent the others from being imported. Domain
+// errors are reported per team; any other error stops the import and is
+// returned, leaving already imported teams in place. transfer is passed to
+// CreateTeam for every team.
+func (s *Service) ImportTeams(ctx context.Context, teams []Team, transfer bool) ([]TeamImportResult, error) {
+	results := make([]TeamImportResult, 0, len(teams))
+	for _, team := range teams {
+		res := TeamImportResult{TeamName: team.Name, Members: len(team.Members)}
+		if _, err := s.CreateTeam(ctx, team, transfer); err != nil {
+			var appErr *Error
+			if !errors.As(err, &appErr) {
+				return nil, err
+			}
+			res.Status = TeamImportFailed
+			res.Code = appErr.Code
+			res.Message = appErr.Message
+			res.Fields = appErr.Fields
+			results = append(results, res)
+			continue
+		}
+
+		res.Status = TeamImportCreated
+		results = append(results, res)
+	}
+	return results, nil
+}
@@ -0,0 +1,62 @@
+package app
+
+import (
+	"context"
+	"fmt"
+)
+
+// TeamSummary is a team with member counts.
+type TeamSummary struct {
+	Name          string `json:"team_name"`
+	Members       int    `json:"members"`
+	ActiveMembers int    `json:"active_members"`
+}
+
+// ListTeams returns active teams ordered by name, the total number of teams
+// and the cursor key of the next page, empty on the last page.
+func (s *Service) ListTeams(ctx context.Context, page Page) ([]TeamSummary, int, string, error) {
+	const query = `
+SELECT t.team_name,
+       COUNT(u.user_id),
+       COUNT(u.user_id) FILTER (WHERE u.is_active),
+       (SELECT COUNT(*) FROM teams WHERE archived_at IS NULL)
+FROM teams t
+LEFT JOIN users u ON u.team_name = t.team_name
+WHERE t.archived_at IS NULL
+  AND ($3 = '' OR t.team_name > $3)
+GROUP BY t.team_name
+ORDER BY t.team_name
+LIMIT $1 OFFSET $2
+`
+	rows, err := s.db.QueryContext(ctx, query, page.fetchLimit(), page.Offset, page.After)
+	if err != nil {
+		return nil, 0, "", fmt.Errorf("list teams: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	teams := make([]TeamSummary, 0)
+	total := 0
+	for rows.Next() {
+		var t TeamSummary
+		if err := rows.Scan(&t.Name, &t.Members, &t.ActiveMembers, &total); err != nil {
+			return nil, 0, "", fmt.Errorf("scan team: %w", err)
+		}
+		teams = append(teams, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, "", fmt.Errorf("teams rows: %w", err)
+	}
+
+	// The count is unavailable when the page is past the end.
+	if len(teams) == 0 && (page.Offset > 0 || page.After != "") {
+		const countQuery = `SELECT COUNT(*) FROM teams WHERE archived_at IS NULL`
+		if err := s.db.QueryRowContext(ctx, countQuery).Scan(&total); err != nil {
+			return nil, 0, "", fmt.Errorf("count teams: %w", err)
+		}
+	}
+
+	teams, next := cutPage(teams, page, func(t TeamSummary) string { return t.Name })
+	return teams, total, next, nil
+}
@@ -0,0 +1,64 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// RenameTeam renames a team. Users and team settings follow through
+// ON UPDATE CASCADE foreign keys, so the rename is a single atomic update.
+func (s *Service) RenameTeam(ctx context.Context, oldName, newName string) (Team, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Team{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if err := lockTeam(ctx, tx, oldName); err != nil {
+		return Team{}, err
+	}
+
+	const selectTeamQuery = `SELECT team_name FROM teams WHERE team_name = $1`
+	var existing string
+	err = tx.QueryRowContext(ctx, selectTeamQuery, newName).Scan(&existing)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return Team{}, fmt.Errorf("check team: %w", err)
+	}
+	if err == nil {
+		return Team{}, &Error{Code: ErrorCodeTeamExists, Message: "team_name already exists"}
+	}
+
+	const renameQuery = `UPDATE teams SET team_name = $2 WHERE team_name = $1`
+	if _, err := tx.ExecContext(ctx, renameQuery, oldName, newName); err != nil {
+		if isUniqueViolation(err) {
+			return Team{}, &Error{Code: ErrorCodeTeamExists, Message: "team_name already exists"}
+		}
+		return Team{}, fmt.Errorf("rename team: %w", err)
+	}
+
+	// Membership history has no foreign key to teams, so it is moved by hand.
+	const renameEventsQuery = `
+UPDATE membership_events
+SET team_name = CASE WHEN team_name = $1 THEN $2 ELSE team_name END,
+    related_team = CASE WHEN related_team = $1 THEN $2 ELSE related_team END
+WHERE team_name = $1 OR related_team = $1
+`
+	if _, err := tx.ExecContext(ctx, renameEventsQuery, oldName, newName); err != nil {
+		return Team{}, fmt.Errorf("rename membership events: %w", err)
+	}
+
+	const renameStatsHistoryQuery = `UPDATE stats_history SET team_name = $2 WHERE team_name = $1`
+	if _, err := tx.ExecContext(ctx, renameStatsHistoryQuery, oldName, newName); err != nil {
+		return Team{}, fmt.Errorf("rename stats history: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Team{}, fmt.Errorf("commit tx: %w", err)
+	}
+
+	return s.GetTeam(ctx, newName)
+}
@@ -0,0 +1,160 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// MaxReviewerCount limits reviewer_count and required_approvals in team settings.
+const MaxReviewerCount = 10
+
+// TeamSettings holds per-team assignment settings. Teams without a
+// team_settings row use the defaults.
+type TeamSettings struct {
+	TeamName string `json:"team_name"`
+	// ReviewerCount is the number of reviewers assigned to a new pull request,
+	// RequiredReviewers by default.
+	ReviewerCount int `json:"reviewer_count"`
+	// Strategy picks initial reviewers: TEAM_ORDER (by user_id) or RANDOM.
+	Strategy string `json:"strategy"`
+	// SLAHours sets the deadline of pull requests created without one; 0 disables it.
+	SLAHours int `json:"sla_hours"`
+	// RequiredApprovals blocks merge until that many assigned reviewers
+	// approved, capped by the number of assigned reviewers; 0 disables it.
+	RequiredApprovals int `json:"required_approvals"`
+	// MaxOpenReviews is the number of open reviews a member can handle, used
+	// by the capacity report; 0 means it is not set.
+	MaxOpenReviews int `json:"max_open_reviews"`
+	// StrictMode makes pull request creation fail with NOT_ENOUGH_REVIEWERS
+	// instead of assigning fewer than ReviewerCount reviewers.
+	StrictMode bool `json:"strict_mode"`
+	// ExcludeLeads keeps team leads out of automatic reviewer assignment.
+	ExcludeLeads bool `json:"exclude_leads"`
+	// RequireSenior makes assignment pick at least one senior when the team has one available.
+	RequireSenior bool `json:"require_senior"`
+	// OrgFallback fills missing reviewers from other teams of the same organization.
+	OrgFallback bool `json:"org_fallback"`
+	// JiraCommentOnMerge comments on the Jira issues of a merged pull request.
+	JiraCommentOnMerge bool `json:"jira_comment_on_merge"`
+	// JiraMergeTransition is the ID of the Jira transition applied to the
+	// issues of a merged pull request; empty disables it.
+	JiraMergeTransition string `json:"jira_merge_transition"`
+}
+
+// TeamSettingsUpdate holds settings to change. Nil fields are left unchanged.
+type TeamSettingsUpdate struct {
+	TeamName          string
+	ReviewerCount     *int
+	Strategy          *string
+	SLAHours          *int
+	RequiredApprovals *int
+	MaxOpenReviews    *int
+	StrictMode        *bool
+	ExcludeLeads      *bool
+	RequireSenior     *bool
+	OrgFallback       *bool
+
+	JiraCommentOnMerge  *bool
+	JiraMergeTransition *string
+}
+
+// IsValidTeamStrategy reports whether p can be used as a team assignment strategy.
+func IsValidTeamStrategy(p string) bool {
+	return p == AssignmentStrategyTeamOrder || p == AssignmentStrategyRandom
+}
+
+// GetTeamSettings returns the settings of a team.
+func (s *Service) GetTeamSettings(ctx context.Context, teamName string) (TeamSettings, error) {
+	if err := s.checkTeamExists(ctx, teamName); err != nil {
+		return TeamSettings{}, err
+	}
+	return s.teamSettings(ctx, s.db, teamName)
+}
+
+// UpdateTeamSettings changes the settings of a team and returns the result.
+func (s *Service) UpdateTeamSettings(ctx context.Context, upd TeamSettingsUpdate) (TeamSettings, error) {
+	if err := s.checkTeamExists(ctx, upd.TeamName); err != nil {
+		return TeamSettings{}, err
+	}
+	defaults, err := s.runtimeSettings(ctx, s.db)
+	if err != nil {
+		return TeamSettings{}, err
+	}
+
+	// NULL strategy and sla_hours follow the runtime defaults.
+	const query = `
+INSERT INTO team_settings(team_name, reviewer_count, strategy, sla_hours, required_approvals, max_open_reviews,
+                          strict_mode, exclude_leads, require_senior, org_fallback,
+                          jira_comment_on_merge, jira_merge_transition)
+VALUES ($1, COALESCE($2, 2), $3, $4, COALESCE($5, 0), COALESCE($6, 0),
+        COALESCE($7, FALSE), COALESCE($8, FALSE), COALESCE($9, FALSE), COALESCE($10, FALSE),
+        COALESCE($11, FALSE), COALESCE($12, ''))
+ON CONFLICT (team_name) DO UPDATE
+SET reviewer_count = COALESCE($2, team_settings.reviewer_count),
+    strategy = COALESCE($3, team_settings.strategy),
+    sla_hours = COALESCE($4, team_settings.sla_hours),
+    required_approvals = COALESCE($5, team_settings.required_approvals),
+    max_open_reviews = COALESCE($6, team_settings.max_open_reviews),
+    strict_mode = COALESCE($7, team_settings.strict_mode),
+    exclude_leads = COALESCE($8, team_settings.exclude_leads),
+    require_senior = COALESCE($9, team_settings.require_senior),
+    org_fallback = COALESCE($10, team_settings.org_fallback),
+    jira_comment_on_merge = COALESCE($11, team_settings.jira_comment_on_merge),
+    jira_merge_transition = COALESCE($12, team_settings.jira_merge_transition)
+RETURNING team_name, reviewer_count, COALESCE(strategy, $13), COALESCE(sla_hours, $14), required_approvals,
+          max_open_reviews, strict_mode, exclude_leads, require_senior, org_fallback, jira_comment_on_merge, jira_merge_transition
+`
+	var st TeamSettings
+	err = s.db.QueryRowContext(ctx, query, upd.TeamName, upd.ReviewerCount, upd.Strategy, upd.SLAHours, upd.RequiredApprovals,
+		upd.MaxOpenReviews, upd.StrictMode, upd.ExcludeLeads, upd.RequireSenior, upd.OrgFallback,
+		upd.JiraCommentOnMerge, upd.JiraMergeTransition, defaults.DefaultStrategy, defaults.DefaultSLAHours).
+		Scan(&st.TeamName, &st.ReviewerCount, &st.Strategy, &st.SLAHours, &st.RequiredApprovals, &st.MaxOpenReviews,
+			&st.StrictMode, &st.ExcludeLeads, &st.RequireSenior, &st.OrgFallback, &st.JiraCommentOnMerge,
+			&st.JiraMergeTransition)
+	if err != nil {
+		return TeamSettings{}, fmt.Errorf("update team settings: %w", err)
+	}
+	return st, nil
+}
+
+func (s *Service) teamSettings(ctx context.Context, q querier, teamName string) (TeamSettings, error) {
+	defaults, err := s.runtimeSettings(ctx, q)
+	if err != nil {
+		return TeamSettings{}, err
+	}
+	st := TeamSettings{
+		TeamName:      teamName,
+		ReviewerCount: RequiredReviewers,
+		Strategy:      defaults.DefaultStrategy,
+		SLAHours:      defaults.DefaultSLAHours,
+	}
+
+	const query = `
+SELECT reviewer_count, COALESCE(strategy, $2), COALESCE(sla_hours, $3), required_approvals, max_open_reviews,
+       strict_mode, exclude_leads, require_senior, org_fallback, jira_comment_on_merge, jira_merge_transition
+FROM team_settings
+WHERE team_name = $1
+`
+	err = q.QueryRowContext(ctx, query, teamName, defaults.DefaultStrategy, defaults.DefaultSLAHours).
+		Scan(&st.ReviewerCount, &st.Strategy, &st.SLAHours, &st.RequiredApprovals, &st.MaxOpenReviews,
+			&st.StrictMode, &st.ExcludeLeads, &st.RequireSenior, &st.OrgFallback, &st.JiraCommentOnMerge,
+			&st.JiraMergeTransition)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return TeamSettings{}, fmt.Errorf("get team settings: %w", err)
+	}
+	return st, nil
+}
+
+func (s *Service) checkTeamExists(ctx context.Context, teamName string) error {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `SELECT TRUE FROM teams WHERE team_name = $1 AND archived_at IS NULL`, teamName).Scan(&exists)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &Error{Code: ErrorCodeNotFound, Message: "team not found"}
+		}
+		return fmt.Errorf("check team: %w", err)
+	}
+	return nil
+}
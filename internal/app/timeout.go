@@ -0,0 +1,15 @@
+package app
+
+import (
+	"context"
+	"errors"
+)
+
+// IsTimeout reports whether err comes from an expired deadline, either of
+// the context or of a database statement cancelled because of it.
+func IsTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	return hasPQCode(err, pqQueryCanceled)
+}
@@ -0,0 +1,63 @@
+package app
+
+import (
+	"context"
+	"fmt"
+)
+
+// UnderassignedPullRequest is an open pull request lacking reviewers.
+type UnderassignedPullRequest struct {
+	PullRequest
+	TeamName          string `json:"team_name"`
+	RequiredReviewers int    `json:"required_reviewers"`
+	MissingReviewers  int    `json:"missing_reviewers"`
+}
+
+// GetUnderassignedPullRequests returns open pull requests with fewer
+// reviewers than the reviewer_count of the author's team, e.g. after
+// reviewers were deactivated.
+func (s *Service) GetUnderassignedPullRequests(ctx context.Context) ([]UnderassignedPullRequest, error) {
+	const query = `
+SELECT ` + pullRequestColumns + `,
+       a.author_team,
+       a.required_reviewers,
+       a.required_reviewers - cardinality({reviewers:pull_requests})
+FROM pull_requests
+CROSS JOIN LATERAL (
+    SELECT COALESCE(u.team_name, '') AS author_team,
+           COALESCE(ts.reviewer_count, $1) AS required_reviewers
+    FROM users u
+    LEFT JOIN team_settings ts ON ts.team_name = u.team_name
+    WHERE u.user_id = pull_requests.author_id
+) a
+WHERE status = 'OPEN'
+  AND deleted_at IS NULL
+  AND cardinality({reviewers:pull_requests}) < a.required_reviewers
+ORDER BY created_at, pull_request_id
+`
+	rows, err := s.db.QueryContext(ctx, s.expandReviewers(query), RequiredReviewers)
+	if err != nil {
+		return nil, fmt.Errorf("get underassigned pull requests: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	prs := make([]UnderassignedPullRequest, 0)
+	for rows.Next() {
+		var u UnderassignedPullRequest
+		pr, err := s.scanPullRequest(scanFunc(func(dest ...any) error {
+			return rows.Scan(append(dest, &u.TeamName, &u.RequiredReviewers, &u.MissingReviewers)...)
+		}))
+		if err != nil {
+			return nil, fmt.Errorf("scan underassigned pull request: %w", err)
+		}
+		u.PullRequest = pr
+		prs = append(prs, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("underassigned pull requests rows: %w", err)
+	}
+
+	return prs, nil
+}
@@ -0,0 +1,193 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// APIUsage is the aggregated API usage of one client and endpoint within an hour.
+type APIUsage struct {
+	Bucket        time.Time `json:"bucket"`
+	Endpoint      string    `json:"endpoint"`
+	Client        string    `json:"client"`
+	Calls         int64     `json:"calls"`
+	Errors        int64     `json:"errors"`
+	AvgDurationMs float64   `json:"avg_duration_ms"`
+	MaxDurationMs float64   `json:"max_duration_ms"`
+}
+
+// UsageFilter narrows down GetAPIUsage results. Zero values are ignored.
+type UsageFilter struct {
+	From     *time.Time
+	To       *time.Time
+	Endpoint string
+	Client   string
+}
+
+type usageKey struct {
+	bucket   time.Time
+	endpoint string
+	client   string
+}
+
+type usageCounter struct {
+	calls   int64
+	errors  int64
+	totalMs float64
+	maxMs   float64
+}
+
+func (c *usageCounter) merge(o *usageCounter) {
+	c.calls += o.calls
+	c.errors += o.errors
+	c.totalMs += o.totalMs
+	if o.maxMs > c.maxMs {
+		c.maxMs = o.maxMs
+	}
+}
+
+// usageRecorder buffers API call counters in memory until they are flushed.
+type usageRecorder struct {
+	mu       sync.Mutex
+	counters map[usageKey]*usageCounter
+}
+
+func newUsageRecorder() *usageRecorder {
+	return &usageRecorder{counters: make(map[usageKey]*usageCounter)}
+}
+
+func (r *usageRecorder) add(key usageKey, c *usageCounter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.counters[key]
+	if !ok {
+		r.counters[key] = c
+		return
+	}
+	existing.merge(c)
+}
+
+func (r *usageRecorder) drain() map[usageKey]*usageCounter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counters := r.counters
+	r.counters = make(map[usageKey]*usageCounter)
+	return counters
+}
+
+// RecordAPICall counts a finished API call. It only touches memory;
+// FlushUsage persists the counters.
+func (s *Service) RecordAPICall(endpoint, client string, status int, duration time.Duration) {
+	ms := float64(duration) / float64(time.Millisecond)
+	c := &usageCounter{calls: 1, totalMs: ms, maxMs: ms}
+	if status >= 500 {
+		c.errors = 1
+	}
+	key := usageKey{
+		bucket:   time.Now().UTC().Truncate(time.Hour),
+		endpoint: endpoint,
+		client:   client,
+	}
+	s.usage.add(key, c)
+}
+
+// FlushUsage writes buffered API usage counters to the database.
+func (s *Service) FlushUsage(ctx context.Context) error {
+	counters := s.usage.drain()
+	if len(counters) == 0 {
+		return nil
+	}
+
+	if err := s.writeUsage(ctx, counters); err != nil {
+		for key, c := range counters {
+			s.usage.add(key, c)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *Service) writeUsage(ctx context.Context, counters map[usageKey]*usageCounter) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	const upsertQuery = `
+INSERT INTO api_usage(bucket, endpoint, client, calls, errors, total_duration_ms, max_duration_ms)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (bucket, endpoint, client) DO UPDATE
+SET calls = api_usage.calls + EXCLUDED.calls,
+    errors = api_usage.errors + EXCLUDED.errors,
+    total_duration_ms = api_usage.total_duration_ms + EXCLUDED.total_duration_ms,
+    max_duration_ms = GREATEST(api_usage.max_duration_ms, EXCLUDED.max_duration_ms)
+`
+	for key, c := range counters {
+		_, err := tx.ExecContext(ctx, upsertQuery, key.bucket, key.endpoint, key.client,
+			c.calls, c.errors, c.totalMs, c.maxMs)
+		if err != nil {
+			return fmt.Errorf("upsert api usage: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// GetAPIUsage returns hourly API usage, flushing buffered counters first.
+func (s *Service) GetAPIUsage(ctx context.Context, f UsageFilter) ([]APIUsage, error) {
+	if err := s.FlushUsage(ctx); err != nil {
+		return nil, fmt.Errorf("flush api usage: %w", err)
+	}
+
+	var where whereBuilder
+	if f.From != nil {
+		where.add("bucket >= ?", *f.From)
+	}
+	if f.To != nil {
+		where.add("bucket < ?", *f.To)
+	}
+	if f.Endpoint != "" {
+		where.add("endpoint = ?", f.Endpoint)
+	}
+	if f.Client != "" {
+		where.add("client = ?", f.Client)
+	}
+
+	query := `
+SELECT bucket, endpoint, client, calls, errors, total_duration_ms / GREATEST(calls, 1), max_duration_ms
+FROM api_usage
+` + where.String() + `
+ORDER BY bucket, endpoint, client
+`
+	rows, err := s.db.QueryContext(ctx, query, where.args...)
+	if err != nil {
+		return nil, fmt.Errorf("get api usage: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	usage := make([]APIUsage, 0)
+	for rows.Next() {
+		var u APIUsage
+		if err := rows.Scan(&u.Bucket, &u.Endpoint, &u.Client, &u.Calls, &u.Errors, &u.AvgDurationMs, &u.MaxDurationMs); err != nil {
+			return nil, fmt.Errorf("scan api usage: %w", err)
+		}
+		usage = append(usage, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("api usage rows: %w", err)
+	}
+
+	return usage, nil
+}
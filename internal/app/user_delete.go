@@ -0,0 +1,105 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// AssignmentReasonUserDeleted marks reviewers unassigned because their user was deleted.
+const AssignmentReasonUserDeleted = "USER_DELETED"
+
+// DeletedUsername replaces the name of deleted users.
+const DeletedUsername = "Deleted user"
+
+// UserDeletion is the result of DeleteUser.
+type UserDeletion struct {
+	UserID    string    `json:"user_id"`
+	DeletedAt time.Time `json:"deleted_at"`
+	// RemovedAssignments counts open pull requests the user was removed from.
+	RemovedAssignments int `json:"removed_assignments"`
+}
+
+// DeleteUser anonymizes a user: personal data is scrubbed, the user leaves
+// their team, is deactivated and removed from open pull requests. The row
+// itself is kept, so pull requests, reviews and merge snapshots that
+// reference the user_id stay intact; the user_id cannot be reused.
+func (s *Service) DeleteUser(ctx context.Context, userID string) (UserDeletion, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return UserDeletion{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	const selectUserQuery = `
+SELECT COALESCE(team_name, ''), is_active
+FROM users
+WHERE user_id = $1
+  AND deleted_at IS NULL
+FOR UPDATE
+`
+	var teamName string
+	var isActive bool
+	if err := tx.QueryRowContext(ctx, selectUserQuery, userID).Scan(&teamName, &isActive); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return UserDeletion{}, &Error{Code: ErrorCodeNotFound, Message: "user not found"}
+		}
+		return UserDeletion{}, fmt.Errorf("get user: %w", err)
+	}
+
+	const countQuery = `
+SELECT COUNT(*)
+FROM pull_requests
+WHERE status = 'OPEN'
+  AND deleted_at IS NULL
+  AND ($1 = ANY({reviewers:pull_requests}) OR (security_reviewer_id = $1 AND security_approved_at IS NULL))
+`
+	result := UserDeletion{UserID: userID}
+	if err := tx.QueryRowContext(ctx, s.expandReviewers(countQuery), userID).Scan(&result.RemovedAssignments); err != nil {
+		return UserDeletion{}, fmt.Errorf("count open assignments: %w", err)
+	}
+
+	if err := s.removeOpenAssignments(ctx, tx, []string{userID}, AssignmentReasonUserDeleted); err != nil {
+		return UserDeletion{}, fmt.Errorf("remove deleted user from pull requests: %w", err)
+	}
+
+	const anonymizeQuery = `
+UPDATE users
+SET username = $2,
+    team_name = NULL,
+    is_active = FALSE,
+    role = 'MEMBER',
+    email = '',
+    slack_handle = '',
+    avatar_url = '',
+    github_login = '',
+    deleted_at = NOW()
+WHERE user_id = $1
+RETURNING deleted_at
+`
+	if err := tx.QueryRowContext(ctx, anonymizeQuery, userID, DeletedUsername).Scan(&result.DeletedAt); err != nil {
+		return UserDeletion{}, fmt.Errorf("anonymize user: %w", err)
+	}
+
+	if teamName != "" {
+		if isActive {
+			if err := recordMembershipEvent(ctx, tx, teamName, userID, MembershipDeactivated, ""); err != nil {
+				return UserDeletion{}, err
+			}
+		}
+		if err := recordMembershipEvent(ctx, tx, teamName, userID, MembershipLeft, ""); err != nil {
+			return UserDeletion{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return UserDeletion{}, fmt.Errorf("commit tx: %w", err)
+	}
+
+	s.publishQueueChange()
+	return result, nil
+}
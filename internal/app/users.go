@@ -0,0 +1,230 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// UserDetails is a user together with their current review load.
+type UserDetails struct {
+	User
+	// OpenReviews counts open pull requests the user is assigned to,
+	// including pending security reviews.
+	OpenReviews int `json:"open_reviews"`
+}
+
+// CreateUser creates a user, optionally attached to an existing team.
+func (s *Service) CreateUser(ctx context.Context, u User) (User, error) {
+	if u.Role == "" {
+		u.Role = RoleMember
+	}
+	if u.TeamName != "" {
+		if err := s.checkTeamExists(ctx, u.TeamName); err != nil {
+			return User{}, err
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return User{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	const query = `
+INSERT INTO users(user_id, username, team_name, is_active, role, email, slack_handle, avatar_url, github_login)
+VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9)
+ON CONFLICT (user_id) DO NOTHING
+`
+	res, err := tx.ExecContext(ctx, query, u.ID, u.Name, u.TeamName, u.IsActive, u.Role,
+		u.Email, u.SlackHandle, u.AvatarURL, u.GitHubLogin)
+	if err != nil {
+		return User{}, fmt.Errorf("insert user: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return User{}, fmt.Errorf("insert user: %w", err)
+	}
+	if n == 0 {
+		return User{}, &Error{Code: ErrorCodeUserExists, Message: "user_id already exists"}
+	}
+
+	if u.TeamName != "" {
+		if err := recordJoin(ctx, tx, u.TeamName, u.ID, "", u.IsActive); err != nil {
+			return User{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return User{}, fmt.Errorf("commit tx: %w", err)
+	}
+
+	return u, nil
+}
+
+// GetUser returns a user with their team, activity flag and review load.
+func (s *Service) GetUser(ctx context.Context, userID string) (UserDetails, error) {
+	const query = `
+SELECT u.user_id, u.username, COALESCE(u.team_name, ''), u.is_active, u.role,
+       u.email, u.slack_handle, u.avatar_url, u.github_login,
+       (SELECT COUNT(*)
+        FROM pull_requests p
+        WHERE p.status = 'OPEN'
+          AND p.deleted_at IS NULL
+          AND (u.user_id = ANY({reviewers:p})
+               OR (p.security_reviewer_id = u.user_id AND p.security_approved_at IS NULL)))
+FROM users u
+WHERE u.user_id = $1
+  AND u.deleted_at IS NULL
+`
+	var d UserDetails
+	err := s.db.QueryRowContext(ctx, s.expandReviewers(query), userID).Scan(&d.ID, &d.Name, &d.TeamName, &d.IsActive, &d.Role,
+		&d.Email, &d.SlackHandle, &d.AvatarURL, &d.GitHubLogin, &d.OpenReviews)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return UserDetails{}, &Error{Code: ErrorCodeNotFound, Message: "user not found"}
+		}
+		return UserDetails{}, fmt.Errorf("get user: %w", err)
+	}
+	return d, nil
+}
+
+// GetUserBySlackHandle finds the user whose slack_handle is the given Slack
+// member ID or handle; a leading @ is ignored on both sides.
+func (s *Service) GetUserBySlackHandle(ctx context.Context, handle string) (User, error) {
+	const query = `
+SELECT user_id, username, COALESCE(team_name, ''), is_active, role, email, slack_handle, avatar_url, github_login
+FROM users
+WHERE deleted_at IS NULL
+  AND slack_handle <> ''
+  AND ltrim(slack_handle, '@') = ltrim($1, '@')
+ORDER BY user_id
+LIMIT 2
+`
+	rows, err := s.db.QueryContext(ctx, query, handle)
+	if err != nil {
+		return User{}, fmt.Errorf("find user by slack handle: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var users []User
+	for rows.Next() {
+		var u User
+		if err := rows.Scan(&u.ID, &u.Name, &u.TeamName, &u.IsActive, &u.Role, &u.Email, &u.SlackHandle, &u.AvatarURL, &u.GitHubLogin); err != nil {
+			return User{}, fmt.Errorf("scan user: %w", err)
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return User{}, fmt.Errorf("user rows: %w", err)
+	}
+
+	switch len(users) {
+	case 0:
+		return User{}, &Error{Code: ErrorCodeNotFound, Message: "no user has this slack handle"}
+	case 1:
+		return users[0], nil
+	}
+	return User{}, &Error{Code: ErrorCodeValidation, Message: "slack handle is shared by several users"}
+}
+
+// ListUsers returns a page of users ordered by user_id, optionally limited to
+// one team, and the cursor key of the next page, empty on the last page.
+func (s *Service) ListUsers(ctx context.Context, teamName string, page Page) ([]User, string, error) {
+	if teamName != "" {
+		if err := s.checkTeamExists(ctx, teamName); err != nil {
+			return nil, "", err
+		}
+	}
+
+	const query = `
+SELECT user_id, username, COALESCE(team_name, ''), is_active, role, email, slack_handle, avatar_url, github_login
+FROM users
+WHERE deleted_at IS NULL
+  AND ($1 = '' OR team_name = $1)
+  AND ($2 = '' OR user_id > $2)
+ORDER BY user_id
+LIMIT $3 OFFSET $4
+`
+	rows, err := s.db.QueryContext(ctx, query, teamName, page.After, page.fetchLimit(), page.Offset)
+	if err != nil {
+		return nil, "", fmt.Errorf("list users: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	users := make([]User, 0)
+	for rows.Next() {
+		var u User
+		if err := rows.Scan(&u.ID, &u.Name, &u.TeamName, &u.IsActive, &u.Role, &u.Email, &u.SlackHandle, &u.AvatarURL, &u.GitHubLogin); err != nil {
+			return nil, "", fmt.Errorf("scan user: %w", err)
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("users rows: %w", err)
+	}
+
+	users, next := cutPage(users, page, func(u User) string { return u.ID })
+	return users, next, nil
+}
+
+// SetUserRole changes the team role of a user. Roles only affect future assignments.
+func (s *Service) SetUserRole(ctx context.Context, userID, role string) (User, error) {
+	const query = `
+UPDATE users SET role = $2
+WHERE user_id = $1
+  AND deleted_at IS NULL
+RETURNING user_id, username, COALESCE(team_name, ''), is_active, role, email, slack_handle, avatar_url, github_login
+`
+	var u User
+	err := s.db.QueryRowContext(ctx, query, userID, role).
+		Scan(&u.ID, &u.Name, &u.TeamName, &u.IsActive, &u.Role, &u.Email, &u.SlackHandle, &u.AvatarURL, &u.GitHubLogin)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return User{}, &Error{Code: ErrorCodeNotFound, Message: "user not found"}
+		}
+		return User{}, fmt.Errorf("set role: %w", err)
+	}
+	return u, nil
+}
+
+// ProfileUpdate holds profile fields to change. Nil fields are left unchanged,
+// an empty string clears the field.
+type ProfileUpdate struct {
+	UserID      string
+	Email       *string
+	SlackHandle *string
+	AvatarURL   *string
+	GitHubLogin *string
+}
+
+// UpdateUserProfile changes the contact details of a user.
+func (s *Service) UpdateUserProfile(ctx context.Context, upd ProfileUpdate) (User, error) {
+	const query = `
+UPDATE users
+SET email = COALESCE($2, email),
+    slack_handle = COALESCE($3, slack_handle),
+    avatar_url = COALESCE($4, avatar_url),
+    github_login = COALESCE($5, github_login)
+WHERE user_id = $1
+  AND deleted_at IS NULL
+RETURNING user_id, username, COALESCE(team_name, ''), is_active, role, email, slack_handle, avatar_url, github_login
+`
+	var u User
+	err := s.db.QueryRowContext(ctx, query, upd.UserID, upd.Email, upd.SlackHandle, upd.AvatarURL, upd.GitHubLogin).
+		Scan(&u.ID, &u.Name, &u.TeamName, &u.IsActive, &u.Role, &u.Email, &u.SlackHandle, &u.AvatarURL, &u.GitHubLogin)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return User{}, &Error{Code: ErrorCodeNotFound, Message: "user not found"}
+		}
+		return User{}, fmt.Errorf("update profile: %w", err)
+	}
+	return u, nil
+}
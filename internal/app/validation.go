@@ -0,0 +1,54 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MaxTeamNameLength is the longest accepted team name.
+const MaxTeamNameLength = 100
+
+// FieldError describes an invalid field of a request payload.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidateTeam checks a team payload and returns every problem found.
+// Field names follow the JSON payload, e.g. members[1].user_id.
+func ValidateTeam(team Team) []FieldError {
+	var errs []FieldError
+	switch {
+	case strings.TrimSpace(team.Name) == "":
+		errs = append(errs, FieldError{Field: "team_name", Message: "must not be empty"})
+	case len(team.Name) > MaxTeamNameLength:
+		errs = append(errs, FieldError{Field: "team_name", Message: fmt.Sprintf("must be at most %d characters", MaxTeamNameLength)})
+	}
+
+	seen := make(map[string]int, len(team.Members))
+	for i, m := range team.Members {
+		prefix := fmt.Sprintf("members[%d].", i)
+		if strings.TrimSpace(m.ID) == "" {
+			errs = append(errs, FieldError{Field: prefix + "user_id", Message: "must not be empty"})
+		} else if first, ok := seen[m.ID]; ok {
+			errs = append(errs, FieldError{Field: prefix + "user_id", Message: fmt.Sprintf("duplicates members[%d]", first)})
+		} else {
+			seen[m.ID] = i
+		}
+		if strings.TrimSpace(m.Name) == "" {
+			errs = append(errs, FieldError{Field: prefix + "username", Message: "must not be empty"})
+		}
+		if m.Role != "" && !IsValidRole(m.Role) {
+			errs = append(errs, FieldError{Field: prefix + "role", Message: "must be one of LEAD, SENIOR, MEMBER"})
+		}
+		for _, fe := range profileFieldErrors(m.Profile) {
+			fe.Field = prefix + fe.Field
+			errs = append(errs, fe)
+		}
+	}
+	return errs
+}
+
+func validationError(fields []FieldError) error {
+	return &Error{Code: ErrorCodeValidation, Message: "invalid request payload", Fields: fields}
+}
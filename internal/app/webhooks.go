@@ -0,0 +1,287 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// WebhookChannel is the outbox channel of webhook deliveries.
+const WebhookChannel = "webhook"
+
+// Types of webhook events.
+const (
+	WebhookEventReviewAssigned    = "review.assigned"
+	WebhookEventReviewReplaced    = "review.replaced"
+	WebhookEventPullRequestMerged = "pull_request.merged"
+	// WebhookEventPing is only sent by TestWebhook.
+	WebhookEventPing = "ping"
+)
+
+// MinWebhookSecretLength is the shortest secret a subscription may use.
+const MinWebhookSecretLength = 16
+
+// WebhookSender delivers a webhook event to a subscriber, signing body with
+// the subscription secret. A returned error makes the outbox retry.
+type WebhookSender interface {
+	SendWebhook(ctx context.Context, url, secret, eventID string, body []byte) error
+}
+
+// WithWebhookSender enables webhook subscriptions.
+func WithWebhookSender(w WebhookSender) Option {
+	return func(s *Service) {
+		s.webhookSender = w
+	}
+}
+
+// WebhookSubscription is an endpoint receiving webhook events. The secret
+// is only returned when the subscription is created.
+type WebhookSubscription struct {
+	ID        int64     `json:"subscription_id"`
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WebhookEvent is the body of a webhook delivery. Retries carry the same ID,
+// so that receivers can drop duplicates.
+type WebhookEvent struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// WebhookTest is the result of a test delivery.
+type WebhookTest struct {
+	SubscriptionID int64  `json:"subscription_id"`
+	EventID        string `json:"event_id"`
+	Delivered      bool   `json:"delivered"`
+	Error          string `json:"error,omitempty"`
+}
+
+// webhookMessage is the outbox payload of a delivery to one subscription.
+type webhookMessage struct {
+	SubscriptionID int64           `json:"subscription_id"`
+	Event          json.RawMessage `json:"event"`
+}
+
+// IsValidWebhookURL reports whether u is an absolute http or https URL.
+func IsValidWebhookURL(u string) bool {
+	parsed, err := url.Parse(u)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// CreateWebhook subscribes url to webhook events and returns the
+// subscription with its signing secret, generated when secret is empty.
+func (s *Service) CreateWebhook(ctx context.Context, url, secret string) (WebhookSubscription, string, error) {
+	if s.webhookSender == nil {
+		return WebhookSubscription{}, "", &Error{Code: ErrorCodeValidation, Message: "webhooks are not configured"}
+	}
+	if secret == "" {
+		var err error
+		if secret, err = newWebhookSecret(); err != nil {
+			return WebhookSubscription{}, "", err
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return WebhookSubscription{}, "", fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// A sealed secret is bound to the subscription ID, so it is stored
+	// once the ID is known.
+	clearSecret := secret
+	if s.credentialsBox != nil {
+		clearSecret = ""
+	}
+	const query = `
+INSERT INTO webhook_subscriptions (url, secret)
+VALUES ($1, $2)
+RETURNING subscription_id, url, created_at
+`
+	var sub WebhookSubscription
+	if err := tx.QueryRowContext(ctx, query, url, clearSecret).Scan(&sub.ID, &sub.URL, &sub.CreatedAt); err != nil {
+		return WebhookSubscription{}, "", fmt.Errorf("insert webhook subscription: %w", err)
+	}
+	if s.credentialsBox != nil {
+		sealed, err := s.sealWebhookSecret(sub.ID, secret)
+		if err != nil {
+			return WebhookSubscription{}, "", err
+		}
+		const sealQuery = `UPDATE webhook_subscriptions SET secret_sealed = $2 WHERE subscription_id = $1`
+		if _, err := tx.ExecContext(ctx, sealQuery, sub.ID, sealed); err != nil {
+			return WebhookSubscription{}, "", fmt.Errorf("seal webhook secret: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return WebhookSubscription{}, "", fmt.Errorf("commit tx: %w", err)
+	}
+	return sub, secret, nil
+}
+
+// ListWebhooks returns all webhook subscriptions.
+func (s *Service) ListWebhooks(ctx context.Context) ([]WebhookSubscription, error) {
+	const query = `SELECT subscription_id, url, created_at FROM webhook_subscriptions ORDER BY subscription_id`
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook subscriptions: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	subs := make([]WebhookSubscription, 0)
+	for rows.Next() {
+		var sub WebhookSubscription
+		if err := rows.Scan(&sub.ID, &sub.URL, &sub.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan webhook subscription: %w", err)
+		}
+		subs = append(subs, sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("webhook subscriptions rows: %w", err)
+	}
+	return subs, nil
+}
+
+// DeleteWebhook removes a subscription. Queued deliveries to it are dropped.
+func (s *Service) DeleteWebhook(ctx context.Context, id int64) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE subscription_id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete webhook subscription: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("delete webhook subscription: %w", err)
+	}
+	if n == 0 {
+		return &Error{Code: ErrorCodeNotFound, Message: "webhook subscription not found"}
+	}
+	return nil
+}
+
+// TestWebhook sends a signed ping to a subscription right away, bypassing
+// the outbox, so that a receiver can check its signature verification.
+func (s *Service) TestWebhook(ctx context.Context, id int64) (WebhookTest, error) {
+	if s.webhookSender == nil {
+		return WebhookTest{}, &Error{Code: ErrorCodeValidation, Message: "webhooks are not configured"}
+	}
+	url, secret, err := s.getWebhookSubscription(ctx, s.db, id)
+	if err != nil {
+		return WebhookTest{}, err
+	}
+	ev, err := newWebhookEvent(WebhookEventPing, map[string]any{"subscription_id": id})
+	if err != nil {
+		return WebhookTest{}, err
+	}
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return WebhookTest{}, fmt.Errorf("encode webhook event: %w", err)
+	}
+
+	test := WebhookTest{SubscriptionID: id, EventID: ev.ID, Delivered: true}
+	if err := s.webhookSender.SendWebhook(ctx, url, secret, ev.ID, body); err != nil {
+		test.Delivered = false
+		test.Error = err.Error()
+	}
+	return test, nil
+}
+
+// enqueueWebhookEvent queues an event for every subscription in the
+// transaction of the change it reports.
+func (s *Service) enqueueWebhookEvent(ctx context.Context, q querier, eventType string, data any) error {
+	if s.webhookSender == nil {
+		return nil
+	}
+	ev, err := newWebhookEvent(eventType, data)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("encode webhook event: %w", err)
+	}
+
+	const query = `
+INSERT INTO outbox (kind, channel, payload)
+SELECT $1, $2, jsonb_build_object('subscription_id', subscription_id, 'event', $3::JSONB)
+FROM webhook_subscriptions
+`
+	if _, err := q.ExecContext(ctx, query, OutboxKindWebhook, WebhookChannel, payload); err != nil {
+		return fmt.Errorf("insert webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+// deliverWebhook sends a queued event. Deliveries to deleted subscriptions
+// are dropped.
+func (s *Service) deliverWebhook(ctx context.Context, q querier, msg webhookMessage) error {
+	if s.webhookSender == nil {
+		return errors.New("webhooks are not configured")
+	}
+	url, secret, err := s.getWebhookSubscription(ctx, q, msg.SubscriptionID)
+	if err != nil {
+		var appErr *Error
+		if errors.As(err, &appErr) && appErr.Code == ErrorCodeNotFound {
+			return nil
+		}
+		return err
+	}
+	var ev struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(msg.Event, &ev); err != nil {
+		return fmt.Errorf("decode webhook event: %w", err)
+	}
+	return s.webhookSender.SendWebhook(ctx, url, secret, ev.ID, msg.Event)
+}
+
+func (s *Service) getWebhookSubscription(ctx context.Context, q querier, id int64) (url, secret string, err error) {
+	const query = `SELECT url, secret, secret_sealed FROM webhook_subscriptions WHERE subscription_id = $1`
+	var sealed []byte
+	if err := q.QueryRowContext(ctx, query, id).Scan(&url, &secret, &sealed); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", "", &Error{Code: ErrorCodeNotFound, Message: "webhook subscription not found"}
+		}
+		return "", "", fmt.Errorf("get webhook subscription: %w", err)
+	}
+	secret, err = s.openWebhookSecret(id, secret, sealed)
+	if err != nil {
+		return "", "", err
+	}
+	return url, secret, nil
+}
+
+func newWebhookEvent(eventType string, data any) (WebhookEvent, error) {
+	id, err := randomHex(16)
+	if err != nil {
+		return WebhookEvent{}, err
+	}
+	return WebhookEvent{ID: id, Type: eventType, CreatedAt: time.Now().UTC(), Data: data}, nil
+}
+
+func newWebhookSecret() (string, error) {
+	secret, err := randomHex(24)
+	if err != nil {
+		return "", err
+	}
+	return "whsec_" + secret, nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate random bytes: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
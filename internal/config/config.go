@@ -0,0 +1,554 @@
+// Package config loads service settings from environment variables.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"review-assigner/internal/ratelimit"
+	"review-assigner/internal/secretbox"
+)
+
+// Config holds service settings.
+type Config struct {
+	// DatabaseURL is DATABASE_URL or, when it is not set, a URL assembled
+	// from the libpq variables PGHOST, PGPORT, PGUSER, PGPASSWORD,
+	// PGDATABASE and PGSSLMODE.
+	DatabaseURL string
+	Addr        string
+
+	// TLSCertFile and TLSKeyFile make the server serve HTTPS on Addr with
+	// this certificate. ACMEHosts does the same with certificates issued by
+	// Let's Encrypt for these host names, cached in ACMECacheDir; ACMEEmail
+	// is the optional account contact. HTTPRedirectAddr is the plain HTTP
+	// address redirecting to HTTPS and answering ACME challenges, which
+	// need port 80.
+	TLSCertFile      string
+	TLSKeyFile       string
+	ACMEHosts        []string
+	ACMECacheDir     string
+	ACMEEmail        string
+	HTTPRedirectAddr string
+
+	// DBMaxOpenConns and DBMaxIdleConns size the connection pool; zero
+	// open connections means no limit. DBConnMaxLifetime recycles
+	// connections, e.g. to follow a failover behind a DNS name.
+	DBMaxOpenConns    int
+	DBMaxIdleConns    int
+	DBConnMaxLifetime time.Duration
+	// DBConnectTimeout bounds retries of the first database connection, so
+	// that the service may start before Postgres.
+	DBConnectTimeout time.Duration
+
+	// RequestTimeout is the deadline of each request's handler and database
+	// queries. Zero disables it.
+	RequestTimeout time.Duration
+
+	// ShutdownTimeout bounds a graceful shutdown: draining in-flight
+	// requests, finishing background jobs and flushing the outbox.
+	ShutdownTimeout time.Duration
+
+	// RateLimitRead, RateLimitWrite, RateLimitIntegration and RateLimitAdmin
+	// limit the calls of each client to the respective route class.
+	RateLimitRead        ratelimit.Limit
+	RateLimitWrite       ratelimit.Limit
+	RateLimitIntegration ratelimit.Limit
+	RateLimitAdmin       ratelimit.Limit
+	// RateLimitAuthFailure limits the calls refused with 401 per IP address.
+	RateLimitAuthFailure ratelimit.Limit
+
+	// LogLevel is the lowest level of logged records: debug, info, warn
+	// or error.
+	LogLevel slog.Level
+
+	// AccessLogFile enables an access log written to this file apart from
+	// the application log. It is rotated when it outgrows
+	// AccessLogMaxSizeMB or gets older than AccessLogMaxAge, keeping
+	// AccessLogMaxBackups rotated files; zero disables each limit.
+	AccessLogFile       string
+	AccessLogMaxSizeMB  int64
+	AccessLogMaxAge     time.Duration
+	AccessLogMaxBackups int
+
+	// ReviewerStorage selects how assigned reviewers are persisted:
+	// "array" (legacy column only), "dual" (write both, read array)
+	// or "table" (write both, read join table).
+	ReviewerStorage string
+	// ReviewerStorageVerifyInterval enables a periodic comparison of both
+	// reviewer representations. Zero disables the job.
+	ReviewerStorageVerifyInterval time.Duration
+
+	// UsageFlushInterval controls how often buffered API usage counters are written.
+	UsageFlushInterval time.Duration
+
+	// MergedRetentionDays is how long merged pull requests stay in the hot
+	// set before they are archived. Zero disables automatic archival.
+	MergedRetentionDays int
+	// ArchiveInterval controls how often the archival job runs.
+	ArchiveInterval time.Duration
+
+	// AuditRetentionDays is how long audit log entries are kept, purged
+	// once per ArchiveInterval. Zero keeps them forever.
+	AuditRetentionDays int
+
+	// StatsSnapshotInterval controls how often today's assignment stats
+	// snapshot is refreshed. Zero disables the job.
+	StatsSnapshotInterval time.Duration
+
+	// StatsCacheTTL is how long assignment stats are served from memory.
+	// Zero disables the cache.
+	StatsCacheTTL time.Duration
+
+	// SecurityTeam is the team whose members perform second-stage security
+	// reviews. Empty disables needs_security_review.
+	SecurityTeam string
+
+	// CORSAllowedOrigins are the browser origins allowed to call the API,
+	// "*" for any. Empty disables CORS.
+	CORSAllowedOrigins []string
+
+	// SlackBotToken and SlackWebhookURL enable Slack notifications about
+	// reviewer assignments. Both empty disables them.
+	SlackBotToken   string
+	SlackWebhookURL string
+	// SlackSigningSecret enables the Slack slash command endpoint.
+	SlackSigningSecret string
+	// SCIMToken is the bearer token of directory provisioning through SCIM.
+	// Empty disables the SCIM endpoints.
+	SCIMToken string
+	// APIKeyAuth requires an X-API-Key on API calls. BootstrapAPIKey is
+	// accepted besides the issued keys, to issue the first ones.
+	APIKeyAuth      bool
+	BootstrapAPIKey string
+	// OIDCIssuerURL enables Bearer tokens issued by this OpenID Connect
+	// provider for OIDCAudience. Keys are discovered from the issuer unless
+	// OIDCJWKSURL is set. OIDCUserClaim and OIDCRolesClaim name the claims
+	// with the caller's user_id and roles; OIDCAdminRole grants /admin.
+	OIDCIssuerURL  string
+	OIDCAudience   string
+	OIDCJWKSURL    string
+	OIDCUserClaim  string
+	OIDCRolesClaim string
+	OIDCAdminRole  string
+	// CredentialsKey is the master key encrypting the credentials stored
+	// through /admin/credentials and the webhook secrets; nil disables
+	// stored credentials.
+	CredentialsKey []byte
+
+	// SMTPAddr (host:port) enables e-mail notifications sent from SMTPFrom.
+	// Empty SMTPUsername disables SMTP authentication.
+	SMTPAddr     string
+	SMTPFrom     string
+	SMTPUsername string
+	SMTPPassword string
+	// EmailDigestInterval controls how often review digests are e-mailed.
+	// Zero disables digests.
+	EmailDigestInterval time.Duration
+
+	// JiraBaseURL enables updates of the Jira issues linked to merged pull
+	// requests, authenticated as JiraUser with JiraAPIToken.
+	JiraBaseURL  string
+	JiraUser     string
+	JiraAPIToken string
+
+	// GitHubAppID enables GitHub App mode: reviews are requested on GitHub
+	// and reported in check runs, as the installation GitHubInstallationID
+	// authenticated with the PEM key GitHubPrivateKey. GitHubAPIURL is the
+	// API address, changed for GitHub Enterprise Server.
+	GitHubAPIURL         string
+	GitHubAppID          string
+	GitHubInstallationID string
+	GitHubPrivateKey     string
+
+	// OutboxDispatchInterval controls how often undelivered notifications
+	// are retried.
+	OutboxDispatchInterval time.Duration
+	// OutboxWorkers is the number of concurrent outbox deliveries.
+	OutboxWorkers int
+	// SlackMaxAttempts, EmailMaxAttempts, JiraMaxAttempts,
+	// GitHubMaxAttempts and WebhookMaxAttempts bound delivery attempts per channel before a
+	// message is dead-lettered; 0 retries forever.
+	SlackMaxAttempts   int
+	EmailMaxAttempts   int
+	JiraMaxAttempts    int
+	GitHubMaxAttempts  int
+	WebhookMaxAttempts int
+	// WebhookAllowPrivate lets webhooks be delivered to loopback, private
+	// and link-local addresses.
+	WebhookAllowPrivate bool
+}
+
+// Load reads the configuration from the environment, applying defaults.
+func Load() (Config, error) {
+	cfg := Config{
+		Addr:            getEnv("HTTP_ADDR", ":8080"),
+		TLSCertFile:     getEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:      getEnv("TLS_KEY_FILE", ""),
+		ACMECacheDir:    getEnv("ACME_CACHE_DIR", "acme-cache"),
+		ACMEEmail:       getEnv("ACME_EMAIL", ""),
+		ReviewerStorage: getEnv("REVIEWER_STORAGE", "array"),
+		SecurityTeam:    getEnv("SECURITY_TEAM", ""),
+
+		SMTPAddr:     getEnv("SMTP_ADDR", ""),
+		SMTPFrom:     getEnv("SMTP_FROM", "review-assigner@localhost"),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+
+		JiraBaseURL: getEnv("JIRA_BASE_URL", ""),
+		JiraUser:    getEnv("JIRA_USER", ""),
+
+		GitHubAPIURL:         getEnv("GITHUB_API_URL", "https://api.github.com"),
+		GitHubAppID:          getEnv("GITHUB_APP_ID", ""),
+		GitHubInstallationID: getEnv("GITHUB_INSTALLATION_ID", ""),
+	}
+	cfg.CORSAllowedOrigins = getList("CORS_ALLOWED_ORIGINS")
+	cfg.ACMEHosts = getList("ACME_HOSTS")
+	cfg.HTTPRedirectAddr = getEnv("HTTP_REDIRECT_ADDR", "")
+
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return Config{}, errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if cfg.TLSCertFile != "" && len(cfg.ACMEHosts) > 0 {
+		return Config{}, errors.New("TLS_CERT_FILE and ACME_HOSTS are mutually exclusive")
+	}
+	if cfg.HTTPRedirectAddr != "" && cfg.TLSCertFile == "" && len(cfg.ACMEHosts) == 0 {
+		return Config{}, errors.New("HTTP_REDIRECT_ADDR requires TLS_CERT_FILE or ACME_HOSTS")
+	}
+
+	if err := cfg.LogLevel.UnmarshalText([]byte(getEnv("LOG_LEVEL", "info"))); err != nil {
+		return Config{}, fmt.Errorf("parse LOG_LEVEL: %w", err)
+	}
+
+	cfg.AccessLogFile = getEnv("ACCESS_LOG_FILE", "")
+
+	var err error
+	cfg.DatabaseURL, err = databaseURL()
+	if err != nil {
+		return Config{}, err
+	}
+
+	// Secrets may come from files, e.g. Docker or Kubernetes secrets
+	// mounted as SCIM_TOKEN_FILE=/run/secrets/scim_token.
+	secrets := []struct {
+		dst *string
+		key string
+	}{
+		{&cfg.SlackBotToken, "SLACK_BOT_TOKEN"},
+		{&cfg.SlackWebhookURL, "SLACK_WEBHOOK_URL"},
+		{&cfg.SlackSigningSecret, "SLACK_SIGNING_SECRET"},
+		{&cfg.SCIMToken, "SCIM_TOKEN"},
+		{&cfg.BootstrapAPIKey, "BOOTSTRAP_API_KEY"},
+		{&cfg.SMTPPassword, "SMTP_PASSWORD"},
+		{&cfg.JiraAPIToken, "JIRA_API_TOKEN"},
+		{&cfg.GitHubPrivateKey, "GITHUB_APP_PRIVATE_KEY"},
+	}
+	for _, sec := range secrets {
+		if *sec.dst, err = getSecret(sec.key, ""); err != nil {
+			return Config{}, err
+		}
+	}
+
+	credentialsKey, err := getSecret("CREDENTIALS_KEY", "")
+	if err != nil {
+		return Config{}, err
+	}
+	if credentialsKey != "" {
+		if cfg.CredentialsKey, err = secretbox.ParseKey(credentialsKey); err != nil {
+			return Config{}, fmt.Errorf("parse CREDENTIALS_KEY: %w", err)
+		}
+	}
+
+	cfg.DBMaxOpenConns, err = getInt("DB_MAX_OPEN_CONNS", 25)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg.DBMaxIdleConns, err = getInt("DB_MAX_IDLE_CONNS", 10)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg.DBConnMaxLifetime, err = getDuration("DB_CONN_MAX_LIFETIME", 30*time.Minute)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg.DBConnectTimeout, err = getDuration("DB_CONNECT_TIMEOUT", 30*time.Second)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg.RequestTimeout, err = getDuration("REQUEST_TIMEOUT", 8*time.Second)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg.ShutdownTimeout, err = getDuration("SHUTDOWN_TIMEOUT", 15*time.Second)
+	if err != nil {
+		return Config{}, err
+	}
+
+	rateLimits := []struct {
+		dst *ratelimit.Limit
+		key string
+		def ratelimit.Limit
+	}{
+		{&cfg.RateLimitRead, "RATE_LIMIT_READ", ratelimit.Limit{Count: 1200, Period: time.Minute}},
+		{&cfg.RateLimitWrite, "RATE_LIMIT_WRITE", ratelimit.Limit{Count: 600, Period: time.Minute}},
+		{&cfg.RateLimitIntegration, "RATE_LIMIT_INTEGRATION", ratelimit.Limit{Count: 300, Period: time.Minute}},
+		{&cfg.RateLimitAdmin, "RATE_LIMIT_ADMIN", ratelimit.Limit{Count: 120, Period: time.Minute}},
+		{&cfg.RateLimitAuthFailure, "RATE_LIMIT_AUTH_FAILURE", ratelimit.Limit{Count: 20, Period: time.Minute}},
+	}
+	for _, rl := range rateLimits {
+		if *rl.dst, err = getRateLimit(rl.key, rl.def); err != nil {
+			return Config{}, err
+		}
+	}
+
+	cfg.APIKeyAuth, err = getBool("API_KEY_AUTH", false)
+	if err != nil {
+		return Config{}, err
+	}
+	if cfg.BootstrapAPIKey != "" && !cfg.APIKeyAuth {
+		return Config{}, errors.New("BOOTSTRAP_API_KEY requires API_KEY_AUTH")
+	}
+
+	cfg.OIDCIssuerURL = getEnv("OIDC_ISSUER_URL", "")
+	cfg.OIDCAudience = getEnv("OIDC_AUDIENCE", "")
+	cfg.OIDCJWKSURL = getEnv("OIDC_JWKS_URL", "")
+	cfg.OIDCUserClaim = getEnv("OIDC_USER_CLAIM", "sub")
+	cfg.OIDCRolesClaim = getEnv("OIDC_ROLES_CLAIM", "roles")
+	cfg.OIDCAdminRole = getEnv("OIDC_ADMIN_ROLE", "admin")
+	switch {
+	case cfg.OIDCIssuerURL == "" && (cfg.OIDCAudience != "" || cfg.OIDCJWKSURL != ""):
+		return Config{}, errors.New("OIDC_AUDIENCE and OIDC_JWKS_URL require OIDC_ISSUER_URL")
+	case cfg.OIDCIssuerURL != "" && cfg.OIDCAudience == "":
+		// Without an audience, tokens the provider issued to any other
+		// application would be accepted.
+		return Config{}, errors.New("OIDC_ISSUER_URL requires OIDC_AUDIENCE")
+	}
+	if (cfg.GitHubAppID == "") != (cfg.GitHubInstallationID == "") {
+		return Config{}, errors.New("GITHUB_APP_ID and GITHUB_INSTALLATION_ID must be set together")
+	}
+
+	maxSizeMB, err := getInt("ACCESS_LOG_MAX_SIZE_MB", 100)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.AccessLogMaxSizeMB = int64(maxSizeMB)
+
+	cfg.AccessLogMaxAge, err = getDuration("ACCESS_LOG_MAX_AGE", 24*time.Hour)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg.AccessLogMaxBackups, err = getInt("ACCESS_LOG_MAX_BACKUPS", 7)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg.ReviewerStorageVerifyInterval, err = getDuration("REVIEWER_STORAGE_VERIFY_INTERVAL", 0)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg.UsageFlushInterval, err = getDuration("USAGE_FLUSH_INTERVAL", time.Minute)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg.MergedRetentionDays, err = getInt("MERGED_PR_RETENTION_DAYS", 0)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg.ArchiveInterval, err = getDuration("ARCHIVE_INTERVAL", time.Hour)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg.AuditRetentionDays, err = getInt("AUDIT_LOG_RETENTION_DAYS", 90)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg.StatsSnapshotInterval, err = getDuration("STATS_SNAPSHOT_INTERVAL", time.Hour)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg.StatsCacheTTL, err = getDuration("STATS_CACHE_TTL", 15*time.Second)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg.EmailDigestInterval, err = getDuration("EMAIL_DIGEST_INTERVAL", 24*time.Hour)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg.OutboxDispatchInterval, err = getDuration("OUTBOX_DISPATCH_INTERVAL", 10*time.Second)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg.OutboxWorkers, err = getInt("OUTBOX_WORKERS", 4)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg.SlackMaxAttempts, err = getInt("SLACK_MAX_ATTEMPTS", 15)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg.EmailMaxAttempts, err = getInt("SMTP_MAX_ATTEMPTS", 15)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg.JiraMaxAttempts, err = getInt("JIRA_MAX_ATTEMPTS", 15)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg.GitHubMaxAttempts, err = getInt("GITHUB_MAX_ATTEMPTS", 15)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg.WebhookMaxAttempts, err = getInt("WEBHOOK_MAX_ATTEMPTS", 15)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg.WebhookAllowPrivate, err = getBool("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false)
+	if err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+// databaseURL returns DATABASE_URL or assembles a URL from the libpq
+// variables, with defaults matching the development database.
+func databaseURL() (string, error) {
+	dsn, err := getSecret("DATABASE_URL", "")
+	if err != nil || dsn != "" {
+		return dsn, err
+	}
+
+	user, err := getSecret("PGUSER", "reassv")
+	if err != nil {
+		return "", err
+	}
+	password, err := getSecret("PGPASSWORD", "reassv")
+	if err != nil {
+		return "", err
+	}
+	u := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(user, password),
+		Path:   "/" + getEnv("PGDATABASE", "reasdb"),
+	}
+	query := url.Values{"sslmode": {getEnv("PGSSLMODE", "disable")}}
+	host, port := getEnv("PGHOST", "localhost"), getEnv("PGPORT", "5432")
+	if strings.HasPrefix(host, "/") {
+		// A Unix socket directory cannot be the host part of a URL.
+		query.Set("host", host)
+		query.Set("port", port)
+	} else {
+		u.Host = net.JoinHostPort(host, port)
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// getSecret reads key, or the file named by key+"_FILE" with trailing
+// newlines removed. Setting both is an error.
+func getSecret(key, def string) (string, error) {
+	path := os.Getenv(key + "_FILE")
+	if path == "" {
+		return getEnv(key, def), nil
+	}
+	if os.Getenv(key) != "" {
+		return "", fmt.Errorf("%s and %s_FILE are mutually exclusive", key, key)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read %s_FILE: %w", key, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+func getEnv(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// getList splits a comma-separated variable, skipping empty items.
+func getList(key string) []string {
+	var items []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func getInt(key string, def int) (int, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s: %w", key, err)
+	}
+	return n, nil
+}
+
+func getBool(key string, def bool) (bool, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("parse %s: %w", key, err)
+	}
+	return b, nil
+}
+
+func getRateLimit(key string, def ratelimit.Limit) (ratelimit.Limit, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	l, err := ratelimit.ParseLimit(v)
+	if err != nil {
+		return ratelimit.Limit{}, fmt.Errorf("parse %s: %w", key, err)
+	}
+	return l, nil
+}
+
+func getDuration(key string, def time.Duration) (time.Duration, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s: %w", key, err)
+	}
+	return d, nil
+}
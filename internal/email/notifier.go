@@ -0,0 +1,117 @@
+// Package email delivers reviewer notifications and digests over SMTP.
+package email
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"review-assigner/internal/app"
+)
+
+// Channel is the outbox channel of e-mail notifications.
+const Channel = "email"
+
+// Notifier sends assignment e-mails and review digests through an SMTP
+// server.
+type Notifier struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewNotifier creates a notifier sending from the given address through the
+// SMTP server at addr (host:port). Empty username disables authentication.
+func NewNotifier(addr, from, username, password string) *Notifier {
+	n := &Notifier{addr: addr, from: from}
+	if username != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		n.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return n
+}
+
+// Channel implements app.Notifier.
+func (n *Notifier) Channel() string {
+	return Channel
+}
+
+// NotifyAssignment implements app.Notifier.
+func (n *Notifier) NotifyAssignment(_ context.Context, notice app.AssignmentNotice) error {
+	if notice.Reviewer.Email == "" || !notice.Preferences.Email {
+		return nil
+	}
+
+	pr := notice.PullRequest
+	var subject, body string
+	if notice.EventType == app.AssignmentEventReplaced {
+		subject = fmt.Sprintf("Review of %s handed over", pr.Name)
+		body = fmt.Sprintf("You no longer need to review %s (%s): it was handed over to %s.\n",
+			pr.Name, pr.ID, notice.ReplacedBy)
+	} else {
+		subject = fmt.Sprintf("Review requested: %s", pr.Name)
+		body = fmt.Sprintf("You were assigned to review %s (%s, %s priority) by %s.\n",
+			pr.Name, pr.ID, strings.ToLower(pr.Priority), pr.AuthorID)
+		if pr.Deadline != nil {
+			body += fmt.Sprintf("Deadline: %s.\n", pr.Deadline.UTC().Format(time.RFC3339))
+		}
+	}
+
+	if err := n.send(notice.Reviewer.Email, subject, body); err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+	return nil
+}
+
+// SendDigest e-mails a review digest to its user.
+func (n *Notifier) SendDigest(d app.ReviewDigest) error {
+	var b strings.Builder
+	if len(d.Overdue) > 0 {
+		b.WriteString("Overdue:\n")
+		writeReviews(&b, d.Overdue)
+		b.WriteString("\n")
+	}
+	if len(d.Reviews) > 0 {
+		b.WriteString("Waiting for your review:\n")
+		writeReviews(&b, d.Reviews)
+	}
+
+	subject := fmt.Sprintf("%d reviews waiting for you", len(d.Reviews)+len(d.Overdue))
+	if len(d.Overdue) > 0 {
+		subject += fmt.Sprintf(", %d overdue", len(d.Overdue))
+	}
+	return n.send(d.User.Email, subject, b.String())
+}
+
+func writeReviews(b *strings.Builder, prs []app.PullRequestShort) {
+	for _, pr := range prs {
+		fmt.Fprintf(b, "- %s (%s, %s priority) by %s", pr.Name, pr.ID, strings.ToLower(pr.Priority), pr.AuthorID)
+		if pr.Deadline != nil {
+			fmt.Fprintf(b, ", due %s", pr.Deadline.UTC().Format(time.RFC3339))
+		}
+		b.WriteString("\n")
+	}
+}
+
+// send delivers a plain text message.
+func (n *Notifier) send(to, subject, body string) error {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	if err := smtp.SendMail(n.addr, n.auth, n.from, []string{to}, msg.Bytes()); err != nil {
+		return fmt.Errorf("send mail: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+// Package github requests reviews and reports assigned reviewers on GitHub
+// pull requests as a GitHub App.
+package github
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBaseURL is the address of the GitHub REST API.
+const DefaultBaseURL = "https://api.github.com"
+
+// CheckRunName is the name of the check runs the client creates.
+const CheckRunName = "review-assigner"
+
+// Client calls the GitHub REST API as an installation of a GitHub App. It
+// signs a JWT with the App's private key and exchanges it for an
+// installation token, which is reused until shortly before it expires.
+type Client struct {
+	baseURL        string
+	appID          string
+	installationID string
+	key            *rsa.PrivateKey
+	client         *http.Client
+	now            func() time.Time
+
+	mu           sync.Mutex
+	token        string
+	tokenExpires time.Time
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used to call GitHub.
+func WithHTTPClient(c *http.Client) Option {
+	return func(cl *Client) {
+		cl.client = c
+	}
+}
+
+// WithBaseURL sets the API address, e.g. https://github.example.com/api/v3
+// for GitHub Enterprise Server.
+func WithBaseURL(baseURL string) Option {
+	return func(cl *Client) {
+		cl.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// NewClient creates a client for the installation of the App appID.
+// privateKey is the PEM-encoded key downloaded from the App settings.
+func NewClient(appID, installationID string, privateKey []byte, opts ...Option) (*Client, error) {
+	key, err := parsePrivateKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("github: %w", err)
+	}
+	c := &Client{
+		baseURL:        DefaultBaseURL,
+		appID:          appID,
+		installationID: installationID,
+		key:            key,
+		client:         &http.Client{Timeout: 10 * time.Second},
+		now:            time.Now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+func parsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("private key is not PEM-encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// RequestReviews implements app.CodeHost.
+func (c *Client) RequestReviews(ctx context.Context, repo string, number int, logins []string) error {
+	payload := map[string][]string{"reviewers": logins}
+	if err := c.do(ctx, http.MethodPost, pullPath(repo, number)+"/requested_reviewers", payload, nil); err != nil {
+		return fmt.Errorf("github: request reviews on %s#%d: %w", repo, number, err)
+	}
+	return nil
+}
+
+// RemoveReviewRequests implements app.CodeHost.
+func (c *Client) RemoveReviewRequests(ctx context.Context, repo string, number int, logins []string) error {
+	payload := map[string][]string{"reviewers": logins}
+	if err := c.do(ctx, http.MethodDelete, pullPath(repo, number)+"/requested_reviewers", payload, nil); err != nil {
+		return fmt.Errorf("github: remove review requests on %s#%d: %w", repo, number, err)
+	}
+	return nil
+}
+
+// ReportReviewers implements app.CodeHost with a completed check run on
+// the head commit of the pull request.
+func (c *Client) ReportReviewers(ctx context.Context, repo string, number int, title, summary string) error {
+	var pull struct {
+		Head struct {
+			SHA string `json:"sha"`
+		} `json:"head"`
+	}
+	if err := c.do(ctx, http.MethodGet, pullPath(repo, number), nil, &pull); err != nil {
+		return fmt.Errorf("github: get %s#%d: %w", repo, number, err)
+	}
+	payload := map[string]any{
+		"name":       CheckRunName,
+		"head_sha":   pull.Head.SHA,
+		"status":     "completed",
+		"conclusion": "success",
+		"output":     map[string]string{"title": title, "summary": summary},
+	}
+	if err := c.do(ctx, http.MethodPost, repoPath(repo)+"/check-runs", payload, nil); err != nil {
+		return fmt.Errorf("github: create check run on %s#%d: %w", repo, number, err)
+	}
+	return nil
+}
+
+func repoPath(repo string) string {
+	owner, name, _ := strings.Cut(repo, "/")
+	return "/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(name)
+}
+
+func pullPath(repo string, number int) string {
+	return repoPath(repo) + "/pulls/" + strconv.Itoa(number)
+}
+
+// installationToken returns a cached installation token or requests a new
+// one.
+func (c *Client) installationToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && c.now().Before(c.tokenExpires.Add(-time.Minute)) {
+		return c.token, nil
+	}
+
+	jwt, err := c.appJWT()
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	path := "/app/installations/" + url.PathEscape(c.installationID) + "/access_tokens"
+	if err := c.send(ctx, http.MethodPost, path, "Bearer "+jwt, nil, &resp); err != nil {
+		return "", fmt.Errorf("get installation token: %w", err)
+	}
+	c.token, c.tokenExpires = resp.Token, resp.ExpiresAt
+	return c.token, nil
+}
+
+// appJWT signs the short-lived token that authenticates the App itself.
+func (c *Client) appJWT() (string, error) {
+	now := c.now()
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]any{
+		// Backdated against clock drift, as GitHub recommends.
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": c.appID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("encode jwt: %w", err)
+	}
+	signed := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, c.key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", fmt.Errorf("sign jwt: %w", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// do calls the API with the installation token.
+func (c *Client) do(ctx context.Context, method, path string, payload, out any) error {
+	token, err := c.installationToken(ctx)
+	if err != nil {
+		return err
+	}
+	return c.send(ctx, method, path, "Bearer "+token, payload, out)
+}
+
+func (c *Client) send(ctx context.Context, method, path, auth string, payload, out any) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	req.Header.Set("Authorization", auth)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("decode response: %w", err)
+		}
+	}
+	return nil
+}
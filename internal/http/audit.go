@@ -0,0 +1,181 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"review-assigner/internal/app"
+)
+
+const (
+	// auditBodyLimit is how much of a request body the audit log looks at;
+	// longer bodies are not summarized.
+	auditBodyLimit = 64 << 10
+	// auditErrorLimit is how much of an error response is kept to find its
+	// error code.
+	auditErrorLimit = 4 << 10
+	// auditMaxString and auditMaxArray bound the values of a summary.
+	auditMaxString = 200
+	auditMaxArray  = 20
+	// auditWriteTimeout bounds recording an entry after the response.
+	auditWriteTimeout = 5 * time.Second
+)
+
+// withAudit records in the audit log every mutating call that passed
+// authentication and rate limits, including the failed ones. Entries are
+// written after the response, so a failure to record one is logged but does
+// not fail the call.
+func (h *Handler) withAudit(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+		_, pattern := mux.Handler(r)
+		endpoint := "unmatched"
+		if _, path, ok := strings.Cut(pattern, " "); ok {
+			endpoint = path
+		}
+
+		body := &auditBody{ReadCloser: r.Body}
+		r.Body = body
+		rec := &auditRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		entry := app.AuditEntry{
+			Client:    clientName(r),
+			UserID:    callerUserID(r),
+			Method:    r.Method,
+			Endpoint:  endpoint,
+			Path:      r.URL.Path,
+			Payload:   summarizePayload(body),
+			Status:    rec.status,
+			ErrorCode: rec.errorCode(),
+			RequestID: requestID(w),
+		}
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), auditWriteTimeout)
+		defer cancel()
+		if err := h.service.RecordAudit(ctx, entry); err != nil {
+			h.logger.Error("record audit entry", "request_id", requestID(w), "error", err)
+		}
+	})
+}
+
+// auditBody keeps the start of the request body as the handler reads it.
+type auditBody struct {
+	io.ReadCloser
+	buf       bytes.Buffer
+	truncated bool
+}
+
+func (b *auditBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if room := auditBodyLimit - b.buf.Len(); n > room {
+		b.buf.Write(p[:room])
+		b.truncated = true
+	} else {
+		b.buf.Write(p[:n])
+	}
+	return n, err
+}
+
+// auditRecorder tracks the status and keeps the start of error responses.
+type auditRecorder struct {
+	http.ResponseWriter
+	status int
+	errBuf bytes.Buffer
+}
+
+func (r *auditRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *auditRecorder) Write(p []byte) (int, error) {
+	if r.status >= http.StatusBadRequest && r.errBuf.Len() < auditErrorLimit {
+		r.errBuf.Write(p[:min(len(p), auditErrorLimit-r.errBuf.Len())])
+	}
+	return r.ResponseWriter.Write(p)
+}
+
+func (r *auditRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// errorCode is the code of an app error response, empty for others.
+func (r *auditRecorder) errorCode() string {
+	var resp errorResponse
+	if r.errBuf.Len() == 0 || json.Unmarshal(r.errBuf.Bytes(), &resp) != nil {
+		return ""
+	}
+	return resp.Error.Code
+}
+
+// summarizePayload returns the top-level fields of a JSON object body with
+// long strings cut, arrays of objects counted and secrets redacted; nil for
+// other or truncated bodies.
+func summarizePayload(body *auditBody) json.RawMessage {
+	if body.truncated || body.buf.Len() == 0 {
+		return nil
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(body.buf.Bytes(), &fields); err != nil {
+		return nil
+	}
+	for name, v := range fields {
+		fields[name] = summarizeValue(name, v)
+	}
+	summary, err := json.Marshal(fields)
+	if err != nil {
+		return nil
+	}
+	return summary
+}
+
+func summarizeValue(name string, v any) any {
+	if isSecretField(name) {
+		return "[redacted]"
+	}
+	switch v := v.(type) {
+	case string:
+		if utf8.RuneCountInString(v) > auditMaxString {
+			return string([]rune(v)[:auditMaxString]) + "…"
+		}
+		return v
+	case []any:
+		if len(v) > auditMaxArray {
+			return fmt.Sprintf("[%d items]", len(v))
+		}
+		for i, item := range v {
+			switch item.(type) {
+			case map[string]any, []any:
+				return fmt.Sprintf("[%d items]", len(v))
+			default:
+				v[i] = summarizeValue(name, item)
+			}
+		}
+		return v
+	case map[string]any:
+		return fmt.Sprintf("{%d fields}", len(v))
+	}
+	return v
+}
+
+// isSecretField reports whether a field name suggests a credential.
+func isSecretField(name string) bool {
+	name = strings.ToLower(name)
+	for _, word := range []string{"secret", "token", "password", "credential"} {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return name == "key" || strings.HasSuffix(name, "_key")
+}
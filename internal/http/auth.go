@@ -0,0 +1,244 @@
+package httpserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"review-assigner/internal/app"
+)
+
+// apiKeyHeader carries the API key of the caller.
+const apiKeyHeader = "X-API-Key"
+
+// bootstrapClient is the client name of the bootstrap key.
+const bootstrapClient = "bootstrap"
+
+// feedTokenParam carries the feed token of a user on feedOperations.
+const feedTokenParam = "token"
+
+// feedOperations are the "METHOD /path" patterns that also accept a feed
+// token in the query string: calendar apps and browser WebSockets cannot
+// send credentials in headers.
+var feedOperations = map[string]bool{
+	"GET /users/{id}/reviews.ics": true,
+	"GET /ws":                     true,
+}
+
+// WithAPIKeyAuth requires a valid X-API-Key on every call except the
+// operations documented as public: probes, metrics, docs and the endpoints
+// with their own authentication. bootstrapKey, when set, is accepted as
+// the key of the "bootstrap" client, to issue the first keys.
+func WithAPIKeyAuth(bootstrapKey string) Option {
+	return func(h *Handler) {
+		h.apiKeyAuth = true
+		h.bootstrapKey = bootstrapKey
+	}
+}
+
+// caller is the identity authentication establishes for a request.
+// withRequestLog puts an empty one in the context before the handlers run,
+// so that the request line reports what authentication filled in.
+type caller struct {
+	// client is the client name of the API key; empty when the request
+	// was not authenticated by a key.
+	client   string
+	apiKeyID int64
+	// scopes limit a caller authenticated by a scoped API key; nil
+	// otherwise.
+	scopes []string
+	// userID and roles come from the claims of a Bearer token; empty when
+	// the request was not authenticated by one.
+	userID string
+	roles  []string
+	// admin is set for callers allowed to use the /admin endpoints: API
+	// keys without scopes, which belong to services, and tokens with the
+	// admin role.
+	admin bool
+}
+
+type callerKey struct{}
+
+func contextWithCaller(ctx context.Context) context.Context {
+	return context.WithValue(ctx, callerKey{}, &caller{})
+}
+
+// callerOf returns the caller of the request, nil outside withRequestLog.
+func callerOf(r *http.Request) *caller {
+	c, _ := r.Context().Value(callerKey{}).(*caller)
+	return c
+}
+
+// publicOperations are the "METHOD /path" patterns of operations that need
+// no credentials.
+var publicOperations = sync.OnceValue(func() map[string]bool {
+	ops := make(map[string]bool)
+	for _, op := range apiOperations {
+		if op.Public {
+			ops[op.Method+" "+op.Path] = true
+		}
+	}
+	return ops
+})
+
+// withAuth authenticates calls by their X-API-Key when WithAPIKeyAuth is
+// set, or by their Bearer token when WithOIDC is. Members authenticated by
+// a token without the admin role, and scoped keys, are refused the /admin
+// endpoints; scoped keys also the operations outside their scopes, and
+// members the webhooks and the writes of teams and users except
+// selfServiceOperations.
+func (h *Handler) withAuth(mux *http.ServeMux, next http.Handler) http.Handler {
+	if !h.apiKeyAuth && h.oidc == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pattern := mux.Handler(r)
+		if publicOperations()[pattern] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		c, err := h.authenticate(r, pattern)
+		if err != nil {
+			h.writeAppError(w, err)
+			return
+		}
+		if dst := callerOf(r); dst != nil {
+			*dst = c
+		}
+		if strings.HasPrefix(r.URL.Path, "/admin/") && !c.admin {
+			h.writeAppError(w, &app.Error{Code: app.ErrorCodeForbidden, Message: "admin access required"})
+			return
+		}
+		scope := requiredScopes()[pattern]
+		if c.scopes != nil && scope != "" && !slices.Contains(c.scopes, scope) {
+			h.writeAppError(w, &app.Error{Code: app.ErrorCodeForbidden, Message: "API key lacks scope " + scope})
+			return
+		}
+		if c.userID != "" && !c.admin && (scope == app.ScopeWebhooksManage ||
+			scope == app.ScopeTeamsWrite && !selfServiceOperations[pattern]) {
+			h.writeAppError(w, &app.Error{Code: app.ErrorCodeForbidden, Message: "admin access required"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// selfServiceOperations are the writes of users that members authenticated
+// by a token may call on themselves, see authorizeSelf. Their other writes
+// of teams and users need the admin role, so that a member cannot, say,
+// make themselves a lead.
+var selfServiceOperations = map[string]bool{
+	"POST /users/setIsActive":   true,
+	"POST /users/updateProfile": true,
+	"POST /users/notifications": true,
+	"POST /users/feedToken":     true,
+}
+
+// requiredScopes maps "METHOD /path" patterns to the API key scope they
+// need. Reads of teams, users and pull requests need none.
+var requiredScopes = sync.OnceValue(func() map[string]string {
+	scopes := make(map[string]string)
+	for _, op := range apiOperations {
+		var scope string
+		switch {
+		case strings.HasPrefix(op.Path, "/stats/"):
+			scope = app.ScopeStatsRead
+		case strings.HasPrefix(op.Path, "/webhooks/"):
+			scope = app.ScopeWebhooksManage
+		case op.Method == http.MethodGet, op.Path == "/users/getReviewBatch":
+			// The POST form of getReviewBatch only reads, too.
+			continue
+		case strings.HasPrefix(op.Path, "/pullRequest/"), strings.HasPrefix(op.Path, "/events/"):
+			scope = app.ScopePRsWrite
+		case strings.HasPrefix(op.Path, "/team/"), strings.HasPrefix(op.Path, "/org/"),
+			strings.HasPrefix(op.Path, "/users/"):
+			scope = app.ScopeTeamsWrite
+		default:
+			continue
+		}
+		scopes[op.Method+" "+op.Path] = scope
+	}
+	return scopes
+})
+
+// authenticate establishes the caller of an operation from the credentials
+// the server accepts. A feed token authenticates its user like a Bearer
+// token without roles.
+func (h *Handler) authenticate(r *http.Request, pattern string) (caller, error) {
+	if token, ok := bearerToken(r); ok && h.oidc != nil {
+		return h.authenticateToken(r.Context(), token)
+	}
+	secret := r.Header.Get(apiKeyHeader)
+	if token := r.URL.Query().Get(feedTokenParam); secret == "" && token != "" && feedOperations[pattern] {
+		userID, err := h.service.AuthenticateFeedToken(r.Context(), token)
+		if err != nil {
+			return caller{}, err
+		}
+		return caller{userID: userID}, nil
+	}
+	if secret == "" || !h.apiKeyAuth {
+		return caller{}, &app.Error{Code: app.ErrorCodeUnauthorized, Message: h.missingCredentials()}
+	}
+	if h.bootstrapKey != "" && subtle.ConstantTimeCompare([]byte(secret), []byte(h.bootstrapKey)) == 1 {
+		return caller{client: bootstrapClient, admin: true}, nil
+	}
+	key, err := h.service.AuthenticateAPIKey(r.Context(), secret)
+	if err != nil {
+		return caller{}, err
+	}
+	return caller{client: key.ClientName, apiKeyID: key.ID, scopes: key.Scopes, admin: key.Scopes == nil}, nil
+}
+
+func (h *Handler) missingCredentials() string {
+	switch {
+	case h.apiKeyAuth && h.oidc != nil:
+		return "missing " + apiKeyHeader + " header or Bearer token"
+	case h.oidc != nil:
+		return "missing Bearer token"
+	}
+	return "missing " + apiKeyHeader + " header"
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// authorizeSelf allows a member authenticated by a Bearer token to act only
+// on their own user_id, unless they are an admin or lead the user's team.
+// Calls with API keys, or without authentication, are not restricted.
+func (h *Handler) authorizeSelf(r *http.Request, userID string) error {
+	c := callerOf(r)
+	if c == nil || c.userID == "" || c.admin || c.userID == userID {
+		return nil
+	}
+	leads, err := h.service.LeadsTeamOf(r.Context(), c.userID, userID)
+	if err != nil {
+		return err
+	}
+	if !leads {
+		return &app.Error{Code: app.ErrorCodeForbidden, Message: "members may only act on themselves"}
+	}
+	return nil
+}
+
+// authorizeAdmin allows only admins, when authentication is enabled, to use
+// the admin options of operations open to everyone.
+func (h *Handler) authorizeAdmin(r *http.Request) error {
+	if !h.apiKeyAuth && h.oidc == nil {
+		return nil
+	}
+	if c := callerOf(r); c == nil || !c.admin {
+		return &app.Error{Code: app.ErrorCodeForbidden, Message: "admin access required"}
+	}
+	return nil
+}
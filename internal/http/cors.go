@@ -0,0 +1,158 @@
+package httpserver
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// corsAllowedHeaders are the request headers browsers may send cross-origin.
+var corsAllowedHeaders = []string{"Content-Type", clientHeader, "Idempotency-Key", "If-None-Match", requestIDHeader,
+	apiKeyHeader, "Authorization"}
+
+// corsExposedHeaders are the response headers browser scripts may read.
+var corsExposedHeaders = []string{"ETag", requestIDHeader}
+
+// corsMaxAge is how long browsers may cache a preflight response.
+const corsMaxAge = 10 * 60
+
+// methodOrder is the order in which methods are listed in Allow headers.
+var methodOrder = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete}
+
+// routeMethods lists the methods of apiOperations in methodOrder, HEAD
+// included because every GET route serves it.
+var routeMethods = sync.OnceValue(func() []string {
+	used := map[string]bool{}
+	for _, op := range apiOperations {
+		used[op.Method] = true
+		if op.Method == http.MethodGet {
+			used[http.MethodHead] = true
+		}
+	}
+	var methods []string
+	for _, method := range methodOrder {
+		if used[method] {
+			methods = append(methods, method)
+		}
+	}
+	return methods
+})
+
+// operationMethods maps the path of every API operation to the methods it
+// is registered with, HEAD included for GET, in the order of routeMethods.
+var operationMethods = sync.OnceValue(func() map[string][]string {
+	registered := make(map[string]map[string]bool)
+	for _, op := range apiOperations {
+		if registered[op.Path] == nil {
+			registered[op.Path] = make(map[string]bool)
+		}
+		registered[op.Path][op.Method] = true
+		if op.Method == http.MethodGet {
+			registered[op.Path][http.MethodHead] = true
+		}
+	}
+	methods := make(map[string][]string, len(registered))
+	for path, set := range registered {
+		for _, method := range routeMethods() {
+			if set[method] {
+				methods[path] = append(methods[path], method)
+			}
+		}
+	}
+	return methods
+})
+
+// rejectOperationPath answers 405 Method Not Allowed when a path-parameter
+// route receives the path of another operation, e.g. GET /pullRequest/create,
+// which would otherwise be taken for a pull request ID. It reports whether
+// it answered.
+func rejectOperationPath(w http.ResponseWriter, r *http.Request) bool {
+	methods := operationMethods()[r.URL.Path]
+	if len(methods) == 0 {
+		return false
+	}
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	return true
+}
+
+// Option configures the HTTP handler.
+type Option func(*Handler)
+
+// WithCORSOrigins allows browser calls from the given origins. "*" allows
+// any origin; no origins (the default) disables CORS headers.
+func WithCORSOrigins(origins []string) Option {
+	return func(h *Handler) {
+		h.corsOrigins = make(map[string]bool, len(origins))
+		for _, origin := range origins {
+			h.corsOrigins[strings.TrimRight(origin, "/")] = true
+		}
+	}
+}
+
+// allowOrigin returns the Access-Control-Allow-Origin value for a request
+// origin, or "" when the origin is not allowed.
+func (h *Handler) allowOrigin(origin string) string {
+	switch {
+	case origin == "":
+		return ""
+	case h.corsOrigins["*"]:
+		return "*"
+	case h.corsOrigins[origin]:
+		return origin
+	}
+	return ""
+}
+
+// withCORS answers OPTIONS requests for every route, including CORS
+// preflights, and adds CORS headers to responses for allowed origins.
+func (h *Handler) withCORS(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowOrigin := h.allowOrigin(r.Header.Get("Origin"))
+		if allowOrigin != "" {
+			w.Header().Set("Access-Control-Allow-Origin", allowOrigin)
+			w.Header().Add("Vary", "Origin")
+			w.Header().Set("Access-Control-Expose-Headers", strings.Join(corsExposedHeaders, ", "))
+		}
+
+		if r.Method != http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		methods := allowedMethods(mux, r)
+		if len(methods) == 0 {
+			http.NotFound(w, r)
+			return
+		}
+		allow := strings.Join(append(methods, http.MethodOptions), ", ")
+		w.Header().Set("Allow", allow)
+		if allowOrigin != "" && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", allow)
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(corsAllowedHeaders, ", "))
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(corsMaxAge))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// allowedMethods lists the methods a route accepts by asking the mux which
+// of them it would route. The path of an operation only accepts that
+// operation's methods, even where a path-parameter route also matches it.
+func allowedMethods(mux *http.ServeMux, r *http.Request) []string {
+	operation, isOperation := operationMethods()[r.URL.Path]
+	var methods []string
+	for _, method := range routeMethods() {
+		if isOperation && !slices.Contains(operation, method) {
+			continue
+		}
+		probe := &http.Request{Method: method, URL: r.URL, Host: r.Host, Header: http.Header{}}
+		if _, pattern := mux.Handler(probe); pattern != "" {
+			methods = append(methods, method)
+		}
+	}
+	return methods
+}
@@ -0,0 +1,60 @@
+package httpserver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// writeJSONWithETag writes value like writeJSON with status 200 and a weak
+// ETag of the body. A client that already has the body (If-None-Match) gets
+// 304 Not Modified instead, so polling does not re-download it.
+func writeJSONWithETag(w http.ResponseWriter, r *http.Request, value any) {
+	writeJSONWithContentETag(w, r, value, nil)
+}
+
+// writeJSONWithContentETag is writeJSONWithETag with the ETag computed from
+// content instead of the body, for bodies with fields, such as the time they
+// were computed, that change while the data stays the same. A nil content
+// means the body itself.
+func writeJSONWithContentETag(w http.ResponseWriter, r *http.Request, value, content any) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		writeJSON(w, http.StatusOK, value)
+		return
+	}
+	tagged := body
+	if content != nil {
+		if tagged, err = json.Marshal(content); err != nil {
+			writeJSON(w, http.StatusOK, value)
+			return
+		}
+	}
+	sum := sha256.Sum256(tagged)
+	etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	// Unlike other API responses, these may be kept and revalidated.
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(append(body, '\n'))
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, using the
+// weak comparison RFC 9110 requires for this header.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
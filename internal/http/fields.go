@@ -0,0 +1,81 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// parseFields reads the fields parameter of a list endpoint: JSON field names
+// of item to keep in every list entry, so mobile and CLI clients can trim
+// payloads. Nil means all fields.
+func parseFields(q url.Values, item any) ([]string, error) {
+	v := q.Get("fields")
+	if v == "" {
+		return nil, nil
+	}
+
+	known := make(map[string]bool)
+	jsonFieldNames(reflect.TypeOf(item), known)
+	var fields []string
+	for _, field := range strings.Split(v, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !known[field] {
+			return nil, fmt.Errorf("fields must name fields of the listed entries, unknown %q", field)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// jsonFieldNames collects the JSON names of a struct's fields, following
+// embedded structs the way encoding/json does.
+func jsonFieldNames(t reflect.Type, names map[string]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		switch {
+		case tag == "-":
+		case f.Anonymous && tag == "" && f.Type.Kind() == reflect.Struct:
+			jsonFieldNames(f.Type, names)
+		case !f.IsExported():
+		case tag != "":
+			names[tag] = true
+		default:
+			names[f.Name] = true
+		}
+	}
+}
+
+// selectFields trims every item to the given JSON fields. Fields an item
+// omits (omitempty) stay absent.
+func selectFields[T any](items []T, fields []string) any {
+	if fields == nil {
+		return items
+	}
+
+	trimmed := make([]map[string]json.RawMessage, 0, len(items))
+	for _, item := range items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return items
+		}
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(data, &all); err != nil {
+			return items
+		}
+		picked := make(map[string]json.RawMessage, len(fields))
+		for _, field := range fields {
+			if v, ok := all[field]; ok {
+				picked[field] = v
+			}
+		}
+		trimmed = append(trimmed, picked)
+	}
+	return trimmed
+}
@@ -1,36 +1,167 @@
 package httpserver
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"review-assigner/internal/app"
+	"review-assigner/internal/ratelimit"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Handler routes HTTP requests to the application service.
 type Handler struct {
 	service *app.Service
+	metrics *metrics
+	// corsOrigins are the browser origins allowed to call the API.
+	corsOrigins map[string]bool
+	// slackSigningSecret verifies Slack commands; empty disables them.
+	slackSigningSecret string
+	// scimToken authenticates directory provisioning; empty disables SCIM.
+	scimToken string
+	logger    *slog.Logger
+	// accessLog receives an entry per request; nil disables it.
+	accessLog *slog.Logger
+	// apiKeyAuth requires API keys; bootstrapKey is accepted besides the
+	// issued ones. See WithAPIKeyAuth.
+	apiKeyAuth   bool
+	bootstrapKey string
+	// oidc authenticates Bearer tokens; nil disables them. See WithOIDC.
+	oidc *OIDCConfig
+	// rateLimiters limit calls per client by route class with rateLimits,
+	// unless the runtime settings override them; see WithRateLimits.
+	rateLimiters map[RouteClass]*ratelimit.Limiter
+	rateLimits   map[RouteClass]ratelimit.Limit
+	// shutdown is closed when the server stops; see WithShutdown.
+	shutdown <-chan struct{}
+	// requestTimeout is the deadline of each request; zero disables it.
+	requestTimeout time.Duration
 }
 
 // NewHandler creates a new HTTP handler for the provided service.
-func NewHandler(service *app.Service) http.Handler {
-	h := &Handler{service: service}
+func NewHandler(service *app.Service, opts ...Option) http.Handler {
+	h := &Handler{service: service, logger: slog.Default()}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.metrics = newMetrics(service, h.logger)
 	mux := http.NewServeMux()
-	mux.HandleFunc("/team/add", h.handleTeamAdd)
-	mux.HandleFunc("/team/get", h.handleTeamGet)
-	mux.HandleFunc("/team/deactivateMembers", h.handleTeamDeactivateMembers)
-	mux.HandleFunc("/users/setIsActive", h.handleUserSetIsActive)
-	mux.HandleFunc("/users/getReview", h.handleUserGetReview)
-	mux.HandleFunc("/pullRequest/create", h.handlePullRequestCreate)
-	mux.HandleFunc("/pullRequest/merge", h.handlePullRequestMerge)
-	mux.HandleFunc("/pullRequest/reassign", h.handlePullRequestReassign)
-	mux.HandleFunc("/stats/assignments", h.handleStatsAssignments)
-	return mux
+	mux.HandleFunc("POST /team/add", h.handleTeamAdd)
+	mux.HandleFunc("GET /team/get", h.handleTeamGet)
+	mux.HandleFunc("GET /team/{name}", h.handleTeamGet)
+	mux.HandleFunc("GET /team/list", h.handleTeamList)
+	mux.HandleFunc("GET /team/history", h.handleTeamHistory)
+	mux.HandleFunc("GET /team/capacity", h.handleTeamCapacity)
+	mux.HandleFunc("POST /team/import", h.handleTeamImport)
+	mux.HandleFunc("POST /team/deactivateMembers", h.handleTeamDeactivateMembers)
+	mux.HandleFunc("GET /team/settings", h.handleTeamSettingsGet)
+	mux.HandleFunc("POST /team/settings", h.handleTeamSettingsUpdate)
+	mux.HandleFunc("POST /team/delete", h.handleTeamDelete)
+	mux.HandleFunc("POST /team/rename", h.handleTeamRename)
+	mux.HandleFunc("POST /org/create", h.handleOrgCreate)
+	mux.HandleFunc("GET /org/get", h.handleOrgGet)
+	mux.HandleFunc("POST /org/setTeam", h.handleOrgSetTeam)
+	mux.HandleFunc("POST /users/create", h.handleUserCreate)
+	mux.HandleFunc("GET /users/get", h.handleUserGet)
+	mux.HandleFunc("GET /users/list", h.handleUserList)
+	mux.HandleFunc("POST /users/setIsActive", h.handleUserSetIsActive)
+	mux.HandleFunc("POST /users/setRole", h.handleUserSetRole)
+	mux.HandleFunc("POST /users/updateProfile", h.handleUserUpdateProfile)
+	mux.HandleFunc("POST /users/delete", h.handleUserDelete)
+	mux.HandleFunc("GET /users/notifications", h.handleUserNotificationsGet)
+	mux.HandleFunc("POST /users/notifications", h.handleUserNotificationsUpdate)
+	mux.HandleFunc("GET /users/getReview", h.handleUserGetReview)
+	mux.HandleFunc("GET /users/getReviewBatch", h.handleUserGetReviewBatch)
+	mux.HandleFunc("POST /users/getReviewBatch", h.handleUserPostReviewBatch)
+	mux.HandleFunc("GET /users/{id}/reviews.ics", h.handleUserReviewsICal)
+	mux.HandleFunc("POST /users/feedToken", h.handleUserFeedToken)
+	mux.HandleFunc("POST /pullRequest/create", h.handlePullRequestCreate)
+	mux.HandleFunc("POST /pullRequest/update", h.handlePullRequestUpdate)
+	mux.HandleFunc("POST /pullRequest/merge", h.handlePullRequestMerge)
+	mux.HandleFunc("POST /pullRequest/delete", h.handlePullRequestDelete)
+	mux.HandleFunc("POST /pullRequest/reassign", h.handlePullRequestReassign)
+	mux.HandleFunc("POST /pullRequest/approve", h.handlePullRequestApprove)
+	mux.HandleFunc("POST /pullRequest/decline", h.handlePullRequestDecline)
+	mux.HandleFunc("GET /pullRequest/list", h.handlePullRequestList)
+	mux.HandleFunc("GET /pullRequest/history", h.handlePullRequestHistory)
+	mux.HandleFunc("GET /pullRequest/underassigned", h.handlePullRequestUnderassigned)
+	mux.HandleFunc("GET /pullRequest/{id}", h.handlePullRequestGet)
+	mux.HandleFunc("POST /events/ingest", h.handleEventIngest)
+	mux.HandleFunc("POST /webhooks/create", h.handleWebhookCreate)
+	mux.HandleFunc("GET /webhooks/list", h.handleWebhookList)
+	mux.HandleFunc("POST /webhooks/delete", h.handleWebhookDelete)
+	mux.HandleFunc("POST /webhooks/test", h.handleWebhookTest)
+	mux.HandleFunc("GET /search", h.handleSearch)
+	mux.HandleFunc("GET /ws", h.handleQueueSocket)
+	mux.HandleFunc("GET /stats/assignments", h.handleStatsAssignments)
+	mux.HandleFunc("GET /stats/latency", h.handleStatsLatency)
+	mux.HandleFunc("GET /stats/history", h.handleStatsHistory)
+	mux.HandleFunc("GET /stats/reviewMatrix", h.handleStatsReviewMatrix)
+	mux.HandleFunc("GET /stats/pullRequest", h.handleStatsPullRequest)
+	mux.HandleFunc("GET /stats/sla", h.handleStatsSLA)
+	mux.HandleFunc("GET /stats/velocity", h.handleStatsVelocity)
+	mux.HandleFunc("GET /stats/forecast", h.handleStatsForecast)
+	mux.HandleFunc("GET /stats/strategies", h.handleStatsStrategies)
+	mux.HandleFunc("GET /stats/stale", h.handleStatsStale)
+	mux.HandleFunc("POST /stats/refresh", h.handleStatsRefresh)
+	mux.HandleFunc("GET /admin/reviewerStorage", h.handleAdminReviewerStorage)
+	mux.HandleFunc("POST /admin/reviewerStorage/backfill", h.handleAdminReviewerStorageBackfill)
+	mux.HandleFunc("GET /admin/usage", h.handleAdminUsage)
+	mux.HandleFunc("GET /admin/archive", h.handleAdminArchiveStatus)
+	mux.HandleFunc("POST /admin/archive", h.handleAdminArchiveRun)
+	mux.HandleFunc("POST /admin/statsSnapshot", h.handleAdminStatsSnapshot)
+	mux.HandleFunc("GET /admin/jobs", h.handleAdminJobs)
+	mux.HandleFunc("GET /admin/settings", h.handleAdminSettingsGet)
+	mux.HandleFunc("POST /admin/settings", h.handleAdminSettingsUpdate)
+	mux.HandleFunc("GET /admin/notifications/failures", h.handleAdminNotificationFailures)
+	mux.HandleFunc("POST /admin/notifications/failures/{id}/replay", h.handleAdminNotificationReplay)
+	mux.HandleFunc("GET /admin/apiKeys", h.handleAdminAPIKeyList)
+	mux.HandleFunc("POST /admin/apiKeys", h.handleAdminAPIKeyIssue)
+	mux.HandleFunc("POST /admin/apiKeys/{id}/revoke", h.handleAdminAPIKeyRevoke)
+	mux.HandleFunc("GET /admin/audit", h.handleAdminAudit)
+	mux.HandleFunc("GET /admin/credentials", h.handleAdminCredentialList)
+	mux.HandleFunc("POST /admin/credentials", h.handleAdminCredentialSet)
+	mux.HandleFunc("POST /admin/credentials/{name}/delete", h.handleAdminCredentialDelete)
+	if h.slackSigningSecret != "" {
+		mux.HandleFunc("POST /integrations/slack/command", h.handleSlackCommand)
+	}
+	if h.scimToken != "" {
+		mux.HandleFunc("GET /scim/v2/ServiceProviderConfig", h.withSCIMAuth(h.handleSCIMServiceProviderConfig))
+		mux.HandleFunc("GET /scim/v2/Users", h.withSCIMAuth(h.handleSCIMUserList))
+		mux.HandleFunc("POST /scim/v2/Users", h.withSCIMAuth(h.handleSCIMUserCreate))
+		mux.HandleFunc("GET /scim/v2/Users/{id}", h.withSCIMAuth(h.handleSCIMUserGet))
+		mux.HandleFunc("PUT /scim/v2/Users/{id}", h.withSCIMAuth(h.handleSCIMUserReplace))
+		mux.HandleFunc("PATCH /scim/v2/Users/{id}", h.withSCIMAuth(h.handleSCIMUserPatch))
+		mux.HandleFunc("DELETE /scim/v2/Users/{id}", h.withSCIMAuth(h.handleSCIMUserDelete))
+		mux.HandleFunc("GET /scim/v2/Groups", h.withSCIMAuth(h.handleSCIMGroupList))
+		mux.HandleFunc("POST /scim/v2/Groups", h.withSCIMAuth(h.handleSCIMGroupCreate))
+		mux.HandleFunc("GET /scim/v2/Groups/{id}", h.withSCIMAuth(h.handleSCIMGroupGet))
+		mux.HandleFunc("PUT /scim/v2/Groups/{id}", h.withSCIMAuth(h.handleSCIMGroupReplace))
+		mux.HandleFunc("PATCH /scim/v2/Groups/{id}", h.withSCIMAuth(h.handleSCIMGroupPatch))
+		mux.HandleFunc("DELETE /scim/v2/Groups/{id}", h.withSCIMAuth(h.handleSCIMGroupDelete))
+	}
+	mux.HandleFunc("GET /healthz", h.handleHealthz)
+	mux.HandleFunc("GET /readyz", h.handleReadyz)
+	mux.Handle("GET /metrics", h.metrics.handler())
+	mux.HandleFunc("GET /openapi.json", h.handleOpenAPI)
+	mux.HandleFunc("GET /docs", h.handleDocs)
+	return withRequestID(withSecurityHeaders(h.withRequestLog(h.withUsage(mux, h.withCORS(mux, h.withTimeout(
+		h.withAuthFailureLimit(h.withAuth(mux, h.withRateLimit(mux, h.withJSONContentType(mux, h.withAudit(mux, mux)))))))))))
 }
 
 type errorBody struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
+	Code    string           `json:"code"`
+	Message string           `json:"message"`
+	Fields  []app.FieldError `json:"fields,omitempty"`
+	// RequestID identifies the request in the service logs.
+	RequestID string `json:"request_id"`
 }
 
 type errorResponse struct {
@@ -43,26 +174,156 @@ func writeJSON(w http.ResponseWriter, status int, value any) {
 	_ = json.NewEncoder(w).Encode(value)
 }
 
+// errEmptyBody is returned by decodeJSON for a request without a body.
+var errEmptyBody = &app.Error{Code: app.ErrorCodeValidation, Message: "request body is empty"}
+
+// decodeJSON reads exactly one JSON value into dst. Unknown fields and data
+// after the value are rejected, so a misspelled field fails with its own name
+// instead of looking like a missing one. Errors are VALIDATION *app.Error.
+func decodeJSON(body io.Reader, dst any) error {
+	dec := json.NewDecoder(body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.Is(err, io.EOF):
+			return errEmptyBody
+		case errors.As(err, &typeErr) && typeErr.Field != "":
+			return invalidField(typeErr.Field, fmt.Sprintf("%s must not be a JSON %s", typeErr.Field, typeErr.Value))
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			field, unquoteErr := strconv.Unquote(strings.TrimPrefix(err.Error(), "json: unknown field "))
+			if unquoteErr == nil {
+				return invalidField(field, "unknown field "+field)
+			}
+		}
+		return &app.Error{Code: app.ErrorCodeValidation, Message: "invalid JSON"}
+	}
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		return &app.Error{Code: app.ErrorCodeValidation, Message: "unexpected data after JSON body"}
+	}
+	return nil
+}
+
+func invalidField(field, message string) *app.Error {
+	return &app.Error{
+		Code:    app.ErrorCodeValidation,
+		Message: message,
+		Fields:  []app.FieldError{{Field: field, Message: message}},
+	}
+}
+
 func (h *Handler) writeAppError(w http.ResponseWriter, err error) {
 	var appErr *app.Error
 	if errors.As(err, &appErr) {
-		status := http.StatusInternalServerError
-		switch appErr.Code {
-		case app.ErrorCodeTeamExists:
-			status = http.StatusBadRequest
-		case app.ErrorCodePRExists, app.ErrorCodePRMerged, app.ErrorCodeNoCandidate, app.ErrorCodeNotAssigned:
-			status = http.StatusConflict
-		case app.ErrorCodeNotFound:
-			status = http.StatusNotFound
-		}
-		writeJSON(w, status, errorResponse{
+		h.metrics.appErrors.WithLabelValues(string(appErr.Code)).Inc()
+		writeJSON(w, appErrorStatus(appErr.Code), errorResponse{
 			Error: errorBody{
-				Code:    string(appErr.Code),
-				Message: appErr.Message,
+				Code:      string(appErr.Code),
+				Message:   appErr.Message,
+				Fields:    appErr.Fields,
+				RequestID: requestID(w),
 			},
 		})
 		return
 	}
 
-	http.Error(w, "internal error", http.StatusInternalServerError)
+	if app.IsTimeout(err) {
+		h.metrics.appErrors.WithLabelValues(string(app.ErrorCodeTimeout)).Inc()
+		h.logger.Warn("request timed out", "request_id", requestID(w), "error", err)
+		writeJSON(w, http.StatusGatewayTimeout, errorResponse{
+			Error: errorBody{Code: string(app.ErrorCodeTimeout), Message: "request timed out", RequestID: requestID(w)},
+		})
+		return
+	}
+
+	h.logger.Error("internal error", "request_id", requestID(w), "error", err)
+	writeJSON(w, http.StatusInternalServerError, errorResponse{
+		Error: errorBody{Code: string(app.ErrorCodeInternal), Message: "internal error", RequestID: requestID(w)},
+	})
+}
+
+// appErrorStatus is the HTTP status of an app error code.
+func appErrorStatus(code app.ErrorCode) int {
+	switch code {
+	case app.ErrorCodeTeamExists, app.ErrorCodeValidation:
+		return http.StatusBadRequest
+	case app.ErrorCodePRExists, app.ErrorCodePRMerged, app.ErrorCodeNoCandidate, app.ErrorCodeNotAssigned,
+		app.ErrorCodeIdempotencyKeyReused, app.ErrorCodeSecurityReviewRequired,
+		app.ErrorCodeNotEnoughReviewers, app.ErrorCodeTeamHasOpenPRs, app.ErrorCodeUserExists,
+		app.ErrorCodeOrgExists, app.ErrorCodeNotEnoughApprovals, app.ErrorCodeUserInOtherTeam,
+		app.ErrorCodeEventInProgress:
+		return http.StatusConflict
+	case app.ErrorCodeNotFound:
+		return http.StatusNotFound
+	case app.ErrorCodeUnauthorized:
+		return http.StatusUnauthorized
+	case app.ErrorCodeForbidden:
+		return http.StatusForbidden
+	case app.ErrorCodeRateLimited:
+		return http.StatusTooManyRequests
+	case app.ErrorCodeUnsupportedMediaType:
+		return http.StatusUnsupportedMediaType
+	}
+	return http.StatusInternalServerError
+}
+
+// writeValidationError reports a malformed request in the same envelope as
+// app errors. A non-empty field is also listed in the fields details.
+func (h *Handler) writeValidationError(w http.ResponseWriter, field, message string) {
+	if field != "" {
+		h.writeAppError(w, invalidField(field, message))
+		return
+	}
+	h.writeAppError(w, &app.Error{Code: app.ErrorCodeValidation, Message: message})
+}
+
+const maxPageLimit = 1000
+
+// parsePage reads optional limit and offset query parameters. The error
+// message is meant for the client.
+func parsePage(q url.Values) (app.Page, error) {
+	var page app.Page
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageLimit {
+			return app.Page{}, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+		}
+		page.Limit = n
+	}
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return app.Page{}, errors.New("offset must be a non-negative integer")
+		}
+		page.Offset = n
+	}
+	return page, nil
+}
+
+// parseCursorPage reads limit together with either offset or the opaque
+// cursor returned as next_cursor by the previous page.
+func parseCursorPage(q url.Values) (app.Page, error) {
+	page, err := parsePage(q)
+	if err != nil {
+		return app.Page{}, err
+	}
+	if v := q.Get("cursor"); v != "" {
+		if q.Get("offset") != "" {
+			return app.Page{}, errors.New("cursor and offset cannot be combined")
+		}
+		key, err := base64.RawURLEncoding.DecodeString(v)
+		if err != nil || len(key) == 0 {
+			return app.Page{}, errors.New("cursor is invalid")
+		}
+		page.After = string(key)
+	}
+	return page, nil
+}
+
+// nextCursor encodes the cursor key of the next page, or returns nil on the last page.
+func nextCursor(key string) any {
+	if key == "" {
+		return nil
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(key))
 }
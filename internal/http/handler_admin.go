@@ -0,0 +1,246 @@
+package httpserver
+
+import (
+	"errors"
+	"net/http"
+	"review-assigner/internal/app"
+	"strconv"
+	"time"
+)
+
+type archiveRequest struct {
+	OlderThanDays *int `json:"older_than_days"`
+}
+
+func (h *Handler) handleAdminReviewerStorage(w http.ResponseWriter, r *http.Request) {
+	report, err := h.service.VerifyReviewerStorage(r.Context(), false)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+func (h *Handler) handleAdminReviewerStorageBackfill(w http.ResponseWriter, r *http.Request) {
+	report, err := h.service.VerifyReviewerStorage(r.Context(), true)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+func (h *Handler) handleAdminUsage(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := app.UsageFilter{
+		Endpoint: q.Get("endpoint"),
+		Client:   q.Get("client"),
+	}
+
+	var err error
+	if filter.From, err = parseTimeParam(q.Get("from")); err != nil {
+		h.writeValidationError(w, "from", "from must be an RFC 3339 timestamp")
+		return
+	}
+	if filter.To, err = parseTimeParam(q.Get("to")); err != nil {
+		h.writeValidationError(w, "to", "to must be an RFC 3339 timestamp")
+		return
+	}
+
+	usage, err := h.service.GetAPIUsage(r.Context(), filter)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"usage": usage,
+	})
+}
+
+func (h *Handler) handleAdminAudit(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, err := parseCursorPage(q)
+	if err != nil {
+		h.writeValidationError(w, "", err.Error())
+		return
+	}
+	filter := app.AuditFilter{
+		Actor:    q.Get("actor"),
+		Endpoint: q.Get("endpoint"),
+		Result:   q.Get("result"),
+	}
+	if filter.From, err = parseTimeParam(q.Get("from")); err != nil {
+		h.writeValidationError(w, "from", "from must be an RFC 3339 timestamp")
+		return
+	}
+	if filter.To, err = parseTimeParam(q.Get("to")); err != nil {
+		h.writeValidationError(w, "to", "to must be an RFC 3339 timestamp")
+		return
+	}
+
+	entries, next, err := h.service.ListAudit(r.Context(), filter, page)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"entries":     entries,
+		"next_cursor": nextCursor(next),
+	})
+}
+
+func (h *Handler) handleAdminArchiveStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := h.service.GetArchiveStatus(r.Context())
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (h *Handler) handleAdminArchiveRun(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+	}()
+
+	// The body is optional: without older_than_days the configured retention is used.
+	var req archiveRequest
+	if err := decodeJSON(r.Body, &req); err != nil && !errors.Is(err, errEmptyBody) {
+		h.writeAppError(w, err)
+		return
+	}
+
+	olderThan := h.service.MergedRetention()
+	if req.OlderThanDays != nil {
+		if *req.OlderThanDays < 1 {
+			h.writeValidationError(w, "older_than_days", "older_than_days must be positive")
+			return
+		}
+		olderThan = time.Duration(*req.OlderThanDays) * 24 * time.Hour
+	}
+	if olderThan <= 0 {
+		h.writeValidationError(w, "older_than_days", "retention is not configured, older_than_days is required")
+		return
+	}
+
+	run, err := h.service.ArchiveMergedPullRequests(r.Context(), olderThan)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, run)
+}
+
+func (h *Handler) handleAdminStatsSnapshot(w http.ResponseWriter, r *http.Request) {
+	snapshot, err := h.service.SnapshotStats(r.Context(), time.Now())
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, snapshot)
+}
+
+func (h *Handler) handleAdminNotificationFailures(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, err := parseCursorPage(q)
+	if err != nil {
+		h.writeValidationError(w, "", err.Error())
+		return
+	}
+
+	failures, next, err := h.service.ListNotificationFailures(r.Context(), q.Get("channel"), page)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"failures":    failures,
+		"next_cursor": nextCursor(next),
+	})
+}
+
+func (h *Handler) handleAdminNotificationReplay(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writeValidationError(w, "id", "id must be a failure_id")
+		return
+	}
+
+	messageID, err := h.service.ReplayNotificationFailure(r.Context(), id)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"failure_id": id,
+		"message_id": messageID,
+	})
+}
+
+type runtimeSettingsRequest struct {
+	DefaultStrategy *string         `json:"default_strategy"`
+	DefaultSLAHours *int            `json:"default_sla_hours"`
+	Features        map[string]bool `json:"features"`
+	// RateLimits maps route classes to COUNT/PERIOD; null restores the
+	// limit configured at startup.
+	RateLimits map[string]*string `json:"rate_limits"`
+}
+
+func (h *Handler) handleAdminSettingsGet(w http.ResponseWriter, r *http.Request) {
+	settings, err := h.service.GetRuntimeSettings(r.Context())
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"settings": settings,
+	})
+}
+
+func (h *Handler) handleAdminSettingsUpdate(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+	}()
+
+	var req runtimeSettingsRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	settings, err := h.service.UpdateRuntimeSettings(r.Context(), app.RuntimeSettingsUpdate{
+		DefaultStrategy: req.DefaultStrategy,
+		DefaultSLAHours: req.DefaultSLAHours,
+		Features:        req.Features,
+		RateLimits:      req.RateLimits,
+	})
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"settings": settings,
+	})
+}
+
+func (h *Handler) handleAdminJobs(w http.ResponseWriter, r *http.Request) {
+	jobs, err := h.service.ListJobs(r.Context())
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"jobs": jobs,
+	})
+}
@@ -0,0 +1,73 @@
+package httpserver
+
+import (
+	"net/http"
+	"strconv"
+
+	"review-assigner/internal/app"
+)
+
+type issueAPIKeyRequest struct {
+	ClientName string `json:"client_name"`
+	// Scopes limit the key to teams:write, prs:write, stats:read and
+	// webhooks:manage; omitted for full access.
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+type issueAPIKeyResponse struct {
+	APIKey app.APIKey `json:"api_key"`
+	// Key is only ever returned here.
+	Key string `json:"key"`
+}
+
+type apiKeyResponse struct {
+	APIKey app.APIKey `json:"api_key"`
+}
+
+func (h *Handler) handleAdminAPIKeyIssue(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+	}()
+
+	var req issueAPIKeyRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	key, secret, err := h.service.IssueAPIKey(r.Context(), req.ClientName, req.Scopes)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, issueAPIKeyResponse{APIKey: key, Key: secret})
+}
+
+func (h *Handler) handleAdminAPIKeyList(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.service.ListAPIKeys(r.Context())
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"api_keys": keys,
+	})
+}
+
+func (h *Handler) handleAdminAPIKeyRevoke(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writeValidationError(w, "id", "id must be a key_id")
+		return
+	}
+
+	key, err := h.service.RevokeAPIKey(r.Context(), id)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, apiKeyResponse{APIKey: key})
+}
@@ -0,0 +1,67 @@
+package httpserver
+
+import (
+	"net/http"
+
+	"review-assigner/internal/app"
+)
+
+type setCredentialRequest struct {
+	Name string `json:"name"`
+	// Secret is stored encrypted and never returned.
+	Secret string `json:"secret"`
+}
+
+type credentialResponse struct {
+	Credential app.Credential `json:"credential"`
+	// RestartRequired tells the caller that replicas read credentials at
+	// startup, so the change applies once they restart.
+	RestartRequired bool `json:"restart_required"`
+}
+
+type deleteCredentialResponse struct {
+	Name            string `json:"name"`
+	RestartRequired bool   `json:"restart_required"`
+}
+
+func (h *Handler) handleAdminCredentialList(w http.ResponseWriter, r *http.Request) {
+	credentials, err := h.service.ListCredentials(r.Context())
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"credentials": credentials,
+	})
+}
+
+func (h *Handler) handleAdminCredentialSet(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+	}()
+
+	var req setCredentialRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	credential, err := h.service.SetCredential(r.Context(), req.Name, req.Secret)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, credentialResponse{Credential: credential, RestartRequired: true})
+}
+
+func (h *Handler) handleAdminCredentialDelete(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if err := h.service.DeleteCredential(r.Context(), name); err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, deleteCredentialResponse{Name: name, RestartRequired: true})
+}
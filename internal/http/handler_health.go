@@ -0,0 +1,55 @@
+package httpserver
+
+import (
+	"context"
+	"net/http"
+	"review-assigner/internal/app"
+	"time"
+)
+
+// readinessTimeout bounds the database check of /readyz, so that a probe
+// fails fast instead of hanging on an unreachable database.
+const readinessTimeout = 2 * time.Second
+
+type healthResponse struct {
+	Status string `json:"status"`
+}
+
+type readinessResponse struct {
+	// Status is ready, unavailable (the database is unreachable) or
+	// migrations_pending (the database schema is older than the code).
+	Status                string `json:"status"`
+	SchemaVersion         int    `json:"schema_version"`
+	ExpectedSchemaVersion int    `json:"expected_schema_version"`
+}
+
+// handleHealthz is the liveness probe: it answers while the process serves
+// HTTP and checks nothing else, so that a database outage does not restart
+// every replica.
+func (h *Handler) handleHealthz(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, healthResponse{Status: "ok"})
+}
+
+// handleReadyz is the readiness probe: it answers 503 while the database is
+// unreachable or not migrated, taking the replica out of load balancing.
+func (h *Handler) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	resp := readinessResponse{Status: "ready", ExpectedSchemaVersion: app.SchemaVersion}
+	version, err := h.service.DatabaseSchemaVersion(ctx)
+	switch {
+	case err != nil:
+		h.logger.Warn("readiness check failed", "request_id", requestID(w), "error", err)
+		resp.Status = "unavailable"
+	case version < app.SchemaVersion:
+		resp.Status = "migrations_pending"
+	}
+	resp.SchemaVersion = version
+
+	status := http.StatusOK
+	if resp.Status != "ready" {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, resp)
+}
@@ -0,0 +1,93 @@
+package httpserver
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// icalTimeLayout is the UTC DATE-TIME form of RFC 5545.
+const icalTimeLayout = "20060102T150405Z"
+
+// icalLineLimit is the longest content line RFC 5545 allows, in octets.
+const icalLineLimit = 75
+
+// handleUserReviewsICal serves the user's pending reviews as an iCalendar
+// feed for calendar subscriptions. A review is an event at its deadline, or
+// at the time the pull request was opened when it has none.
+func (h *Handler) handleUserReviewsICal(w http.ResponseWriter, r *http.Request) {
+	userID := r.PathValue("id")
+	if err := h.authorizeSelf(r, userID); err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+	user, err := h.service.GetUser(r.Context(), userID)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+	prs, err := h.service.GetPendingReviews(r.Context(), userID)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	now := time.Now().UTC().Format(icalTimeLayout)
+	var b strings.Builder
+	writeICalLine(&b, "BEGIN:VCALENDAR")
+	writeICalLine(&b, "VERSION:2.0")
+	writeICalLine(&b, "PRODID:-//review-assigner//reviews//EN")
+	writeICalLine(&b, "CALSCALE:GREGORIAN")
+	writeICalLine(&b, "X-WR-CALNAME:"+icalText("Reviews of "+user.Name))
+	for _, pr := range prs {
+		summary := "Review: " + pr.Name
+		start := pr.CreatedAt
+		if pr.Deadline != nil {
+			summary = "Review due: " + pr.Name
+			start = pr.Deadline
+		}
+		if start == nil {
+			continue
+		}
+
+		writeICalLine(&b, "BEGIN:VEVENT")
+		writeICalLine(&b, "UID:"+icalText(pr.ID+"/"+userID+"@review-assigner"))
+		writeICalLine(&b, "DTSTAMP:"+now)
+		writeICalLine(&b, "DTSTART:"+start.UTC().Format(icalTimeLayout))
+		writeICalLine(&b, "SUMMARY:"+icalText(summary))
+		writeICalLine(&b, "DESCRIPTION:"+icalText(fmt.Sprintf("%s by %s, %s priority",
+			pr.ID, pr.AuthorID, strings.ToLower(pr.Priority))))
+		writeICalLine(&b, "END:VEVENT")
+	}
+	writeICalLine(&b, "END:VCALENDAR")
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="reviews.ics"`)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(b.String()))
+}
+
+// icalText escapes a TEXT value.
+func icalText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// writeICalLine writes a content line, folding it into continuation lines
+// without splitting UTF-8 characters.
+func writeICalLine(b *strings.Builder, line string) {
+	limit := icalLineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space, which counts.
+		limit = icalLineLimit - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
@@ -0,0 +1,134 @@
+package httpserver
+
+import (
+	"net/http"
+	"time"
+
+	"review-assigner/internal/app"
+)
+
+// maxEventIDLen bounds event_id like Idempotency-Key.
+const maxEventIDLen = maxIdempotencyKeyLen
+
+type ingestEventRequest struct {
+	EventID     string                 `json:"event_id"`
+	Type        string                 `json:"type"`
+	PullRequest ingestPullRequestEvent `json:"pull_request"`
+	ReviewerID  string                 `json:"reviewer_id"`
+}
+
+// ingestPullRequestEvent holds the pull request fields of an event, named
+// as in /pullRequest/create.
+type ingestPullRequestEvent struct {
+	ID          string     `json:"pull_request_id"`
+	Name        string     `json:"pull_request_name"`
+	AuthorID    string     `json:"author_id"`
+	Priority    string     `json:"priority"`
+	CoAuthorIDs []string   `json:"co_author_ids"`
+	Labels      []string   `json:"labels"`
+	JiraIssues  []string   `json:"jira_issues"`
+	Deadline    *time.Time `json:"deadline"`
+	// GitHubRepo and GitHubNumber link the pull request to GitHub.
+	GitHubRepo   string `json:"github_repo"`
+	GitHubNumber int    `json:"github_number"`
+}
+
+type ingestEventResponse struct {
+	EventID   string           `json:"event_id,omitempty"`
+	Type      string           `json:"type"`
+	Duplicate bool             `json:"duplicate"`
+	PR        *app.PullRequest `json:"pr,omitempty"`
+}
+
+// handleEventIngest applies a pull request event from a code host without
+// first-class support, translated to the normalized schema.
+func (h *Handler) handleEventIngest(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+	}()
+
+	var req ingestEventRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	if len(req.EventID) > maxEventIDLen {
+		h.writeValidationError(w, "event_id", "event_id is too long")
+		return
+	}
+	if !app.IsValidEventType(req.Type) {
+		h.writeValidationError(w, "type", "type must be one of pull_request.opened, pull_request.updated, "+
+			"pull_request.merged, pull_request.closed, review.approved, review.declined")
+		return
+	}
+	in := req.PullRequest
+	if in.ID == "" {
+		h.writeValidationError(w, "pull_request.pull_request_id", "pull_request.pull_request_id is required")
+		return
+	}
+	switch req.Type {
+	case app.EventPullRequestOpened:
+		if in.Name == "" {
+			h.writeValidationError(w, "pull_request.pull_request_name", "pull_request.pull_request_name is required")
+			return
+		}
+		if in.AuthorID == "" {
+			h.writeValidationError(w, "pull_request.author_id", "pull_request.author_id is required")
+			return
+		}
+	case app.EventReviewApproved, app.EventReviewDeclined:
+		if req.ReviewerID == "" {
+			h.writeValidationError(w, "reviewer_id", "reviewer_id is required")
+			return
+		}
+		if err := h.authorizeSelf(r, req.ReviewerID); err != nil {
+			h.writeAppError(w, err)
+			return
+		}
+	}
+	if in.Priority != "" && !app.IsValidPriority(in.Priority) {
+		h.writeValidationError(w, "pull_request.priority", "priority must be one of LOW, MEDIUM, HIGH")
+		return
+	}
+	if msg := validateLabels(in.Labels); msg != "" {
+		h.writeValidationError(w, "pull_request.labels", msg)
+		return
+	}
+	if msg := validateJiraIssues(in.JiraIssues); msg != "" {
+		h.writeValidationError(w, "pull_request.jira_issues", msg)
+		return
+	}
+	if msg := app.ValidateGitHubLink(in.GitHubRepo, in.GitHubNumber); msg != "" {
+		h.writeValidationError(w, "pull_request.github_repo", msg)
+		return
+	}
+
+	pr, duplicate, err := h.service.IngestEvent(r.Context(), app.PullRequestEvent{
+		ID:   req.EventID,
+		Type: req.Type,
+		PullRequest: app.PullRequestInput{
+			ID:           in.ID,
+			Name:         in.Name,
+			AuthorID:     in.AuthorID,
+			Priority:     in.Priority,
+			CoAuthorIDs:  in.CoAuthorIDs,
+			Labels:       in.Labels,
+			JiraIssues:   in.JiraIssues,
+			GitHubRepo:   in.GitHubRepo,
+			GitHubNumber: in.GitHubNumber,
+			Deadline:     in.Deadline,
+		},
+		ReviewerID: req.ReviewerID,
+	})
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	resp := ingestEventResponse{EventID: req.EventID, Type: req.Type, Duplicate: duplicate}
+	if !duplicate {
+		resp.PR = &pr
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,86 @@
+package httpserver
+
+import (
+	"net/http"
+)
+
+type orgCreateRequest struct {
+	OrgName string `json:"org_name"`
+}
+
+func (h *Handler) handleOrgCreate(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+	}()
+
+	var req orgCreateRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	if req.OrgName == "" {
+		h.writeValidationError(w, "org_name", "org_name is required")
+		return
+	}
+
+	org, err := h.service.CreateOrganization(r.Context(), req.OrgName)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"organization": org,
+	})
+}
+
+func (h *Handler) handleOrgGet(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("org_name")
+	if name == "" {
+		h.writeValidationError(w, "org_name", "org_name is required")
+		return
+	}
+
+	org, err := h.service.GetOrganization(r.Context(), name)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"organization": org,
+	})
+}
+
+type orgSetTeamRequest struct {
+	TeamName string `json:"team_name"`
+	OrgName  string `json:"org_name"`
+}
+
+func (h *Handler) handleOrgSetTeam(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+	}()
+
+	var req orgSetTeamRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	if req.TeamName == "" {
+		h.writeValidationError(w, "team_name", "team_name is required")
+		return
+	}
+
+	team, err := h.service.SetTeamOrganization(r.Context(), req.TeamName, req.OrgName)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"team": team,
+	})
+}
@@ -1,55 +1,143 @@
 package httpserver
 
 import (
-	"encoding/json"
+	"fmt"
 	"net/http"
+	"review-assigner/internal/app"
+	"strconv"
+	"time"
 )
 
+// idempotencyKeyHeader lets callers with at-least-once delivery retry
+// /pullRequest/create safely.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+const maxIdempotencyKeyLen = 255
+
 type createPullRequestRequest struct {
-	ID       string `json:"pull_request_id"`
-	Name     string `json:"pull_request_name"`
-	AuthorID string `json:"author_id"`
+	ID          string     `json:"pull_request_id"`
+	Name        string     `json:"pull_request_name"`
+	AuthorID    string     `json:"author_id"`
+	Priority    string     `json:"priority"`
+	CoAuthorIDs []string   `json:"co_author_ids"`
+	Labels      []string   `json:"labels"`
+	JiraIssues  []string   `json:"jira_issues"`
+	Deadline    *time.Time `json:"deadline"`
+	// GitHubRepo and GitHubNumber link the pull request to GitHub.
+	GitHubRepo   string `json:"github_repo"`
+	GitHubNumber int    `json:"github_number"`
+
+	NeedsSecurityReview bool `json:"needs_security_review"`
+	// WaitSeconds lets strict mode teams wait for reviewers instead of
+	// failing right away with NOT_ENOUGH_REVIEWERS.
+	WaitSeconds int `json:"wait_seconds"`
+}
+
+type updatePullRequestRequest struct {
+	ID         string     `json:"pull_request_id"`
+	Name       *string    `json:"pull_request_name"`
+	Priority   *string    `json:"priority"`
+	Labels     *[]string  `json:"labels"`
+	JiraIssues *[]string  `json:"jira_issues"`
+	Deadline   *time.Time `json:"deadline"`
+	// GitHubRepo and GitHubNumber are set together; "" and 0 unlink.
+	GitHubRepo   *string `json:"github_repo"`
+	GitHubNumber *int    `json:"github_number"`
 }
 
 type mergePullRequestRequest struct {
 	ID string `json:"pull_request_id"`
 }
 
+type deletePullRequestRequest struct {
+	ID string `json:"pull_request_id"`
+}
+
 type reassignPullRequestRequest struct {
 	ID        string `json:"pull_request_id"`
 	OldUserID string `json:"old_user_id"`
 }
 
-func (h *Handler) handlePullRequestCreate(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		return
-	}
+type approvePullRequestRequest struct {
+	ID     string `json:"pull_request_id"`
+	UserID string `json:"user_id"`
+}
+
+type declinePullRequestRequest struct {
+	ID     string `json:"pull_request_id"`
+	UserID string `json:"user_id"`
+}
 
+func (h *Handler) handlePullRequestCreate(w http.ResponseWriter, r *http.Request) {
 	defer func() {
 		_ = r.Body.Close()
 	}()
 
 	var req createPullRequestRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid JSON", http.StatusBadRequest)
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.writeAppError(w, err)
 		return
 	}
 
 	if req.ID == "" {
-		http.Error(w, "pull_request_id is required", http.StatusBadRequest)
+		h.writeValidationError(w, "pull_request_id", "pull_request_id is required")
 		return
 	}
 	if req.Name == "" {
-		http.Error(w, "pull_request_name is required", http.StatusBadRequest)
+		h.writeValidationError(w, "pull_request_name", "pull_request_name is required")
 		return
 	}
 	if req.AuthorID == "" {
-		http.Error(w, "author_id is required", http.StatusBadRequest)
+		h.writeValidationError(w, "author_id", "author_id is required")
+		return
+	}
+	if req.Priority != "" && !app.IsValidPriority(req.Priority) {
+		h.writeValidationError(w, "priority", "priority must be one of LOW, MEDIUM, HIGH")
+		return
+	}
+
+	if msg := validateLabels(req.Labels); msg != "" {
+		h.writeValidationError(w, "", msg)
+		return
+	}
+	if msg := validateJiraIssues(req.JiraIssues); msg != "" {
+		h.writeValidationError(w, "jira_issues", msg)
+		return
+	}
+	if msg := app.ValidateGitHubLink(req.GitHubRepo, req.GitHubNumber); msg != "" {
+		h.writeValidationError(w, "github_repo", msg)
+		return
+	}
+	if maxWait := int(app.MaxCreateWait / time.Second); req.WaitSeconds < 0 || req.WaitSeconds > maxWait {
+		h.writeValidationError(w, "wait_seconds", fmt.Sprintf("wait_seconds must be between 0 and %d", maxWait))
+		return
+	}
+	if req.NeedsSecurityReview && h.service.SecurityTeam() == "" {
+		h.writeValidationError(w, "", "security review is not configured")
+		return
+	}
+
+	idempotencyKey := r.Header.Get(idempotencyKeyHeader)
+	if len(idempotencyKey) > maxIdempotencyKeyLen {
+		h.writeValidationError(w, "", "Idempotency-Key is too long")
 		return
 	}
 
-	pr, err := h.service.CreatePullRequest(r.Context(), req.ID, req.Name, req.AuthorID)
+	pr, err := h.service.CreatePullRequest(r.Context(), app.PullRequestInput{
+		ID:                  req.ID,
+		Name:                req.Name,
+		AuthorID:            req.AuthorID,
+		Priority:            req.Priority,
+		CoAuthorIDs:         req.CoAuthorIDs,
+		Labels:              req.Labels,
+		JiraIssues:          req.JiraIssues,
+		GitHubRepo:          req.GitHubRepo,
+		GitHubNumber:        req.GitHubNumber,
+		Deadline:            req.Deadline,
+		NeedsSecurityReview: req.NeedsSecurityReview,
+		IdempotencyKey:      idempotencyKey,
+		Wait:                time.Duration(req.WaitSeconds) * time.Second,
+	})
 	if err != nil {
 		h.writeAppError(w, err)
 		return
@@ -60,71 +148,405 @@ func (h *Handler) handlePullRequestCreate(w http.ResponseWriter, r *http.Request
 	})
 }
 
-func (h *Handler) handlePullRequestMerge(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		w.WriteHeader(http.StatusMethodNotAllowed)
+func (h *Handler) handlePullRequestUpdate(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+	}()
+
+	var req updatePullRequestRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	if req.ID == "" {
+		h.writeValidationError(w, "pull_request_id", "pull_request_id is required")
+		return
+	}
+	if req.Name != nil && *req.Name == "" {
+		h.writeValidationError(w, "pull_request_name", "pull_request_name must not be empty")
+		return
+	}
+	if req.Priority != nil && !app.IsValidPriority(*req.Priority) {
+		h.writeValidationError(w, "priority", "priority must be one of LOW, MEDIUM, HIGH")
+		return
+	}
+	if req.Labels != nil {
+		if msg := validateLabels(*req.Labels); msg != "" {
+			h.writeValidationError(w, "", msg)
+			return
+		}
+	}
+	if req.JiraIssues != nil {
+		if msg := validateJiraIssues(*req.JiraIssues); msg != "" {
+			h.writeValidationError(w, "jira_issues", msg)
+			return
+		}
+	}
+	if (req.GitHubRepo == nil) != (req.GitHubNumber == nil) {
+		h.writeValidationError(w, "github_repo", "github_repo and github_number must be set together")
+		return
+	}
+	if req.GitHubRepo != nil {
+		if msg := app.ValidateGitHubLink(*req.GitHubRepo, *req.GitHubNumber); msg != "" {
+			h.writeValidationError(w, "github_repo", msg)
+			return
+		}
+	}
+
+	pr, err := h.service.UpdatePullRequest(r.Context(), app.PullRequestUpdate{
+		ID:           req.ID,
+		Name:         req.Name,
+		Priority:     req.Priority,
+		Labels:       req.Labels,
+		JiraIssues:   req.JiraIssues,
+		GitHubRepo:   req.GitHubRepo,
+		GitHubNumber: req.GitHubNumber,
+		Deadline:     req.Deadline,
+	})
+	if err != nil {
+		h.writeAppError(w, err)
 		return
 	}
 
+	writeJSON(w, http.StatusOK, map[string]any{
+		"pr": pr,
+	})
+}
+
+func (h *Handler) handlePullRequestMerge(w http.ResponseWriter, r *http.Request) {
 	defer func() {
 		_ = r.Body.Close()
 	}()
 
 	var req mergePullRequestRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid JSON", http.StatusBadRequest)
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.writeAppError(w, err)
 		return
 	}
 
 	if req.ID == "" {
-		http.Error(w, "pull_request_id is required", http.StatusBadRequest)
+		h.writeValidationError(w, "pull_request_id", "pull_request_id is required")
 		return
 	}
 
-	pr, err := h.service.MergePullRequest(r.Context(), req.ID)
+	pr, summary, err := h.service.MergePullRequest(r.Context(), req.ID)
 	if err != nil {
 		h.writeAppError(w, err)
 		return
 	}
 
 	writeJSON(w, http.StatusOK, map[string]any{
-		"pr": pr,
+		"pr":             pr,
+		"review_summary": summary,
 	})
 }
 
-func (h *Handler) handlePullRequestReassign(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		w.WriteHeader(http.StatusMethodNotAllowed)
+func (h *Handler) handlePullRequestDelete(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+	}()
+
+	var req deletePullRequestRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	if req.ID == "" {
+		h.writeValidationError(w, "pull_request_id", "pull_request_id is required")
+		return
+	}
+
+	pr, err := h.service.DeletePullRequest(r.Context(), req.ID)
+	if err != nil {
+		h.writeAppError(w, err)
 		return
 	}
 
+	writeJSON(w, http.StatusOK, map[string]any{
+		"pr": pr,
+	})
+}
+
+func (h *Handler) handlePullRequestReassign(w http.ResponseWriter, r *http.Request) {
 	defer func() {
 		_ = r.Body.Close()
 	}()
 
 	var req reassignPullRequestRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid JSON", http.StatusBadRequest)
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.writeAppError(w, err)
 		return
 	}
 
 	if req.ID == "" {
-		http.Error(w, "pull_request_id is required", http.StatusBadRequest)
+		h.writeValidationError(w, "pull_request_id", "pull_request_id is required")
 		return
 	}
 	if req.OldUserID == "" {
-		http.Error(w, "old_user_id is required", http.StatusBadRequest)
+		h.writeValidationError(w, "old_user_id", "old_user_id is required")
+		return
+	}
+
+	pr, replacedBy, previous, err := h.service.ReassignReviewer(r.Context(), req.ID, req.OldUserID)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+	h.metrics.reassignments.Inc()
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"pr":                 pr,
+		"replaced_by":        replacedBy,
+		"previous_reviewers": previous,
+	})
+}
+
+func (h *Handler) handlePullRequestApprove(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+	}()
+
+	var req approvePullRequestRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.writeAppError(w, err)
 		return
 	}
 
-	pr, replacedBy, err := h.service.ReassignReviewer(r.Context(), req.ID, req.OldUserID)
+	if req.ID == "" {
+		h.writeValidationError(w, "pull_request_id", "pull_request_id is required")
+		return
+	}
+	if req.UserID == "" {
+		h.writeValidationError(w, "user_id", "user_id is required")
+		return
+	}
+
+	if err := h.authorizeSelf(r, req.UserID); err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	pr, err := h.service.ApprovePullRequest(r.Context(), req.ID, req.UserID)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"pr": pr,
+	})
+}
+
+func (h *Handler) handlePullRequestDecline(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+	}()
+
+	var req declinePullRequestRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	if req.ID == "" {
+		h.writeValidationError(w, "pull_request_id", "pull_request_id is required")
+		return
+	}
+	if req.UserID == "" {
+		h.writeValidationError(w, "user_id", "user_id is required")
+		return
+	}
+	if err := h.authorizeSelf(r, req.UserID); err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	pr, replacedBy, previous, err := h.service.DeclineReview(r.Context(), req.ID, req.UserID)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"pr":                 pr,
+		"replaced_by":        replacedBy,
+		"previous_reviewers": previous,
+	})
+}
+
+func (h *Handler) handlePullRequestList(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := app.PullRequestFilter{
+		Status:     q.Get("status"),
+		AuthorID:   q.Get("author_id"),
+		TeamName:   q.Get("team_name"),
+		ReviewerID: q.Get("reviewer_id"),
+		Priority:   q.Get("priority"),
+		Labels:     q["label"],
+		Sort:       q.Get("sort"),
+	}
+
+	if filter.Status != "" && filter.Status != "OPEN" && filter.Status != "MERGED" {
+		h.writeValidationError(w, "status", "status must be OPEN or MERGED")
+		return
+	}
+	if filter.Priority != "" && !app.IsValidPriority(filter.Priority) {
+		h.writeValidationError(w, "priority", "priority must be one of LOW, MEDIUM, HIGH")
+		return
+	}
+	if !app.IsValidPullRequestSort(filter.Sort) {
+		h.writeValidationError(w, "sort", "sort must be one of pull_request_id, created_at, priority")
+		return
+	}
+
+	var err error
+	if v := q.Get("include_deleted"); v != "" {
+		if filter.IncludeDeleted, err = strconv.ParseBool(v); err != nil {
+			h.writeValidationError(w, "include_deleted", "include_deleted must be a boolean")
+			return
+		}
+	}
+	if filter.IncludeDeleted {
+		if err := h.authorizeAdmin(r); err != nil {
+			h.writeAppError(w, err)
+			return
+		}
+	}
+	if v := q.Get("include_archived"); v != "" {
+		if filter.IncludeArchived, err = strconv.ParseBool(v); err != nil {
+			h.writeValidationError(w, "include_archived", "include_archived must be a boolean")
+			return
+		}
+	}
+	if filter.CreatedFrom, err = parseTimeParam(q.Get("created_from")); err != nil {
+		h.writeValidationError(w, "created_from", "created_from must be an RFC 3339 timestamp")
+		return
+	}
+	if filter.CreatedTo, err = parseTimeParam(q.Get("created_to")); err != nil {
+		h.writeValidationError(w, "created_to", "created_to must be an RFC 3339 timestamp")
+		return
+	}
+
+	page, err := parseCursorPage(q)
+	if err != nil {
+		h.writeValidationError(w, "", err.Error())
+		return
+	}
+	fields, err := parseFields(q, app.PullRequest{})
+	if err != nil {
+		h.writeValidationError(w, "fields", err.Error())
+		return
+	}
+
+	prs, next, err := h.service.ListPullRequests(r.Context(), filter, page)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"pull_requests": selectFields(prs, fields),
+		"next_cursor":   nextCursor(next),
+	})
+}
+
+func (h *Handler) handlePullRequestGet(w http.ResponseWriter, r *http.Request) {
+	if rejectOperationPath(w, r) {
+		return
+	}
+	var includeDeleted bool
+	if v := r.URL.Query().Get("include_deleted"); v != "" {
+		var err error
+		if includeDeleted, err = strconv.ParseBool(v); err != nil {
+			h.writeValidationError(w, "include_deleted", "include_deleted must be a boolean")
+			return
+		}
+	}
+	if includeDeleted {
+		if err := h.authorizeAdmin(r); err != nil {
+			h.writeAppError(w, err)
+			return
+		}
+	}
+	pr, err := h.service.GetPullRequest(r.Context(), r.PathValue("id"), includeDeleted)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"pr": pr,
+	})
+}
+
+func (h *Handler) handlePullRequestHistory(w http.ResponseWriter, r *http.Request) {
+	prID := r.URL.Query().Get("pull_request_id")
+	if prID == "" {
+		h.writeValidationError(w, "pull_request_id", "pull_request_id is required")
+		return
+	}
+
+	events, err := h.service.GetAssignmentHistory(r.Context(), prID)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"pull_request_id": prID,
+		"events":          events,
+	})
+}
+
+// validateLabels returns a message describing the first invalid label, or "".
+func validateLabels(labels []string) string {
+	if len(labels) > app.MaxLabels {
+		return fmt.Sprintf("at most %d labels are allowed", app.MaxLabels)
+	}
+	for _, l := range labels {
+		if len(l) > app.MaxLabelLength {
+			return fmt.Sprintf("labels must be at most %d characters", app.MaxLabelLength)
+		}
+	}
+	return ""
+}
+
+// validateJiraIssues returns a message describing the first invalid Jira
+// issue key, or "".
+func validateJiraIssues(keys []string) string {
+	if len(keys) > app.MaxJiraIssues {
+		return fmt.Sprintf("at most %d jira_issues are allowed", app.MaxJiraIssues)
+	}
+	for _, k := range app.NormalizeJiraIssues(keys) {
+		if !app.IsValidJiraIssueKey(k) {
+			return fmt.Sprintf("%q is not a Jira issue key", k)
+		}
+	}
+	return ""
+}
+
+func parseTimeParam(v string) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (h *Handler) handlePullRequestUnderassigned(w http.ResponseWriter, r *http.Request) {
+	prs, err := h.service.GetUnderassignedPullRequests(r.Context())
 	if err != nil {
 		h.writeAppError(w, err)
 		return
 	}
 
 	writeJSON(w, http.StatusOK, map[string]any{
-		"pr":          pr,
-		"replaced_by": replacedBy,
+		"required_reviewers": app.RequiredReviewers,
+		"pull_requests":      prs,
 	})
 }
@@ -0,0 +1,730 @@
+package httpserver
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"review-assigner/internal/app"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// SCIM 2.0 (RFC 7643, RFC 7644) provisioning of users and teams from a
+// corporate directory. Users are identified by user_id, which is their SCIM
+// id and userName; groups are teams, identified by team_name.
+const (
+	scimUserSchema     = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimGroupSchema    = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	scimListSchema     = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	scimErrorSchema    = "urn:ietf:params:scim:api:messages:2.0:Error"
+	scimConfigSchema   = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	scimContentType    = "application/scim+json"
+	maxSCIMRequestBody = 1 << 20
+)
+
+// WithSCIMToken enables the SCIM provisioning endpoints under /scim/v2,
+// which accept only requests with this bearer token.
+func WithSCIMToken(token string) Option {
+	return func(h *Handler) {
+		h.scimToken = token
+	}
+}
+
+type scimName struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+type scimEmail struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// scimMember is a group member, or a group in the groups of a user.
+type scimMember struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+}
+
+type scimMeta struct {
+	ResourceType string `json:"resourceType"`
+	Location     string `json:"location"`
+}
+
+type scimUser struct {
+	Schemas     []string    `json:"schemas"`
+	ID          string      `json:"id,omitempty"`
+	UserName    string      `json:"userName"`
+	DisplayName string      `json:"displayName,omitempty"`
+	Name        *scimName   `json:"name,omitempty"`
+	Emails      []scimEmail `json:"emails,omitempty"`
+	// Active defaults to true on input.
+	Active *bool `json:"active,omitempty"`
+	// Groups is read-only: membership is changed through groups.
+	Groups []scimMember `json:"groups,omitempty"`
+	Meta   *scimMeta    `json:"meta,omitempty"`
+}
+
+type scimGroup struct {
+	Schemas     []string     `json:"schemas"`
+	ID          string       `json:"id,omitempty"`
+	DisplayName string       `json:"displayName"`
+	Members     []scimMember `json:"members,omitempty"`
+	Meta        *scimMeta    `json:"meta,omitempty"`
+}
+
+type scimListResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []any    `json:"Resources"`
+}
+
+type scimPatchRequest struct {
+	Schemas    []string             `json:"schemas"`
+	Operations []scimPatchOperation `json:"Operations"`
+}
+
+type scimPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+type scimErrorResponse struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail"`
+}
+
+// withSCIMAuth rejects requests without the SCIM bearer token.
+func (h *Handler) withSCIMAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.scimToken)) != 1 {
+			writeSCIMError(w, http.StatusUnauthorized, "", "invalid bearer token")
+			return
+		}
+		next(w, r)
+	}
+}
+
+func writeSCIM(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", scimContentType)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func writeSCIMError(w http.ResponseWriter, status int, scimType, detail string) {
+	writeSCIM(w, status, scimErrorResponse{
+		Schemas:  []string{scimErrorSchema},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	})
+}
+
+// writeSCIMAppError reports an app error in the SCIM error format.
+func (h *Handler) writeSCIMAppError(w http.ResponseWriter, err error) {
+	var appErr *app.Error
+	if app.IsTimeout(err) {
+		h.metrics.appErrors.WithLabelValues(string(app.ErrorCodeTimeout)).Inc()
+		h.logger.Warn("request timed out", "request_id", requestID(w), "error", err)
+		writeSCIMError(w, http.StatusGatewayTimeout, "", "request timed out")
+		return
+	}
+	if !errors.As(err, &appErr) {
+		h.logger.Error("internal error", "request_id", requestID(w), "error", err)
+		writeSCIMError(w, http.StatusInternalServerError, "", "internal error")
+		return
+	}
+
+	h.metrics.appErrors.WithLabelValues(string(appErr.Code)).Inc()
+	status, scimType := appErrorStatus(appErr.Code), ""
+	switch appErr.Code {
+	case app.ErrorCodeUserExists, app.ErrorCodeTeamExists:
+		status, scimType = http.StatusConflict, "uniqueness"
+	case app.ErrorCodeValidation:
+		scimType = "invalidValue"
+	}
+	writeSCIMError(w, status, scimType, appErr.Message)
+}
+
+// decodeSCIM reads a SCIM request body. Unknown attributes are ignored, as
+// directories send many the service does not keep.
+func decodeSCIM(r *http.Request, dst any) error {
+	defer func() {
+		_ = r.Body.Close()
+	}()
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxSCIMRequestBody)).Decode(dst); err != nil {
+		return &app.Error{Code: app.ErrorCodeValidation, Message: "invalid JSON"}
+	}
+	return nil
+}
+
+// parseSCIMFilter parses the only filter form directories need for
+// lookups: `attribute eq "value"`.
+func parseSCIMFilter(filter, attribute string) (string, bool) {
+	fields := strings.SplitN(strings.TrimSpace(filter), " ", 3)
+	if len(fields) != 3 || !strings.EqualFold(fields[0], attribute) || !strings.EqualFold(fields[1], "eq") {
+		return "", false
+	}
+	value, err := strconv.Unquote(fields[2])
+	if err != nil {
+		return "", false
+	}
+	return value, true
+}
+
+// scimPage reads startIndex (1-based) and count.
+func scimPage(q url.Values) (app.Page, int, error) {
+	startIndex := 1
+	if v := q.Get("startIndex"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return app.Page{}, 0, errors.New("startIndex must be an integer")
+		}
+		startIndex = max(n, 1)
+	}
+	page := app.Page{Offset: startIndex - 1}
+	if v := q.Get("count"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return app.Page{}, 0, errors.New("count must be a non-negative integer")
+		}
+		page.Limit = min(n, maxPageLimit)
+	}
+	return page, startIndex, nil
+}
+
+func scimList(startIndex int, total int, resources []any) scimListResponse {
+	if resources == nil {
+		resources = []any{}
+	}
+	return scimListResponse{
+		Schemas:      []string{scimListSchema},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}
+}
+
+func (h *Handler) handleSCIMServiceProviderConfig(w http.ResponseWriter, _ *http.Request) {
+	supported := func(ok bool) map[string]any { return map[string]any{"supported": ok} }
+	writeSCIM(w, http.StatusOK, map[string]any{
+		"schemas":        []string{scimConfigSchema},
+		"patch":          supported(true),
+		"bulk":           map[string]any{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]any{"supported": true, "maxResults": maxPageLimit},
+		"changePassword": supported(false),
+		"sort":           supported(false),
+		"etag":           supported(false),
+		"authenticationSchemes": []map[string]any{{
+			"type": "oauthbearertoken",
+			"name": "Bearer token",
+		}},
+	})
+}
+
+func toSCIMUser(u app.User) scimUser {
+	active := u.IsActive
+	su := scimUser{
+		Schemas:     []string{scimUserSchema},
+		ID:          u.ID,
+		UserName:    u.ID,
+		DisplayName: u.Name,
+		Name:        &scimName{Formatted: u.Name},
+		Active:      &active,
+		Meta:        &scimMeta{ResourceType: "User", Location: "/scim/v2/Users/" + url.PathEscape(u.ID)},
+	}
+	if u.Email != "" {
+		su.Emails = []scimEmail{{Value: u.Email, Type: "work", Primary: true}}
+	}
+	if u.TeamName != "" {
+		su.Groups = []scimMember{{Value: u.TeamName, Display: u.TeamName}}
+	}
+	return su
+}
+
+// directoryUser reads the attributes the service keeps from a SCIM user.
+func (su scimUser) directoryUser(id string) (app.DirectoryUser, error) {
+	du := app.DirectoryUser{ID: id, Name: su.DisplayName, IsActive: su.Active == nil || *su.Active}
+	if du.Name == "" && su.Name != nil {
+		du.Name = su.Name.Formatted
+		if du.Name == "" {
+			du.Name = strings.TrimSpace(su.Name.GivenName + " " + su.Name.FamilyName)
+		}
+	}
+	if du.Name == "" {
+		du.Name = su.UserName
+	}
+	for _, e := range su.Emails {
+		if du.Email == "" || e.Primary {
+			du.Email = e.Value
+		}
+	}
+	if msg := app.ValidateProfile(app.Profile{Email: du.Email}); msg != "" {
+		return app.DirectoryUser{}, &app.Error{Code: app.ErrorCodeValidation, Message: msg}
+	}
+	return du, nil
+}
+
+func (h *Handler) handleSCIMUserList(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, startIndex, err := scimPage(q)
+	if err != nil {
+		writeSCIMError(w, http.StatusBadRequest, "invalidValue", err.Error())
+		return
+	}
+
+	if filter := q.Get("filter"); filter != "" {
+		userName, ok := parseSCIMFilter(filter, "userName")
+		if !ok {
+			writeSCIMError(w, http.StatusBadRequest, "invalidFilter", `only userName eq "value" filters are supported`)
+			return
+		}
+		var resources []any
+		user, err := h.service.GetUser(r.Context(), userName)
+		var appErr *app.Error
+		switch {
+		case err == nil:
+			resources = append(resources, toSCIMUser(user.User))
+		case !errors.As(err, &appErr) || appErr.Code != app.ErrorCodeNotFound:
+			h.writeSCIMAppError(w, err)
+			return
+		}
+		writeSCIM(w, http.StatusOK, scimList(1, len(resources), resources))
+		return
+	}
+
+	users, _, err := h.service.ListUsers(r.Context(), "", app.Page{})
+	if err != nil {
+		h.writeSCIMAppError(w, err)
+		return
+	}
+	total := len(users)
+	users = users[min(page.Offset, total):]
+	if page.Limit > 0 || q.Has("count") {
+		users = users[:min(page.Limit, len(users))]
+	}
+	resources := make([]any, 0, len(users))
+	for _, u := range users {
+		resources = append(resources, toSCIMUser(u))
+	}
+	writeSCIM(w, http.StatusOK, scimList(startIndex, total, resources))
+}
+
+func (h *Handler) handleSCIMUserCreate(w http.ResponseWriter, r *http.Request) {
+	var su scimUser
+	if err := decodeSCIM(r, &su); err != nil {
+		h.writeSCIMAppError(w, err)
+		return
+	}
+	if su.UserName == "" {
+		writeSCIMError(w, http.StatusBadRequest, "invalidValue", "userName is required")
+		return
+	}
+	du, err := su.directoryUser(su.UserName)
+	if err != nil {
+		h.writeSCIMAppError(w, err)
+		return
+	}
+
+	user, err := h.service.CreateUser(r.Context(), app.User{
+		ID:       du.ID,
+		Name:     du.Name,
+		IsActive: du.IsActive,
+		Profile:  app.Profile{Email: du.Email},
+	})
+	if err != nil {
+		h.writeSCIMAppError(w, err)
+		return
+	}
+	writeSCIM(w, http.StatusCreated, toSCIMUser(user))
+}
+
+func (h *Handler) handleSCIMUserGet(w http.ResponseWriter, r *http.Request) {
+	user, err := h.service.GetUser(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.writeSCIMAppError(w, err)
+		return
+	}
+	writeSCIM(w, http.StatusOK, toSCIMUser(user.User))
+}
+
+func (h *Handler) handleSCIMUserReplace(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	var su scimUser
+	if err := decodeSCIM(r, &su); err != nil {
+		h.writeSCIMAppError(w, err)
+		return
+	}
+	if su.UserName != "" && su.UserName != id {
+		writeSCIMError(w, http.StatusBadRequest, "mutability", "userName cannot be changed")
+		return
+	}
+	du, err := su.directoryUser(id)
+	if err != nil {
+		h.writeSCIMAppError(w, err)
+		return
+	}
+
+	user, err := h.service.SyncDirectoryUser(r.Context(), du)
+	if err != nil {
+		h.writeSCIMAppError(w, err)
+		return
+	}
+	writeSCIM(w, http.StatusOK, toSCIMUser(user))
+}
+
+// handleSCIMUserPatch applies replace and add operations on active,
+// displayName, name.formatted and emails, with or without a path.
+// Directories deactivate users this way.
+func (h *Handler) handleSCIMUserPatch(w http.ResponseWriter, r *http.Request) {
+	var req scimPatchRequest
+	if err := decodeSCIM(r, &req); err != nil {
+		h.writeSCIMAppError(w, err)
+		return
+	}
+	current, err := h.service.GetUser(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.writeSCIMAppError(w, err)
+		return
+	}
+
+	su := toSCIMUser(current.User)
+	for _, op := range req.Operations {
+		if !strings.EqualFold(op.Op, "replace") && !strings.EqualFold(op.Op, "add") {
+			writeSCIMError(w, http.StatusBadRequest, "invalidValue", "only add and replace operations are supported for users")
+			return
+		}
+		value := op.Value
+		if op.Path != "" {
+			// A path operation is the same as a value object with one attribute.
+			var err error
+			value, err = json.Marshal(map[string]json.RawMessage{op.Path: op.Value})
+			if err != nil {
+				writeSCIMError(w, http.StatusBadRequest, "invalidValue", "invalid value")
+				return
+			}
+		}
+		var attrs map[string]json.RawMessage
+		if err := json.Unmarshal(value, &attrs); err != nil {
+			writeSCIMError(w, http.StatusBadRequest, "invalidValue", "value must be an object")
+			return
+		}
+		for path, raw := range attrs {
+			var target any
+			var email string
+			switch lower := strings.ToLower(path); {
+			case strings.HasPrefix(lower, "emails[") && strings.HasSuffix(lower, "].value"):
+				// e.g. emails[type eq "work"].value; the service keeps one address.
+				target = &email
+			case lower == "active":
+				target = &su.Active
+			case lower == "displayname":
+				target = &su.DisplayName
+			case lower == "name.formatted":
+				su.DisplayName = ""
+				target = &su.Name.Formatted
+			case lower == "emails":
+				target = &su.Emails
+			default:
+				writeSCIMError(w, http.StatusBadRequest, "invalidPath", "attribute "+path+" cannot be changed")
+				return
+			}
+			if err := json.Unmarshal(raw, target); err != nil {
+				writeSCIMError(w, http.StatusBadRequest, "invalidValue", "invalid value of "+path)
+				return
+			}
+			if target == &email {
+				su.Emails = []scimEmail{{Value: email, Primary: true}}
+			}
+		}
+	}
+
+	du, err := su.directoryUser(current.ID)
+	if err != nil {
+		h.writeSCIMAppError(w, err)
+		return
+	}
+	user, err := h.service.SyncDirectoryUser(r.Context(), du)
+	if err != nil {
+		h.writeSCIMAppError(w, err)
+		return
+	}
+	writeSCIM(w, http.StatusOK, toSCIMUser(user))
+}
+
+func (h *Handler) handleSCIMUserDelete(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.service.DeleteUser(r.Context(), r.PathValue("id")); err != nil {
+		h.writeSCIMAppError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func toSCIMGroup(team app.Team) scimGroup {
+	g := scimGroup{
+		Schemas:     []string{scimGroupSchema},
+		ID:          team.Name,
+		DisplayName: team.Name,
+		Meta:        &scimMeta{ResourceType: "Group", Location: "/scim/v2/Groups/" + url.PathEscape(team.Name)},
+	}
+	for _, m := range team.Members {
+		g.Members = append(g.Members, scimMember{Value: m.ID, Display: m.Name})
+	}
+	return g
+}
+
+func scimMemberIDs(members []scimMember) []string {
+	ids := make([]string, 0, len(members))
+	for _, m := range members {
+		ids = append(ids, m.Value)
+	}
+	return ids
+}
+
+// handleSCIMGroupList lists teams without their members, which directories
+// fetch per group.
+func (h *Handler) handleSCIMGroupList(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, startIndex, err := scimPage(q)
+	if err != nil {
+		writeSCIMError(w, http.StatusBadRequest, "invalidValue", err.Error())
+		return
+	}
+
+	if filter := q.Get("filter"); filter != "" {
+		name, ok := parseSCIMFilter(filter, "displayName")
+		if !ok {
+			writeSCIMError(w, http.StatusBadRequest, "invalidFilter", `only displayName eq "value" filters are supported`)
+			return
+		}
+		var resources []any
+		team, err := h.service.GetTeam(r.Context(), name)
+		var appErr *app.Error
+		switch {
+		case err == nil:
+			resources = append(resources, toSCIMGroup(team))
+		case !errors.As(err, &appErr) || appErr.Code != app.ErrorCodeNotFound:
+			h.writeSCIMAppError(w, err)
+			return
+		}
+		writeSCIM(w, http.StatusOK, scimList(1, len(resources), resources))
+		return
+	}
+
+	if q.Has("count") && page.Limit == 0 {
+		writeSCIM(w, http.StatusOK, scimList(startIndex, 0, nil))
+		return
+	}
+	teams, total, _, err := h.service.ListTeams(r.Context(), page)
+	if err != nil {
+		h.writeSCIMAppError(w, err)
+		return
+	}
+	resources := make([]any, 0, len(teams))
+	for _, t := range teams {
+		resources = append(resources, toSCIMGroup(app.Team{Name: t.Name}))
+	}
+	writeSCIM(w, http.StatusOK, scimList(startIndex, total, resources))
+}
+
+func (h *Handler) handleSCIMGroupCreate(w http.ResponseWriter, r *http.Request) {
+	var g scimGroup
+	if err := decodeSCIM(r, &g); err != nil {
+		h.writeSCIMAppError(w, err)
+		return
+	}
+	if g.DisplayName == "" {
+		writeSCIMError(w, http.StatusBadRequest, "invalidValue", "displayName is required")
+		return
+	}
+
+	team, err := h.service.CreateTeam(r.Context(), app.Team{Name: g.DisplayName}, false)
+	if err != nil {
+		h.writeSCIMAppError(w, err)
+		return
+	}
+	if len(g.Members) > 0 {
+		team, err = h.service.UpdateTeamMembers(r.Context(), team.Name, scimMemberIDs(g.Members), nil)
+		if err != nil {
+			h.writeSCIMAppError(w, err)
+			return
+		}
+	}
+	writeSCIM(w, http.StatusCreated, toSCIMGroup(team))
+}
+
+func (h *Handler) handleSCIMGroupGet(w http.ResponseWriter, r *http.Request) {
+	team, err := h.service.GetTeam(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.writeSCIMAppError(w, err)
+		return
+	}
+	writeSCIM(w, http.StatusOK, toSCIMGroup(team))
+}
+
+func (h *Handler) handleSCIMGroupReplace(w http.ResponseWriter, r *http.Request) {
+	var g scimGroup
+	if err := decodeSCIM(r, &g); err != nil {
+		h.writeSCIMAppError(w, err)
+		return
+	}
+	h.setSCIMGroupMembers(w, r, g.DisplayName, func([]string) []string { return scimMemberIDs(g.Members) })
+}
+
+// handleSCIMGroupPatch applies add, remove and replace operations on
+// members. Renaming is not supported: team names are the keys of the API.
+func (h *Handler) handleSCIMGroupPatch(w http.ResponseWriter, r *http.Request) {
+	var req scimPatchRequest
+	if err := decodeSCIM(r, &req); err != nil {
+		h.writeSCIMAppError(w, err)
+		return
+	}
+
+	var displayName string
+	type change struct {
+		op  string
+		ids []string
+		// all removes every member.
+		all bool
+	}
+	var changes []change
+	for _, op := range req.Operations {
+		opName := strings.ToLower(op.Op)
+		path := op.Path
+		var members []scimMember
+		switch {
+		case path == "" && opName != "remove":
+			// A value object, e.g. {"members": [...]} or {"displayName": "x"}.
+			var value struct {
+				DisplayName string       `json:"displayName"`
+				Members     []scimMember `json:"members"`
+			}
+			if err := json.Unmarshal(op.Value, &value); err != nil {
+				writeSCIMError(w, http.StatusBadRequest, "invalidValue", "value must be an object")
+				return
+			}
+			displayName = value.DisplayName
+			members = value.Members
+			if value.Members == nil {
+				continue
+			}
+		case strings.EqualFold(path, "displayName"):
+			if err := json.Unmarshal(op.Value, &displayName); err != nil {
+				writeSCIMError(w, http.StatusBadRequest, "invalidValue", "displayName must be a string")
+				return
+			}
+			continue
+		case strings.EqualFold(path, "members"):
+			if len(op.Value) > 0 {
+				if err := json.Unmarshal(op.Value, &members); err != nil {
+					writeSCIMError(w, http.StatusBadRequest, "invalidValue", "members must be a list")
+					return
+				}
+			}
+		case opName == "remove" && strings.HasPrefix(strings.ToLower(path), "members["):
+			// members[value eq "u1"]
+			id, ok := parseSCIMFilter(strings.TrimSuffix(path[len("members["):], "]"), "value")
+			if !ok {
+				writeSCIMError(w, http.StatusBadRequest, "invalidPath", `only members[value eq "id"] paths are supported`)
+				return
+			}
+			members = []scimMember{{Value: id}}
+		default:
+			writeSCIMError(w, http.StatusBadRequest, "invalidPath", "only members can be changed")
+			return
+		}
+
+		switch opName {
+		case "add", "replace":
+			changes = append(changes, change{op: opName, ids: scimMemberIDs(members)})
+		case "remove":
+			changes = append(changes, change{op: opName, ids: scimMemberIDs(members), all: len(op.Value) == 0 && path == "members"})
+		default:
+			writeSCIMError(w, http.StatusBadRequest, "invalidValue", "unknown operation "+op.Op)
+			return
+		}
+	}
+
+	h.setSCIMGroupMembers(w, r, displayName, func(current []string) []string {
+		for _, c := range changes {
+			switch {
+			case c.op == "replace", c.all:
+				current = c.ids
+			case c.op == "add":
+				for _, id := range c.ids {
+					if !slices.Contains(current, id) {
+						current = append(current, id)
+					}
+				}
+			default:
+				current = slices.DeleteFunc(current, func(id string) bool { return slices.Contains(c.ids, id) })
+			}
+		}
+		return current
+	})
+}
+
+// setSCIMGroupMembers changes the members of the team in the path to the
+// list update returns for the current members and responds with the team.
+// A non-empty displayName must be the team name.
+func (h *Handler) setSCIMGroupMembers(w http.ResponseWriter, r *http.Request, displayName string, update func(current []string) []string) {
+	name := r.PathValue("id")
+	if displayName != "" && displayName != name {
+		writeSCIMError(w, http.StatusBadRequest, "mutability", "groups cannot be renamed")
+		return
+	}
+	team, err := h.service.GetTeam(r.Context(), name)
+	if err != nil {
+		h.writeSCIMAppError(w, err)
+		return
+	}
+
+	current := make([]string, 0, len(team.Members))
+	for _, m := range team.Members {
+		current = append(current, m.ID)
+	}
+	wanted := update(slices.Clone(current))
+	var add, remove []string
+	for _, id := range wanted {
+		if !slices.Contains(current, id) && !slices.Contains(add, id) {
+			add = append(add, id)
+		}
+	}
+	for _, id := range current {
+		if !slices.Contains(wanted, id) {
+			remove = append(remove, id)
+		}
+	}
+
+	if len(add) > 0 || len(remove) > 0 {
+		team, err = h.service.UpdateTeamMembers(r.Context(), name, add, remove)
+		if err != nil {
+			h.writeSCIMAppError(w, err)
+			return
+		}
+	}
+	writeSCIM(w, http.StatusOK, toSCIMGroup(team))
+}
+
+// handleSCIMGroupDelete deletes the team as POST /team/delete without force
+// does, deactivating its members.
+func (h *Handler) handleSCIMGroupDelete(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.service.DeleteTeam(r.Context(), r.PathValue("id"), false); err != nil {
+		h.writeSCIMAppError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
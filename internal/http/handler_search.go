@@ -0,0 +1,35 @@
+package httpserver
+
+import (
+	"fmt"
+	"net/http"
+	"review-assigner/internal/app"
+	"strconv"
+	"strings"
+)
+
+func (h *Handler) handleSearch(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := strings.TrimSpace(q.Get("q"))
+	if query == "" {
+		h.writeValidationError(w, "q", "q is required")
+		return
+	}
+	var limit int
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > app.MaxSearchLimit {
+			h.writeValidationError(w, "limit", fmt.Sprintf("limit must be between 1 and %d", app.MaxSearchLimit))
+			return
+		}
+		limit = n
+	}
+
+	results, err := h.service.Search(r.Context(), query, limit)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, results)
+}
@@ -0,0 +1,217 @@
+package httpserver
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"review-assigner/internal/app"
+	"review-assigner/internal/slack"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// slackSignatureTolerance is how old a signed Slack request may be; older
+// ones are rejected as possible replays.
+const slackSignatureTolerance = 5 * time.Minute
+
+// slackQueueLimit caps the pull requests listed by "my queue".
+const slackQueueLimit = 20
+
+// maxSlackCommandBody bounds the form Slack posts for a command.
+const maxSlackCommandBody = 64 << 10
+
+const slackCommandHelp = "Usage:\n" +
+	"• `my queue` — pull requests waiting for your review\n" +
+	"• `reassign PR-123 from @user` — hand a review over to another teammate\n" +
+	"• `stats team-1` — assignment stats of a team"
+
+// WithSlackSigningSecret enables the Slack slash command endpoint, which
+// accepts only requests signed with the app's signing secret.
+func WithSlackSigningSecret(secret string) Option {
+	return func(h *Handler) {
+		h.slackSigningSecret = secret
+	}
+}
+
+// slackCommandForm documents the fields of a Slack command form that are
+// used; Slack sends more.
+type slackCommandForm struct {
+	Command string `json:"command"`
+	Text    string `json:"text"`
+	UserID  string `json:"user_id"`
+}
+
+// slackCommandResponse is shown only to the user who ran the command.
+type slackCommandResponse struct {
+	ResponseType string `json:"response_type"`
+	Text         string `json:"text"`
+}
+
+func (h *Handler) handleSlackCommand(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+	}()
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxSlackCommandBody))
+	if err != nil {
+		h.writeValidationError(w, "", "cannot read request body")
+		return
+	}
+	if !verifySlackSignature(h.slackSigningSecret, r.Header, body, time.Now()) {
+		h.writeAppError(w, &app.Error{Code: app.ErrorCodeUnauthorized, Message: "invalid Slack signature"})
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		h.writeValidationError(w, "", "request body must be a form")
+		return
+	}
+
+	// Failures are answered with 200 too: Slack shows the text to the user
+	// and replaces any other status with a generic error.
+	text, err := h.runSlackCommand(r.Context(), form.Get("user_id"), form.Get("text"))
+	if err != nil {
+		var appErr *app.Error
+		if !errors.As(err, &appErr) {
+			h.writeAppError(w, err)
+			return
+		}
+		text = appErr.Message
+	}
+	writeJSON(w, http.StatusOK, slackCommandResponse{ResponseType: "ephemeral", Text: text})
+}
+
+// verifySlackSignature checks the X-Slack-Signature of a request body as
+// described in Slack's "Verifying requests from Slack".
+func verifySlackSignature(secret string, header http.Header, body []byte, now time.Time) bool {
+	ts, err := strconv.ParseInt(header.Get("X-Slack-Request-Timestamp"), 10, 64)
+	if err != nil {
+		return false
+	}
+	if age := now.Sub(time.Unix(ts, 0)); age > slackSignatureTolerance || age < -slackSignatureTolerance {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = fmt.Fprintf(mac, "v0:%d:%s", ts, body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(header.Get("X-Slack-Signature")))
+}
+
+// runSlackCommand executes a /review-assigner command on behalf of a Slack
+// member and returns the reply.
+func (h *Handler) runSlackCommand(ctx context.Context, slackUserID, text string) (string, error) {
+	args := strings.Fields(text)
+	switch {
+	case len(args) == 2 && args[0] == "my" && args[1] == "queue":
+		return h.slackQueue(ctx, slackUserID)
+	case len(args) == 4 && args[0] == "reassign" && args[2] == "from":
+		return h.slackReassign(ctx, slackUserID, args[1], args[3])
+	case len(args) == 2 && args[0] == "stats":
+		return h.slackStats(ctx, args[1])
+	}
+	return slackCommandHelp, nil
+}
+
+func (h *Handler) slackQueue(ctx context.Context, slackUserID string) (string, error) {
+	user, err := h.service.GetUserBySlackHandle(ctx, slackUserID)
+	if err != nil {
+		return "", err
+	}
+	prs, err := h.service.GetPendingReviews(ctx, user.ID)
+	if err != nil {
+		return "", err
+	}
+	if len(prs) == 0 {
+		return "No pull requests are waiting for your review.", nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Pull requests waiting for your review:\n")
+	for _, pr := range prs[:min(len(prs), slackQueueLimit)] {
+		fmt.Fprintf(&b, "• *%s* (`%s`, %s priority) by %s", slack.Escape(pr.Name), slack.Escape(pr.ID),
+			strings.ToLower(pr.Priority), slack.Escape(pr.AuthorID))
+		if pr.Deadline != nil {
+			fmt.Fprintf(&b, ", due %s", pr.Deadline.UTC().Format(time.RFC3339))
+		}
+		b.WriteString("\n")
+	}
+	if len(prs) > slackQueueLimit {
+		fmt.Fprintf(&b, "Only the first %d of %d are shown.", slackQueueLimit, len(prs))
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// slackReassign hands a review over on behalf of the reviewer or of a lead
+// of their team.
+func (h *Handler) slackReassign(ctx context.Context, slackUserID, prID, reviewer string) (string, error) {
+	caller, err := h.service.GetUserBySlackHandle(ctx, slackUserID)
+	if err != nil {
+		return "", err
+	}
+	userID, err := h.slackUser(ctx, reviewer)
+	if err != nil {
+		return "", err
+	}
+	if userID != caller.ID {
+		leads, err := h.service.LeadsTeamOf(ctx, caller.ID, userID)
+		if err != nil {
+			return "", err
+		}
+		if !leads {
+			return "", &app.Error{Code: app.ErrorCodeForbidden,
+				Message: "only the reviewer or a lead of their team can hand the review over"}
+		}
+	}
+	_, newUserID, _, err := h.service.ReassignReviewer(ctx, prID, userID)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("`%s` was reassigned from %s to %s.", slack.Escape(prID), slack.Escape(userID),
+		slack.Escape(newUserID)), nil
+}
+
+func (h *Handler) slackStats(ctx context.Context, teamName string) (string, error) {
+	stats, err := h.service.GetAssignmentStats(ctx, app.StatsFilter{TeamName: teamName})
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Stats of %s:", slack.Escape(teamName))
+	for _, team := range stats.ByTeam {
+		if team.TeamName == teamName {
+			fmt.Fprintf(&b, " %d pull requests (%d open), %d assignments, %.1f reviewers per pull request.",
+				team.PullRequests, team.OpenPullRequests, team.Assignments, team.AvgReviewersPerPullRequest)
+		}
+	}
+	for _, u := range stats.ByUser {
+		if u.OpenAssignments > 0 {
+			fmt.Fprintf(&b, "\n• %s: %d open, %d total", slack.Escape(u.UserID), u.OpenAssignments, u.TotalAssignments)
+		}
+	}
+	return b.String(), nil
+}
+
+// slackUser resolves a user named in a command: a Slack mention
+// (<@U123|name>), a Slack handle (@name) or one of our user IDs.
+func (h *Handler) slackUser(ctx context.Context, arg string) (string, error) {
+	if strings.HasPrefix(arg, "<@") && strings.HasSuffix(arg, ">") {
+		memberID, _, _ := strings.Cut(strings.TrimSuffix(strings.TrimPrefix(arg, "<@"), ">"), "|")
+		arg = memberID
+	} else if !strings.HasPrefix(arg, "@") {
+		return arg, nil
+	}
+	user, err := h.service.GetUserBySlackHandle(ctx, arg)
+	if err != nil {
+		return "", err
+	}
+	return user.ID, nil
+}
@@ -1,20 +1,282 @@
 package httpserver
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"review-assigner/internal/app"
+	"strconv"
+	"time"
 )
 
+// parseStatsFilter reads the filter parameters shared by the stats endpoints.
+func parseStatsFilter(q url.Values) (app.StatsFilter, error) {
+	filter := app.StatsFilter{
+		OrgName:  q.Get("org_name"),
+		TeamName: q.Get("team_name"),
+		Status:   q.Get("status"),
+	}
+	if filter.Status != "" && filter.Status != "OPEN" && filter.Status != "MERGED" {
+		return app.StatsFilter{}, errors.New("status must be OPEN or MERGED")
+	}
+
+	var err error
+	if filter.From, err = parseTimeParam(q.Get("from")); err != nil {
+		return app.StatsFilter{}, errors.New("from must be an RFC 3339 timestamp")
+	}
+	if filter.To, err = parseTimeParam(q.Get("to")); err != nil {
+		return app.StatsFilter{}, errors.New("to must be an RFC 3339 timestamp")
+	}
+	return filter, nil
+}
+
 func (h *Handler) handleStatsAssignments(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusMethodNotAllowed)
+	filter, err := parseStatsFilter(r.URL.Query())
+	if err != nil {
+		h.writeValidationError(w, "", err.Error())
 		return
 	}
+	asCSV, err := wantsCSV(r.URL.Query())
+	if err != nil {
+		h.writeValidationError(w, "", err.Error())
+		return
+	}
+	page, err := parsePage(r.URL.Query())
+	if err != nil {
+		h.writeValidationError(w, "", err.Error())
+		return
+	}
+	var topN int
+	if v := r.URL.Query().Get("top_n"); v != "" {
+		if topN, err = strconv.Atoi(v); err != nil || topN < 1 || topN > maxPageLimit {
+			h.writeValidationError(w, "top_n", fmt.Sprintf("top_n must be between 1 and %d", maxPageLimit))
+			return
+		}
+	}
 
-	stats, err := h.service.GetAssignmentStats(r.Context())
+	stats, err := h.service.GetAssignmentStats(r.Context(), filter)
 	if err != nil {
 		h.writeAppError(w, err)
 		return
 	}
+	stats = stats.Paginate(page, topN)
 
+	if asCSV {
+		h.writeAssignmentStatsCSV(w, r.URL.Query().Get("section"), stats)
+		return
+	}
+	// The ETag ignores computed_at, so that recomputed but unchanged stats
+	// still match.
+	content := stats
+	content.ComputedAt = time.Time{}
+	writeJSONWithContentETag(w, r, stats, content)
+}
+
+func (h *Handler) handleStatsLatency(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseStatsFilter(r.URL.Query())
+	if err != nil {
+		h.writeValidationError(w, "", err.Error())
+		return
+	}
+	asCSV, err := wantsCSV(r.URL.Query())
+	if err != nil {
+		h.writeValidationError(w, "", err.Error())
+		return
+	}
+
+	stats, err := h.service.GetLatencyStats(r.Context(), filter)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	if asCSV {
+		writeLatencyStatsCSV(w, stats)
+		return
+	}
 	writeJSON(w, http.StatusOK, stats)
 }
+
+func (h *Handler) handleStatsHistory(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := app.StatsHistoryFilter{
+		TeamName: q.Get("team_name"),
+		UserID:   q.Get("user_id"),
+	}
+
+	var err error
+	if filter.From, err = parseDateParam(q.Get("from")); err != nil {
+		h.writeValidationError(w, "from", "from must be a date in YYYY-MM-DD format")
+		return
+	}
+	if filter.To, err = parseDateParam(q.Get("to")); err != nil {
+		h.writeValidationError(w, "to", "to must be a date in YYYY-MM-DD format")
+		return
+	}
+
+	points, err := h.service.GetStatsHistory(r.Context(), filter)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"history": points,
+	})
+}
+
+func parseDateParam(v string) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (h *Handler) handleStatsReviewMatrix(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseStatsFilter(r.URL.Query())
+	if err != nil {
+		h.writeValidationError(w, "", err.Error())
+		return
+	}
+
+	matrices, err := h.service.GetReviewMatrix(r.Context(), filter)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"teams": matrices,
+	})
+}
+
+func (h *Handler) handleStatsPullRequest(w http.ResponseWriter, r *http.Request) {
+	prID := r.URL.Query().Get("pull_request_id")
+	if prID == "" {
+		h.writeValidationError(w, "pull_request_id", "pull_request_id is required")
+		return
+	}
+
+	stats, err := h.service.GetPullRequestStats(r.Context(), prID)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, stats)
+}
+
+func (h *Handler) handleStatsRefresh(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"invalidated": h.service.RefreshStats(),
+	})
+}
+
+func (h *Handler) handleStatsSLA(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseStatsFilter(r.URL.Query())
+	if err != nil {
+		h.writeValidationError(w, "", err.Error())
+		return
+	}
+
+	teams, err := h.service.GetSLACompliance(r.Context(), filter)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"teams": teams,
+	})
+}
+
+func (h *Handler) handleStatsVelocity(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseStatsFilter(r.URL.Query())
+	if err != nil {
+		h.writeValidationError(w, "", err.Error())
+		return
+	}
+
+	teams, err := h.service.GetTeamVelocity(r.Context(), filter)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"teams": teams,
+	})
+}
+
+func (h *Handler) handleStatsForecast(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := app.ForecastFilter{
+		OrgName:  q.Get("org_name"),
+		TeamName: q.Get("team_name"),
+	}
+	if v := q.Get("weeks"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > app.MaxForecastWeeks {
+			h.writeValidationError(w, "weeks", fmt.Sprintf("weeks must be between 1 and %d", app.MaxForecastWeeks))
+			return
+		}
+		filter.Weeks = n
+	}
+
+	teams, err := h.service.GetWorkloadForecast(r.Context(), filter)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"teams": teams,
+	})
+}
+
+func (h *Handler) handleStatsStrategies(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseStatsFilter(r.URL.Query())
+	if err != nil {
+		h.writeValidationError(w, "", err.Error())
+		return
+	}
+
+	strategies, err := h.service.GetStrategyStats(r.Context(), filter)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"strategies": strategies,
+	})
+}
+
+func (h *Handler) handleStatsStale(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := app.StaleFilter{
+		OrgName:  q.Get("org_name"),
+		TeamName: q.Get("team_name"),
+	}
+	if v := q.Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > app.MaxStaleDays {
+			h.writeValidationError(w, "days", fmt.Sprintf("days must be between 1 and %d", app.MaxStaleDays))
+			return
+		}
+		filter.Days = n
+	}
+
+	report, err := h.service.GetStalePullRequests(r.Context(), filter)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,139 @@
+package httpserver
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"review-assigner/internal/app"
+	"strconv"
+)
+
+// wantsCSV reports whether the stats response should be rendered as CSV.
+func wantsCSV(q url.Values) (bool, error) {
+	switch q.Get("format") {
+	case "", "json":
+		return false, nil
+	case "csv":
+		return true, nil
+	default:
+		return false, errors.New("format must be json or csv")
+	}
+}
+
+// writeCSV sends rows as a downloadable CSV file.
+func writeCSV(w http.ResponseWriter, filename string, header []string, rows [][]string) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	cw := csv.NewWriter(w)
+	_ = cw.Write(header)
+	_ = cw.WriteAll(rows)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// formatOptionalFloat renders a missing value as an empty cell.
+func formatOptionalFloat(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return formatFloat(*v)
+}
+
+// writeAssignmentStatsCSV renders one section of the assignment stats, since
+// the sections have different columns.
+func (h *Handler) writeAssignmentStatsCSV(w http.ResponseWriter, section string, stats app.AssignmentStats) {
+	var header []string
+	rows := make([][]string, 0)
+	switch section {
+	case "", "by_user":
+		section = "by_user"
+		header = []string{"user_id", "open_assignments", "total_assignments", "declines", "reassigned_away", "removed"}
+		for _, st := range stats.ByUser {
+			rows = append(rows, []string{
+				st.UserID,
+				strconv.Itoa(st.OpenAssignments),
+				strconv.Itoa(st.TotalAssignments),
+				strconv.Itoa(st.Declines),
+				strconv.Itoa(st.ReassignedAway),
+				strconv.Itoa(st.Removed),
+			})
+		}
+	case "by_pr":
+		header = []string{"pull_request_id", "assignments", "time_to_first_review_seconds", "time_to_approval_seconds"}
+		for _, st := range stats.ByPR {
+			rows = append(rows, []string{
+				st.PullRequestID,
+				strconv.Itoa(st.Assignments),
+				formatOptionalFloat(st.TimeToFirstReviewSeconds),
+				formatOptionalFloat(st.TimeToApprovalSeconds),
+			})
+		}
+	case "by_team":
+		header = []string{"team_name", "assignments", "pull_requests", "open_pull_requests", "avg_reviewers_per_pull_request"}
+		for _, st := range stats.ByTeam {
+			rows = append(rows, []string{
+				st.TeamName,
+				strconv.Itoa(st.Assignments),
+				strconv.Itoa(st.PullRequests),
+				strconv.Itoa(st.OpenPullRequests),
+				formatFloat(st.AvgReviewersPerPullRequest),
+			})
+		}
+	case "fairness":
+		header = []string{"team_name", "members", "assignments", "mean_load", "stddev", "gini"}
+		for _, st := range stats.Fairness {
+			rows = append(rows, []string{
+				st.TeamName,
+				strconv.Itoa(st.Members),
+				strconv.Itoa(st.Assignments),
+				formatFloat(st.MeanLoad),
+				formatFloat(st.StdDev),
+				formatFloat(st.Gini),
+			})
+		}
+	default:
+		h.writeValidationError(w, "section", "section must be one of by_user, by_pr, by_team, fairness")
+		return
+	}
+
+	writeCSV(w, "assignments_"+section+".csv", header, rows)
+}
+
+// writeLatencyStatsCSV renders team and user latencies as one table told
+// apart by the scope column.
+func writeLatencyStatsCSV(w http.ResponseWriter, stats app.LatencyStats) {
+	header := []string{
+		"scope", "name",
+		"approval_samples", "approval_avg_seconds", "approval_median_seconds", "approval_p90_seconds",
+		"merge_samples", "merge_avg_seconds", "merge_median_seconds", "merge_p90_seconds",
+	}
+	row := func(scope, name string, approval, merge app.LatencySummary) []string {
+		return []string{
+			scope, name,
+			strconv.Itoa(approval.Samples),
+			formatOptionalFloat(approval.AvgSeconds),
+			formatOptionalFloat(approval.MedianSeconds),
+			formatOptionalFloat(approval.P90Seconds),
+			strconv.Itoa(merge.Samples),
+			formatOptionalFloat(merge.AvgSeconds),
+			formatOptionalFloat(merge.MedianSeconds),
+			formatOptionalFloat(merge.P90Seconds),
+		}
+	}
+
+	rows := make([][]string, 0, len(stats.ByTeam)+len(stats.ByUser))
+	for _, st := range stats.ByTeam {
+		rows = append(rows, row("team", st.TeamName, st.TimeToFirstApproval, st.TimeToMerge))
+	}
+	for _, st := range stats.ByUser {
+		rows = append(rows, row("user", st.UserID, st.TimeToFirstApproval, st.TimeToMerge))
+	}
+
+	writeCSV(w, "latency.csv", header, rows)
+}
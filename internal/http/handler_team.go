@@ -1,28 +1,28 @@
 package httpserver
 
 import (
-	"encoding/json"
 	"net/http"
 	"review-assigner/internal/app"
 )
 
-func (h *Handler) handleTeamAdd(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		return
-	}
+type teamAddRequest struct {
+	app.Team
+	// Transfer allows moving users that already belong to another team.
+	Transfer bool `json:"transfer"`
+}
 
+func (h *Handler) handleTeamAdd(w http.ResponseWriter, r *http.Request) {
 	defer func() {
 		_ = r.Body.Close()
 	}()
 
-	var req app.Team
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid JSON", http.StatusBadRequest)
+	var req teamAddRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.writeAppError(w, err)
 		return
 	}
 
-	team, err := h.service.CreateTeam(r.Context(), req)
+	team, err := h.service.CreateTeam(r.Context(), req.Team, req.Transfer)
 	if err != nil {
 		h.writeAppError(w, err)
 		return
@@ -33,53 +33,179 @@ func (h *Handler) handleTeamAdd(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handleTeamGet serves both /team/get?team_name= and /team/{name}.
 func (h *Handler) handleTeamGet(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusMethodNotAllowed)
+	if r.PathValue("name") != "" && rejectOperationPath(w, r) {
+		return
+	}
+	name := r.PathValue("name")
+	if name == "" {
+		name = r.URL.Query().Get("team_name")
+	}
+	if name == "" {
+		h.writeValidationError(w, "team_name", "team_name is required")
 		return
 	}
 
+	switch include := r.URL.Query().Get("include"); include {
+	case "":
+		team, err := h.service.GetTeam(r.Context(), name)
+		if err != nil {
+			h.writeAppError(w, err)
+			return
+		}
+		writeJSONWithETag(w, r, team)
+	case "load":
+		team, err := h.service.GetTeamWithLoad(r.Context(), name)
+		if err != nil {
+			h.writeAppError(w, err)
+			return
+		}
+		writeJSONWithETag(w, r, team)
+	default:
+		h.writeValidationError(w, "include", "include must be load")
+	}
+}
+
+func (h *Handler) handleTeamList(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, err := parseCursorPage(q)
+	if err != nil {
+		h.writeValidationError(w, "", err.Error())
+		return
+	}
+	fields, err := parseFields(q, app.TeamSummary{})
+	if err != nil {
+		h.writeValidationError(w, "fields", err.Error())
+		return
+	}
+
+	teams, total, next, err := h.service.ListTeams(r.Context(), page)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"teams":       selectFields(teams, fields),
+		"total":       total,
+		"next_cursor": nextCursor(next),
+	})
+}
+
+func (h *Handler) handleTeamHistory(w http.ResponseWriter, r *http.Request) {
 	name := r.URL.Query().Get("team_name")
 	if name == "" {
-		http.Error(w, "team_name is required", http.StatusBadRequest)
+		h.writeValidationError(w, "team_name", "team_name is required")
 		return
 	}
 
-	team, err := h.service.GetTeam(r.Context(), name)
+	events, err := h.service.GetMembershipHistory(r.Context(), name)
 	if err != nil {
 		h.writeAppError(w, err)
 		return
 	}
 
-	writeJSON(w, http.StatusOK, team)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"team_name": name,
+		"events":    events,
+	})
 }
 
 type teamDeactivateMembersRequest struct {
 	TeamName string `json:"team_name"`
+	// UserIDs limits deactivation to some members; omitted means all of them.
+	UserIDs []string `json:"user_ids"`
 }
 
 func (h *Handler) handleTeamDeactivateMembers(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		w.WriteHeader(http.StatusMethodNotAllowed)
+	defer func() {
+		_ = r.Body.Close()
+	}()
+
+	var req teamDeactivateMembersRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	if req.TeamName == "" {
+		h.writeValidationError(w, "team_name", "team_name is required")
 		return
 	}
 
+	if req.UserIDs != nil && len(req.UserIDs) == 0 {
+		h.writeValidationError(w, "user_ids", "user_ids must not be empty")
+		return
+	}
+
+	team, err := h.service.DeactivateTeamMembers(r.Context(), req.TeamName, req.UserIDs)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"team": team,
+	})
+}
+
+type teamDeleteRequest struct {
+	TeamName string `json:"team_name"`
+	Force    bool   `json:"force"`
+}
+
+func (h *Handler) handleTeamDelete(w http.ResponseWriter, r *http.Request) {
 	defer func() {
 		_ = r.Body.Close()
 	}()
 
-	var req teamDeactivateMembersRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid JSON", http.StatusBadRequest)
+	var req teamDeleteRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	if req.TeamName == "" {
+		h.writeValidationError(w, "team_name", "team_name is required")
+		return
+	}
+
+	result, err := h.service.DeleteTeam(r.Context(), req.TeamName, req.Force)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+type teamRenameRequest struct {
+	TeamName    string `json:"team_name"`
+	NewTeamName string `json:"new_team_name"`
+}
+
+func (h *Handler) handleTeamRename(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+	}()
+
+	var req teamRenameRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.writeAppError(w, err)
 		return
 	}
 
 	if req.TeamName == "" {
-		http.Error(w, "team_name is required", http.StatusBadRequest)
+		h.writeValidationError(w, "team_name", "team_name is required")
+		return
+	}
+	if req.NewTeamName == "" {
+		h.writeValidationError(w, "new_team_name", "new_team_name is required")
 		return
 	}
 
-	team, err := h.service.DeactivateTeamMembers(r.Context(), req.TeamName)
+	team, err := h.service.RenameTeam(r.Context(), req.TeamName, req.NewTeamName)
 	if err != nil {
 		h.writeAppError(w, err)
 		return
@@ -89,3 +215,122 @@ func (h *Handler) handleTeamDeactivateMembers(w http.ResponseWriter, r *http.Req
 		"team": team,
 	})
 }
+
+// maxJiraTransitionLen bounds jira_merge_transition; Jira transition IDs
+// are short numbers.
+const maxJiraTransitionLen = 64
+
+type teamSettingsRequest struct {
+	TeamName          string  `json:"team_name"`
+	ReviewerCount     *int    `json:"reviewer_count"`
+	Strategy          *string `json:"strategy"`
+	SLAHours          *int    `json:"sla_hours"`
+	RequiredApprovals *int    `json:"required_approvals"`
+	MaxOpenReviews    *int    `json:"max_open_reviews"`
+	StrictMode        *bool   `json:"strict_mode"`
+	ExcludeLeads      *bool   `json:"exclude_leads"`
+	RequireSenior     *bool   `json:"require_senior"`
+	OrgFallback       *bool   `json:"org_fallback"`
+
+	JiraCommentOnMerge  *bool   `json:"jira_comment_on_merge"`
+	JiraMergeTransition *string `json:"jira_merge_transition"`
+}
+
+func (h *Handler) handleTeamSettingsGet(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("team_name")
+	if name == "" {
+		h.writeValidationError(w, "team_name", "team_name is required")
+		return
+	}
+
+	settings, err := h.service.GetTeamSettings(r.Context(), name)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"settings": settings,
+	})
+}
+
+func (h *Handler) handleTeamSettingsUpdate(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+	}()
+
+	var req teamSettingsRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	if req.TeamName == "" {
+		h.writeValidationError(w, "team_name", "team_name is required")
+		return
+	}
+	if req.ReviewerCount != nil && (*req.ReviewerCount < 0 || *req.ReviewerCount > app.MaxReviewerCount) {
+		h.writeValidationError(w, "reviewer_count", "reviewer_count must be between 0 and 10")
+		return
+	}
+	if req.Strategy != nil && !app.IsValidTeamStrategy(*req.Strategy) {
+		h.writeValidationError(w, "strategy", "strategy must be one of TEAM_ORDER, RANDOM")
+		return
+	}
+	if req.SLAHours != nil && *req.SLAHours < 0 {
+		h.writeValidationError(w, "sla_hours", "sla_hours must not be negative")
+		return
+	}
+	if req.RequiredApprovals != nil && (*req.RequiredApprovals < 0 || *req.RequiredApprovals > app.MaxReviewerCount) {
+		h.writeValidationError(w, "required_approvals", "required_approvals must be between 0 and 10")
+		return
+	}
+	if req.MaxOpenReviews != nil && *req.MaxOpenReviews < 0 {
+		h.writeValidationError(w, "max_open_reviews", "max_open_reviews must not be negative")
+		return
+	}
+	if req.JiraMergeTransition != nil && len(*req.JiraMergeTransition) > maxJiraTransitionLen {
+		h.writeValidationError(w, "jira_merge_transition", "jira_merge_transition is too long")
+		return
+	}
+
+	settings, err := h.service.UpdateTeamSettings(r.Context(), app.TeamSettingsUpdate{
+		TeamName:          req.TeamName,
+		ReviewerCount:     req.ReviewerCount,
+		Strategy:          req.Strategy,
+		SLAHours:          req.SLAHours,
+		RequiredApprovals: req.RequiredApprovals,
+		MaxOpenReviews:    req.MaxOpenReviews,
+		StrictMode:        req.StrictMode,
+		ExcludeLeads:      req.ExcludeLeads,
+		RequireSenior:     req.RequireSenior,
+		OrgFallback:       req.OrgFallback,
+
+		JiraCommentOnMerge:  req.JiraCommentOnMerge,
+		JiraMergeTransition: req.JiraMergeTransition,
+	})
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"settings": settings,
+	})
+}
+
+func (h *Handler) handleTeamCapacity(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("team_name")
+	if name == "" {
+		h.writeValidationError(w, "team_name", "team_name is required")
+		return
+	}
+
+	capacity, err := h.service.GetTeamCapacity(r.Context(), name)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, capacity)
+}
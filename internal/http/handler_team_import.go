@@ -0,0 +1,141 @@
+package httpserver
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"review-assigner/internal/app"
+	"strconv"
+)
+
+type teamImportRequest struct {
+	Teams    []app.Team `json:"teams"`
+	Transfer bool       `json:"transfer"`
+}
+
+func (h *Handler) handleTeamImport(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+	}()
+
+	var teams []app.Team
+	var transfer bool
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "text/csv" {
+		var err error
+		teams, err = parseTeamsCSV(r.Body)
+		if err != nil {
+			h.writeValidationError(w, "", err.Error())
+			return
+		}
+		// CSV has no room for options, so transfer comes from the query string.
+		if v := r.URL.Query().Get("transfer"); v != "" {
+			transfer, err = strconv.ParseBool(v)
+			if err != nil {
+				h.writeValidationError(w, "transfer", "transfer must be a boolean")
+				return
+			}
+		}
+	} else {
+		var req teamImportRequest
+		if err := decodeJSON(r.Body, &req); err != nil {
+			h.writeAppError(w, err)
+			return
+		}
+		teams, transfer = req.Teams, req.Transfer
+	}
+
+	if len(teams) == 0 {
+		h.writeValidationError(w, "teams", "teams are required")
+		return
+	}
+
+	results, err := h.service.ImportTeams(r.Context(), teams, transfer)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	created := 0
+	for _, res := range results {
+		if res.Status == app.TeamImportCreated {
+			created++
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"results": results,
+		"created": created,
+		"failed":  len(results) - created,
+	})
+}
+
+// parseTeamsCSV reads one member per row. The header must contain team_name,
+// user_id and username; is_active (default true) and role are optional.
+// Teams are returned in order of their first row.
+func parseTeamsCSV(body io.Reader) ([]app.Team, error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[name] = i
+	}
+	for _, name := range []string{"team_name", "user_id", "username"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("CSV header must contain %s", name)
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok {
+			return record[i]
+		}
+		return ""
+	}
+
+	var teams []app.Team
+	index := make(map[string]int)
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+
+		member := app.TeamMember{
+			ID:       field(record, "user_id"),
+			Name:     field(record, "username"),
+			IsActive: true,
+			Role:     field(record, "role"),
+		}
+		if v := field(record, "is_active"); v != "" {
+			member.IsActive, err = strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: is_active must be a boolean", line)
+			}
+		}
+
+		name := field(record, "team_name")
+		i, ok := index[name]
+		if !ok {
+			i = len(teams)
+			index[name] = i
+			teams = append(teams, app.Team{Name: name})
+		}
+		teams[i].Members = append(teams[i].Members, member)
+	}
+	return teams, nil
+}
@@ -1,37 +1,153 @@
 package httpserver
 
 import (
-	"encoding/json"
+	"fmt"
 	"net/http"
+	"review-assigner/internal/app"
+	"strings"
 )
 
+type createUserRequest struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	TeamName string `json:"team_name"`
+	IsActive *bool  `json:"is_active"`
+	Role     string `json:"role"`
+	app.Profile
+}
+
+func (h *Handler) handleUserCreate(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+	}()
+
+	var req createUserRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	if req.UserID == "" {
+		h.writeValidationError(w, "user_id", "user_id is required")
+		return
+	}
+	if req.Username == "" {
+		h.writeValidationError(w, "username", "username is required")
+		return
+	}
+	if req.Role != "" && !app.IsValidRole(req.Role) {
+		h.writeValidationError(w, "role", "role must be one of LEAD, SENIOR, MEMBER")
+		return
+	}
+	if msg := app.ValidateProfile(req.Profile); msg != "" {
+		h.writeValidationError(w, "", msg)
+		return
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	user, err := h.service.CreateUser(r.Context(), app.User{
+		ID:       req.UserID,
+		Name:     req.Username,
+		TeamName: req.TeamName,
+		IsActive: isActive,
+		Role:     req.Role,
+		Profile:  req.Profile,
+	})
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"user": user,
+	})
+}
+
+func (h *Handler) handleUserGet(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		h.writeValidationError(w, "user_id", "user_id is required")
+		return
+	}
+
+	user, err := h.service.GetUser(r.Context(), userID)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"user": user,
+	})
+}
+
 type setIsActiveRequest struct {
 	UserID   string `json:"user_id"`
 	IsActive bool   `json:"is_active"`
 }
 
 func (h *Handler) handleUserSetIsActive(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		w.WriteHeader(http.StatusMethodNotAllowed)
+	defer func() {
+		_ = r.Body.Close()
+	}()
+
+	var req setIsActiveRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	if req.UserID == "" {
+		h.writeValidationError(w, "user_id", "user_id is required")
+		return
+	}
+
+	if err := h.authorizeSelf(r, req.UserID); err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	user, err := h.service.SetUserIsActive(r.Context(), req.UserID, req.IsActive)
+	if err != nil {
+		h.writeAppError(w, err)
 		return
 	}
 
+	writeJSON(w, http.StatusOK, map[string]any{
+		"user": user,
+	})
+}
+
+type setRoleRequest struct {
+	UserID string `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+func (h *Handler) handleUserSetRole(w http.ResponseWriter, r *http.Request) {
 	defer func() {
 		_ = r.Body.Close()
 	}()
 
-	var req setIsActiveRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid JSON", http.StatusBadRequest)
+	var req setRoleRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.writeAppError(w, err)
 		return
 	}
 
 	if req.UserID == "" {
-		http.Error(w, "user_id is required", http.StatusBadRequest)
+		h.writeValidationError(w, "user_id", "user_id is required")
+		return
+	}
+	if !app.IsValidRole(req.Role) {
+		h.writeValidationError(w, "role", "role must be one of LEAD, SENIOR, MEMBER")
 		return
 	}
 
-	user, err := h.service.SetUserIsActive(r.Context(), req.UserID, req.IsActive)
+	user, err := h.service.SetUserRole(r.Context(), req.UserID, req.Role)
 	if err != nil {
 		h.writeAppError(w, err)
 		return
@@ -42,26 +158,322 @@ func (h *Handler) handleUserSetIsActive(w http.ResponseWriter, r *http.Request)
 	})
 }
 
-func (h *Handler) handleUserGetReview(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusMethodNotAllowed)
+type updateProfileRequest struct {
+	UserID      string  `json:"user_id"`
+	Email       *string `json:"email"`
+	SlackHandle *string `json:"slack_handle"`
+	AvatarURL   *string `json:"avatar_url"`
+	GitHubLogin *string `json:"github_login"`
+}
+
+func (h *Handler) handleUserUpdateProfile(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+	}()
+
+	var req updateProfileRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	if req.UserID == "" {
+		h.writeValidationError(w, "user_id", "user_id is required")
+		return
+	}
+
+	if err := h.authorizeSelf(r, req.UserID); err != nil {
+		h.writeAppError(w, err)
 		return
 	}
 
+	var profile app.Profile
+	if req.Email != nil {
+		profile.Email = *req.Email
+	}
+	if req.SlackHandle != nil {
+		profile.SlackHandle = *req.SlackHandle
+	}
+	if req.AvatarURL != nil {
+		profile.AvatarURL = *req.AvatarURL
+	}
+	if req.GitHubLogin != nil {
+		profile.GitHubLogin = *req.GitHubLogin
+	}
+	if msg := app.ValidateProfile(profile); msg != "" {
+		h.writeValidationError(w, "", msg)
+		return
+	}
+
+	user, err := h.service.UpdateUserProfile(r.Context(), app.ProfileUpdate{
+		UserID:      req.UserID,
+		Email:       req.Email,
+		SlackHandle: req.SlackHandle,
+		AvatarURL:   req.AvatarURL,
+		GitHubLogin: req.GitHubLogin,
+	})
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"user": user,
+	})
+}
+
+type notificationPreferencesRequest struct {
+	UserID      string `json:"user_id"`
+	Slack       *bool  `json:"slack"`
+	Email       *bool  `json:"email"`
+	EmailDigest *bool  `json:"email_digest"`
+}
+
+func (h *Handler) handleUserNotificationsGet(w http.ResponseWriter, r *http.Request) {
 	userID := r.URL.Query().Get("user_id")
 	if userID == "" {
-		http.Error(w, "user_id is required", http.StatusBadRequest)
+		h.writeValidationError(w, "user_id", "user_id is required")
+		return
+	}
+
+	if err := h.authorizeSelf(r, userID); err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	prefs, err := h.service.GetNotificationPreferences(r.Context(), userID)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"preferences": prefs,
+	})
+}
+
+func (h *Handler) handleUserNotificationsUpdate(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+	}()
+
+	var req notificationPreferencesRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.writeAppError(w, err)
 		return
 	}
 
-	prs, err := h.service.GetUserReviews(r.Context(), userID)
+	if req.UserID == "" {
+		h.writeValidationError(w, "user_id", "user_id is required")
+		return
+	}
+
+	if err := h.authorizeSelf(r, req.UserID); err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	prefs, err := h.service.UpdateNotificationPreferences(r.Context(), app.NotificationPreferencesUpdate{
+		UserID:      req.UserID,
+		Slack:       req.Slack,
+		Email:       req.Email,
+		EmailDigest: req.EmailDigest,
+	})
 	if err != nil {
 		h.writeAppError(w, err)
 		return
 	}
 
 	writeJSON(w, http.StatusOK, map[string]any{
+		"preferences": prefs,
+	})
+}
+
+func (h *Handler) handleUserGetReview(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	userID := q.Get("user_id")
+	if userID == "" {
+		h.writeValidationError(w, "user_id", "user_id is required")
+		return
+	}
+	if err := h.authorizeSelf(r, userID); err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	page, err := parseCursorPage(q)
+	if err != nil {
+		h.writeValidationError(w, "", err.Error())
+		return
+	}
+	fields, err := parseFields(q, app.PullRequestShort{})
+	if err != nil {
+		h.writeValidationError(w, "fields", err.Error())
+		return
+	}
+
+	prs, next, err := h.service.GetUserReviews(r.Context(), userID, page)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSONWithETag(w, r, map[string]any{
 		"user_id":       userID,
-		"pull_requests": prs,
+		"pull_requests": selectFields(prs, fields),
+		"next_cursor":   nextCursor(next),
+	})
+}
+
+type userReviewBatchRequest struct {
+	UserIDs []string `json:"user_ids"`
+}
+
+func (h *Handler) handleUserGetReviewBatch(w http.ResponseWriter, r *http.Request) {
+	var userIDs []string
+	for _, id := range strings.Split(r.URL.Query().Get("user_ids"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			userIDs = append(userIDs, id)
+		}
+	}
+	h.writeUserReviewBatch(w, r, userIDs)
+}
+
+func (h *Handler) handleUserPostReviewBatch(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+	}()
+
+	var req userReviewBatchRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+	h.writeUserReviewBatch(w, r, req.UserIDs)
+}
+
+// writeUserReviewBatch validates the requested users and writes their queues.
+func (h *Handler) writeUserReviewBatch(w http.ResponseWriter, r *http.Request, userIDs []string) {
+	if len(userIDs) == 0 {
+		h.writeValidationError(w, "user_ids", "user_ids is required")
+		return
+	}
+	userIDs = app.UniqueUserIDs(userIDs)
+	if len(userIDs) > app.MaxReviewBatchUsers {
+		h.writeValidationError(w, "user_ids", fmt.Sprintf("user_ids must name at most %d users", app.MaxReviewBatchUsers))
+		return
+	}
+	for _, id := range userIDs {
+		if id == "" {
+			h.writeValidationError(w, "user_ids", "user_ids must not contain empty IDs")
+			return
+		}
+		if err := h.authorizeSelf(r, id); err != nil {
+			h.writeAppError(w, err)
+			return
+		}
+	}
+
+	reviews, err := h.service.GetUserReviewsBatch(r.Context(), userIDs)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSONWithETag(w, r, map[string]any{
+		"users": reviews,
 	})
 }
+
+func (h *Handler) handleUserList(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, err := parseCursorPage(q)
+	if err != nil {
+		h.writeValidationError(w, "", err.Error())
+		return
+	}
+	fields, err := parseFields(q, app.User{})
+	if err != nil {
+		h.writeValidationError(w, "fields", err.Error())
+		return
+	}
+
+	users, next, err := h.service.ListUsers(r.Context(), q.Get("team_name"), page)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"users":       selectFields(users, fields),
+		"next_cursor": nextCursor(next),
+	})
+}
+
+type deleteUserRequest struct {
+	UserID string `json:"user_id"`
+}
+
+func (h *Handler) handleUserDelete(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+	}()
+
+	var req deleteUserRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	if req.UserID == "" {
+		h.writeValidationError(w, "user_id", "user_id is required")
+		return
+	}
+
+	result, err := h.service.DeleteUser(r.Context(), req.UserID)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+type feedTokenRequest struct {
+	UserID string `json:"user_id"`
+}
+
+type feedTokenResponse struct {
+	UserID string `json:"user_id"`
+	// Token is returned only here; issuing a new one revokes it.
+	Token string `json:"token"`
+}
+
+func (h *Handler) handleUserFeedToken(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+	}()
+
+	var req feedTokenRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	if req.UserID == "" {
+		h.writeValidationError(w, "user_id", "user_id is required")
+		return
+	}
+	if err := h.authorizeSelf(r, req.UserID); err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	token, err := h.service.IssueFeedToken(r.Context(), req.UserID)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, feedTokenResponse{UserID: req.UserID, Token: token})
+}
@@ -0,0 +1,115 @@
+package httpserver
+
+import (
+	"net/http"
+
+	"review-assigner/internal/app"
+)
+
+type createWebhookRequest struct {
+	URL string `json:"url"`
+	// Secret is generated when omitted.
+	Secret string `json:"secret"`
+}
+
+type webhookIDRequest struct {
+	SubscriptionID int64 `json:"subscription_id"`
+}
+
+type createWebhookResponse struct {
+	Webhook app.WebhookSubscription `json:"webhook"`
+	// Secret is only ever returned here.
+	Secret string `json:"secret"`
+}
+
+func (h *Handler) handleWebhookCreate(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+	}()
+
+	var req createWebhookRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	if !app.IsValidWebhookURL(req.URL) {
+		h.writeValidationError(w, "url", "url must be an absolute http or https URL")
+		return
+	}
+	if req.Secret != "" && len(req.Secret) < app.MinWebhookSecretLength {
+		h.writeValidationError(w, "secret", "secret must be at least 16 characters")
+		return
+	}
+
+	sub, secret, err := h.service.CreateWebhook(r.Context(), req.URL, req.Secret)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, createWebhookResponse{Webhook: sub, Secret: secret})
+}
+
+func (h *Handler) handleWebhookList(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.service.ListWebhooks(r.Context())
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"webhooks": subs,
+	})
+}
+
+func (h *Handler) handleWebhookDelete(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+	}()
+
+	var req webhookIDRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+	if req.SubscriptionID == 0 {
+		h.writeValidationError(w, "subscription_id", "subscription_id is required")
+		return
+	}
+
+	if err := h.service.DeleteWebhook(r.Context(), req.SubscriptionID); err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"subscription_id": req.SubscriptionID,
+	})
+}
+
+// handleWebhookTest sends a signed ping so that a receiver can check its
+// signature verification. A failed delivery is reported in the body.
+func (h *Handler) handleWebhookTest(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		_ = r.Body.Close()
+	}()
+
+	var req webhookIDRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+	if req.SubscriptionID == 0 {
+		h.writeValidationError(w, "subscription_id", "subscription_id is required")
+		return
+	}
+
+	test, err := h.service.TestWebhook(r.Context(), req.SubscriptionID)
+	if err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, test)
+}
@@ -0,0 +1,131 @@
+package httpserver
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"review-assigner/internal/app"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const metricsNamespace = "review_assigner"
+
+// metricsCollectTimeout bounds the database queries made on every scrape.
+const metricsCollectTimeout = 5 * time.Second
+
+// metrics holds Prometheus collectors of a handler. Every handler gets its own
+// registry so that several handlers can live in one process.
+type metrics struct {
+	registry         *prometheus.Registry
+	requests         *prometheus.CounterVec
+	requestDuration  *prometheus.HistogramVec
+	requestsInFlight *prometheus.GaugeVec
+	appErrors        *prometheus.CounterVec
+	reassignments    prometheus.Counter
+}
+
+func newMetrics(service *app.Service, logger *slog.Logger) *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by route pattern and status code.",
+		}, []string{"endpoint", "code"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route pattern and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"endpoint", "code"}),
+		requestsInFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_requests_in_flight",
+			Help:      "HTTP requests being served by route pattern, including open WebSocket connections.",
+		}, []string{"endpoint"}),
+		appErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "app_errors_total",
+			Help:      "Application errors returned to clients by error code, e.g. NO_CANDIDATE.",
+		}, []string{"code"}),
+		reassignments: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "reassignments_total",
+			Help:      "Successful reviewer reassignments.",
+		}),
+	}
+	m.registry.MustRegister(
+		m.requests,
+		m.requestDuration,
+		m.requestsInFlight,
+		m.appErrors,
+		m.reassignments,
+		&businessCollector{service: service, logger: logger},
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+func (m *metrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// startRequest counts a request in flight until the returned function
+// records its outcome.
+func (m *metrics) startRequest(endpoint string) func(status int, d time.Duration) {
+	inFlight := m.requestsInFlight.WithLabelValues(endpoint)
+	inFlight.Inc()
+	return func(status int, d time.Duration) {
+		inFlight.Dec()
+		code := strconv.Itoa(status)
+		m.requests.WithLabelValues(endpoint, code).Inc()
+		m.requestDuration.WithLabelValues(endpoint, code).Observe(d.Seconds())
+	}
+}
+
+var (
+	openPullRequestsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "", "open_pull_requests"),
+		"Open pull requests.",
+		nil, nil,
+	)
+	openAssignmentsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "", "open_assignments"),
+		"Open review assignments by reviewer team.",
+		[]string{"team"}, nil,
+	)
+)
+
+// businessCollector reads business gauges from the database on every scrape.
+type businessCollector struct {
+	service *app.Service
+	logger  *slog.Logger
+}
+
+func (c *businessCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- openPullRequestsDesc
+	ch <- openAssignmentsDesc
+}
+
+func (c *businessCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), metricsCollectTimeout)
+	defer cancel()
+
+	bm, err := c.service.GetBusinessMetrics(ctx)
+	if err != nil {
+		c.logger.Error("collect business metrics", "error", err)
+		ch <- prometheus.NewInvalidMetric(openPullRequestsDesc, err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(openPullRequestsDesc, prometheus.GaugeValue, float64(bm.OpenPullRequests))
+	for team, n := range bm.OpenAssignmentsByTeam {
+		ch <- prometheus.MustNewConstMetric(openAssignmentsDesc, prometheus.GaugeValue, float64(n), team)
+	}
+}
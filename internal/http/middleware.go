@@ -0,0 +1,191 @@
+package httpserver
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"review-assigner/internal/app"
+	"strings"
+	"time"
+)
+
+// clientHeader identifies the calling client (bot, dashboard, team) for usage analytics.
+const clientHeader = "X-Client-ID"
+
+const maxClientNameLen = app.MaxClientNameLength
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(p []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(p)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the connection, e.g. to hijack
+// it for a WebSocket.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// WithLogger sets the logger of request lines and internal errors; the
+// default is slog.Default().
+func WithLogger(l *slog.Logger) Option {
+	return func(h *Handler) {
+		h.logger = l
+	}
+}
+
+// WithAccessLog writes an access log entry per request to w as JSON lines,
+// separately from the application log, e.g. to an accesslog.Writer for
+// deployments that keep a request audit trail on disk.
+func WithAccessLog(w io.Writer) Option {
+	return func(h *Handler) {
+		h.accessLog = slog.New(slog.NewJSONHandler(w, nil))
+	}
+}
+
+// withRequestLog logs one line per request and hands the service a logger
+// carrying the request ID, so that its log lines can be matched to requests.
+func (h *Handler) withRequestLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger := h.logger.With("request_id", requestID(w))
+		r = r.WithContext(app.ContextWithLogger(contextWithCaller(r.Context()), logger))
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		level := slog.LevelInfo
+		if rec.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		logger.LogAttrs(r.Context(), level, "request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.status),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("user_agent", r.UserAgent()),
+			slog.String("client", clientName(r)),
+			slog.String("user_id", callerUserID(r)),
+		)
+
+		if h.accessLog != nil {
+			h.accessLog.LogAttrs(r.Context(), slog.LevelInfo, "access",
+				slog.String("request_id", requestID(w)),
+				slog.String("remote_addr", r.RemoteAddr),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.String("query", redactQuery(r.URL)),
+				slog.String("proto", r.Proto),
+				slog.Int("status", rec.status),
+				slog.Int64("bytes", rec.bytes),
+				slog.Duration("duration", time.Since(start)),
+				slog.String("user_agent", r.UserAgent()),
+				slog.String("referer", r.Referer()),
+				slog.String("client", clientName(r)),
+				slog.String("user_id", callerUserID(r)),
+			)
+		}
+	})
+}
+
+// WithRequestTimeout gives every request a deadline, so that a stuck
+// database query does not hold a connection; handlers that run out of time
+// answer 504 TIMEOUT. Unlike the server's write timeout, the deadline
+// reaches the queries through the request context.
+func WithRequestTimeout(d time.Duration) Option {
+	return func(h *Handler) {
+		h.requestTimeout = d
+	}
+}
+
+// withTimeout applies the request timeout. WebSocket upgrades are exempt,
+// since the subscription outlives the request.
+func (h *Handler) withTimeout(next http.Handler) http.Handler {
+	if h.requestTimeout <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if headerContains(r.Header, "Upgrade", "websocket") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// withUsage records call volume and latency per route pattern and client,
+// both in API usage analytics and in Prometheus metrics, which also track
+// requests in flight. It runs outside authentication and rate limiting, so
+// that the calls they refuse are counted too.
+func (h *Handler) withUsage(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pattern := mux.Handler(r)
+		// Patterns carry the method ("POST /team/add"); routes are reported by path.
+		if _, path, ok := strings.Cut(pattern, " "); ok {
+			pattern = path
+		}
+		if pattern == "" {
+			pattern = "unmatched"
+		}
+
+		start := time.Now()
+		done := h.metrics.startRequest(pattern)
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		elapsed := time.Since(start)
+		done(rec.status, elapsed)
+		h.service.RecordAPICall(pattern, clientName(r), rec.status, elapsed)
+	})
+}
+
+// callerUserID is the user_id of a caller authenticated by a Bearer token.
+func callerUserID(r *http.Request) string {
+	if c := callerOf(r); c != nil {
+		return c.userID
+	}
+	return ""
+}
+
+// clientName is the client name of the caller's API key, or the X-Client-ID
+// the caller chose.
+func clientName(r *http.Request) string {
+	if c := callerOf(r); c != nil && c.client != "" {
+		return c.client
+	}
+	name := r.Header.Get(clientHeader)
+	if name == "" {
+		return "anonymous"
+	}
+	if len(name) > maxClientNameLen {
+		name = name[:maxClientNameLen]
+	}
+	return name
+}
+
+// redactQuery returns the query string of u without the value of a feed
+// token, which must not end up in log files.
+func redactQuery(u *url.URL) string {
+	q := u.Query()
+	if !q.Has(feedTokenParam) {
+		return u.RawQuery
+	}
+	q.Set(feedTokenParam, "[redacted]")
+	return q.Encode()
+}
@@ -0,0 +1,79 @@
+package httpserver
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+
+	"review-assigner/internal/app"
+)
+
+// OIDCConfig configures authentication by the Bearer tokens an OpenID
+// Connect provider issues.
+type OIDCConfig struct {
+	// Verifier checks the signature, issuer, audience and expiry of tokens.
+	Verifier *oidc.IDTokenVerifier
+	// UserClaim names the claim holding the caller's user_id.
+	UserClaim string
+	// RolesClaim names the claim listing the caller's roles, either an
+	// array or a single string. Dots address nested claims, as in
+	// Keycloak's "realm_access.roles".
+	RolesClaim string
+	// AdminRole is the role that grants the /admin endpoints.
+	AdminRole string
+}
+
+// WithOIDC accepts Bearer tokens verified by cfg.Verifier on every call
+// except the public operations, besides API keys when WithAPIKeyAuth is
+// also set.
+func WithOIDC(cfg OIDCConfig) Option {
+	return func(h *Handler) {
+		h.oidc = &cfg
+	}
+}
+
+// authenticateToken verifies a token and reads the caller from its claims.
+func (h *Handler) authenticateToken(ctx context.Context, token string) (caller, error) {
+	cfg := h.oidc
+	idToken, err := cfg.Verifier.Verify(ctx, token)
+	if err != nil {
+		h.logger.Info("bearer token rejected", "error", err)
+		return caller{}, &app.Error{Code: app.ErrorCodeUnauthorized, Message: "invalid Bearer token"}
+	}
+	var claims map[string]any
+	if err := idToken.Claims(&claims); err != nil {
+		return caller{}, err
+	}
+
+	userID, _ := claimAt(claims, cfg.UserClaim).(string)
+	if userID == "" {
+		return caller{}, &app.Error{Code: app.ErrorCodeUnauthorized, Message: "Bearer token has no " + cfg.UserClaim + " claim"}
+	}
+	var roles []string
+	switch v := claimAt(claims, cfg.RolesClaim).(type) {
+	case string:
+		roles = strings.Fields(v)
+	case []any:
+		for _, role := range v {
+			if role, ok := role.(string); ok {
+				roles = append(roles, role)
+			}
+		}
+	}
+	return caller{userID: userID, roles: roles, admin: slices.Contains(roles, cfg.AdminRole)}, nil
+}
+
+// claimAt returns the claim at a dotted path, nil when there is none.
+func claimAt(claims map[string]any, path string) any {
+	var v any = claims
+	for _, name := range strings.Split(path, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = m[name]
+	}
+	return v
+}
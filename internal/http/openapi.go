@@ -0,0 +1,736 @@
+package httpserver
+
+import (
+	_ "embed"
+	"encoding"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"review-assigner/internal/app"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+//go:embed docs.html
+var docsPage []byte
+
+// apiParam is a query, header or path parameter of an API operation.
+type apiParam struct {
+	Name string
+	// In is "query" when empty.
+	In          string
+	Type        string
+	Format      string
+	Required    bool
+	Description string
+}
+
+// apiOperation documents a single route in the OpenAPI document. Request and
+// Response are zero values of the JSON bodies; a nil Request means no body.
+type apiOperation struct {
+	Method   string
+	Path     string
+	Tag      string
+	Summary  string
+	Params   []apiParam
+	Request  any
+	Status   int
+	Response any
+	// ContentType of the response, application/json when empty.
+	ContentType string
+	// RequestContentType of the request body, application/json when empty.
+	RequestContentType string
+	// AltRequestContentType is another content type a JSON operation
+	// accepts, with a body in its own format.
+	AltRequestContentType string
+	// Public operations need no API key: probes, docs and endpoints with
+	// their own authentication.
+	Public bool
+}
+
+type teamResponse struct {
+	Team app.Team `json:"team"`
+}
+
+type userResponse struct {
+	User app.User `json:"user"`
+}
+
+type organizationResponse struct {
+	Organization app.Organization `json:"organization"`
+}
+
+type userReviewBatchResponse struct {
+	Users []app.UserReviews `json:"users"`
+}
+
+type pullRequestResponse struct {
+	PR app.PullRequest `json:"pr"`
+}
+
+type replaceReviewerResponse struct {
+	PR                app.PullRequest        `json:"pr"`
+	ReplacedBy        string                 `json:"replaced_by"`
+	PreviousReviewers []app.PreviousReviewer `json:"previous_reviewers"`
+}
+
+type teamSettingsResponse struct {
+	Settings app.TeamSettings `json:"settings"`
+}
+
+type runtimeSettingsResponse struct {
+	Settings app.RuntimeSettings `json:"settings"`
+}
+
+type notificationPreferencesResponse struct {
+	Preferences app.NotificationPreferences `json:"preferences"`
+}
+
+func queryParam(name, typ, description string) apiParam {
+	return apiParam{Name: name, Type: typ, Description: description}
+}
+
+func requiredParam(name, description string) apiParam {
+	return apiParam{Name: name, Type: "string", Required: true, Description: description}
+}
+
+func pathParam(name, description string) apiParam {
+	return apiParam{Name: name, In: "path", Type: "string", Required: true, Description: description}
+}
+
+// scimParams adds the bearer token header of the SCIM endpoints to params.
+func scimParams(params ...apiParam) []apiParam {
+	return append([]apiParam{{Name: "Authorization", In: "header", Type: "string", Required: true,
+		Description: "Bearer SCIM_TOKEN"}}, params...)
+}
+
+var (
+	includeLoadParam = queryParam("include", "string", "load adds open_reviews to every member")
+
+	pageParams = []apiParam{
+		queryParam("limit", "integer", "Page size, 1 to 1000; all rows when omitted"),
+		queryParam("offset", "integer", "Number of rows to skip"),
+	}
+	cursorPageParams = params(pageParams, []apiParam{
+		queryParam("cursor", "string", "next_cursor of the previous page; cannot be combined with offset"),
+	})
+	// listParams are accepted by every cursor-paginated list endpoint.
+	listParams = params(cursorPageParams, []apiParam{
+		queryParam("fields", "string", "Comma-separated JSON fields to keep in every entry; all when omitted"),
+	})
+	statsFilterParams = []apiParam{
+		queryParam("org_name", "string", "Only teams of the organization"),
+		queryParam("team_name", "string", "Only the team"),
+		queryParam("status", "string", "OPEN or MERGED"),
+		{Name: "from", Type: "string", Format: "date-time", Description: "Window start, inclusive"},
+		{Name: "to", Type: "string", Format: "date-time", Description: "Window end, exclusive"},
+	}
+	formatParam = queryParam("format", "string", "json (default) or csv")
+)
+
+func params(groups ...[]apiParam) []apiParam {
+	var result []apiParam
+	for _, g := range groups {
+		result = append(result, g...)
+	}
+	return result
+}
+
+// apiOperations lists every route served by NewHandler.
+var apiOperations = []apiOperation{
+	{Method: http.MethodPost, Path: "/team/add", Tag: "Teams", Summary: "Create a team with its members",
+		Request: teamAddRequest{}, Status: http.StatusCreated, Response: teamResponse{}},
+	{Method: http.MethodGet, Path: "/team/get", Tag: "Teams", Summary: "Get a team with its members",
+		Params: []apiParam{requiredParam("team_name", "Team name"), includeLoadParam}, Response: app.TeamWithLoad{}},
+	{Method: http.MethodGet, Path: "/team/{name}", Tag: "Teams", Summary: "Get a team with its members",
+		Params: []apiParam{pathParam("name", "Team name"), includeLoadParam}, Response: app.TeamWithLoad{}},
+	{Method: http.MethodGet, Path: "/team/list", Tag: "Teams", Summary: "List teams with member counts",
+		Params: listParams, Response: struct {
+			Teams      []app.TeamSummary `json:"teams"`
+			Total      int               `json:"total"`
+			NextCursor *string           `json:"next_cursor"`
+		}{}},
+	{Method: http.MethodGet, Path: "/team/history", Tag: "Teams", Summary: "Membership changes of a team",
+		Params: []apiParam{requiredParam("team_name", "Team name")}, Response: struct {
+			TeamName string                `json:"team_name"`
+			Events   []app.MembershipEvent `json:"events"`
+		}{}},
+	{Method: http.MethodGet, Path: "/team/capacity", Tag: "Teams", Summary: "Review load against max_open_reviews",
+		Params: []apiParam{requiredParam("team_name", "Team name")}, Response: app.TeamCapacity{}},
+	{Method: http.MethodPost, Path: "/team/import", Tag: "Teams",
+		Summary: "Import several teams from JSON or from CSV (Content-Type: text/csv)",
+		Params:  []apiParam{queryParam("transfer", "boolean", "Move users from other teams, CSV only")},
+		Request: teamImportRequest{}, AltRequestContentType: "text/csv", Response: struct {
+			Results []app.TeamImportResult `json:"results"`
+			Created int                    `json:"created"`
+			Failed  int                    `json:"failed"`
+		}{}},
+	{Method: http.MethodPost, Path: "/team/deactivateMembers", Tag: "Teams", Summary: "Deactivate team members",
+		Request: teamDeactivateMembersRequest{}, Response: teamResponse{}},
+	{Method: http.MethodGet, Path: "/team/settings", Tag: "Teams", Summary: "Get team assignment settings",
+		Params: []apiParam{requiredParam("team_name", "Team name")}, Response: teamSettingsResponse{}},
+	{Method: http.MethodPost, Path: "/team/settings", Tag: "Teams", Summary: "Update team assignment settings",
+		Request: teamSettingsRequest{}, Response: teamSettingsResponse{}},
+	{Method: http.MethodPost, Path: "/team/delete", Tag: "Teams", Summary: "Archive a team and deactivate its members",
+		Request: teamDeleteRequest{}, Response: app.TeamDeletion{}},
+	{Method: http.MethodPost, Path: "/team/rename", Tag: "Teams", Summary: "Rename a team",
+		Request: teamRenameRequest{}, Response: teamResponse{}},
+
+	{Method: http.MethodPost, Path: "/org/create", Tag: "Organizations", Summary: "Create an organization",
+		Request: orgCreateRequest{}, Status: http.StatusCreated, Response: organizationResponse{}},
+	{Method: http.MethodGet, Path: "/org/get", Tag: "Organizations", Summary: "Get an organization with its teams",
+		Params: []apiParam{requiredParam("org_name", "Organization name")}, Response: organizationResponse{}},
+	{Method: http.MethodPost, Path: "/org/setTeam", Tag: "Organizations", Summary: "Move a team to an organization",
+		Request: orgSetTeamRequest{}, Response: teamResponse{}},
+
+	{Method: http.MethodPost, Path: "/users/create", Tag: "Users", Summary: "Create a user",
+		Request: createUserRequest{}, Status: http.StatusCreated, Response: userResponse{}},
+	{Method: http.MethodGet, Path: "/users/get", Tag: "Users", Summary: "Get a user with their review load",
+		Params: []apiParam{requiredParam("user_id", "User ID")}, Response: struct {
+			User app.UserDetails `json:"user"`
+		}{}},
+	{Method: http.MethodGet, Path: "/users/list", Tag: "Users", Summary: "List users ordered by user_id",
+		Params: params([]apiParam{queryParam("team_name", "string", "Only members of the team")}, listParams),
+		Response: struct {
+			Users      []app.User `json:"users"`
+			NextCursor *string    `json:"next_cursor"`
+		}{}},
+	{Method: http.MethodPost, Path: "/users/setIsActive", Tag: "Users", Summary: "Activate or deactivate a user",
+		Request: setIsActiveRequest{}, Response: userResponse{}},
+	{Method: http.MethodPost, Path: "/users/setRole", Tag: "Users", Summary: "Change the team role of a user",
+		Request: setRoleRequest{}, Response: userResponse{}},
+	{Method: http.MethodPost, Path: "/users/updateProfile", Tag: "Users", Summary: "Update contact details of a user",
+		Request: updateProfileRequest{}, Response: userResponse{}},
+	{Method: http.MethodPost, Path: "/users/delete", Tag: "Users", Summary: "Delete a user",
+		Request: deleteUserRequest{}, Response: app.UserDeletion{}},
+	{Method: http.MethodGet, Path: "/users/notifications", Tag: "Users", Summary: "Get notification preferences",
+		Params: []apiParam{requiredParam("user_id", "User ID")}, Response: notificationPreferencesResponse{}},
+	{Method: http.MethodPost, Path: "/users/notifications", Tag: "Users", Summary: "Update notification preferences",
+		Request: notificationPreferencesRequest{}, Response: notificationPreferencesResponse{}},
+	{Method: http.MethodGet, Path: "/users/getReview", Tag: "Users", Summary: "Review queue of a user",
+		Params: params([]apiParam{requiredParam("user_id", "User ID")}, listParams), Response: struct {
+			UserID       string                 `json:"user_id"`
+			PullRequests []app.PullRequestShort `json:"pull_requests"`
+			NextCursor   *string                `json:"next_cursor"`
+		}{}},
+	{Method: http.MethodGet, Path: "/users/getReviewBatch", Tag: "Users", Summary: "Review queues of several users",
+		Params:   []apiParam{requiredParam("user_ids", "Comma-separated user IDs, at most 100")},
+		Response: userReviewBatchResponse{}},
+	{Method: http.MethodPost, Path: "/users/getReviewBatch", Tag: "Users", Summary: "Review queues of several users",
+		Request: userReviewBatchRequest{}, Response: userReviewBatchResponse{}},
+	{Method: http.MethodGet, Path: "/users/{id}/reviews.ics", Tag: "Users",
+		Summary: "Pending reviews of a user as an iCalendar feed, with events at the deadlines",
+		Params: []apiParam{pathParam("id", "User ID"),
+			queryParam(feedTokenParam, "string", "Feed token of the user, for calendar apps that cannot send headers")},
+		ContentType: "text/calendar"},
+	{Method: http.MethodPost, Path: "/users/feedToken", Tag: "Users",
+		Summary: "Issue the token of a user for the calendar feed and the WebSocket, revoking the previous one",
+		Request: feedTokenRequest{}, Status: http.StatusCreated, Response: feedTokenResponse{}},
+
+	{Method: http.MethodPost, Path: "/pullRequest/create", Tag: "PullRequests", Summary: "Create a PR and assign reviewers",
+		Params: []apiParam{{Name: idempotencyKeyHeader, In: "header", Type: "string",
+			Description: "Repeated requests with the same key return the first result"}},
+		Request: createPullRequestRequest{}, Status: http.StatusCreated, Response: pullRequestResponse{}},
+	{Method: http.MethodPost, Path: "/pullRequest/update", Tag: "PullRequests", Summary: "Update name, priority, labels, Jira issues or deadline",
+		Request: updatePullRequestRequest{}, Response: pullRequestResponse{}},
+	{Method: http.MethodPost, Path: "/pullRequest/merge", Tag: "PullRequests", Summary: "Merge a PR",
+		Request: mergePullRequestRequest{}, Response: struct {
+			PR            app.PullRequest   `json:"pr"`
+			ReviewSummary app.ReviewSummary `json:"review_summary"`
+		}{}},
+	{Method: http.MethodPost, Path: "/pullRequest/delete", Tag: "PullRequests", Summary: "Soft-delete a PR",
+		Request: deletePullRequestRequest{}, Response: pullRequestResponse{}},
+	{Method: http.MethodPost, Path: "/pullRequest/reassign", Tag: "PullRequests", Summary: "Replace a reviewer",
+		Request: reassignPullRequestRequest{}, Response: replaceReviewerResponse{}},
+	{Method: http.MethodPost, Path: "/pullRequest/approve", Tag: "PullRequests", Summary: "Approve a PR as a reviewer",
+		Request: approvePullRequestRequest{}, Response: pullRequestResponse{}},
+	{Method: http.MethodPost, Path: "/pullRequest/decline", Tag: "PullRequests", Summary: "Decline a review and get replaced",
+		Request: declinePullRequestRequest{}, Response: replaceReviewerResponse{}},
+	{Method: http.MethodGet, Path: "/pullRequest/list", Tag: "PullRequests", Summary: "List PRs with filters",
+		Params: params([]apiParam{
+			queryParam("status", "string", "OPEN or MERGED"),
+			queryParam("author_id", "string", "Author"),
+			queryParam("team_name", "string", "Author's team"),
+			queryParam("reviewer_id", "string", "Assigned reviewer"),
+			queryParam("priority", "string", "LOW, MEDIUM or HIGH"),
+			queryParam("label", "string", "Required label, can be repeated"),
+			queryParam("sort", "string", "pull_request_id, created_at or priority, - prefix for descending order"),
+			queryParam("include_deleted", "boolean", "Also return deleted PRs, admins only"),
+			queryParam("include_archived", "boolean", "Also return archived PRs"),
+			{Name: "created_from", Type: "string", Format: "date-time", Description: "Created at or after"},
+			{Name: "created_to", Type: "string", Format: "date-time", Description: "Created before"},
+		}, listParams), Response: struct {
+			PullRequests []app.PullRequest `json:"pull_requests"`
+			NextCursor   *string           `json:"next_cursor"`
+		}{}},
+	{Method: http.MethodGet, Path: "/pullRequest/{id}", Tag: "PullRequests", Summary: "Get a PR, including merged ones",
+		Params: []apiParam{pathParam("id", "PR ID"),
+			queryParam("include_deleted", "boolean", "Also return a deleted PR, admins only")},
+		Response: pullRequestResponse{}},
+	{Method: http.MethodGet, Path: "/pullRequest/history", Tag: "PullRequests", Summary: "Reviewer changes of a PR",
+		Params: []apiParam{requiredParam("pull_request_id", "PR ID")}, Response: struct {
+			PullRequestID string                `json:"pull_request_id"`
+			Events        []app.AssignmentEvent `json:"events"`
+		}{}},
+	{Method: http.MethodGet, Path: "/pullRequest/underassigned", Tag: "PullRequests", Summary: "Open PRs with too few reviewers",
+		Response: struct {
+			RequiredReviewers int                            `json:"required_reviewers"`
+			PullRequests      []app.UnderassignedPullRequest `json:"pull_requests"`
+		}{}},
+
+	{Method: http.MethodGet, Path: "/search", Tag: "Search", Summary: "Find PRs and users by a substring of ID or name",
+		Params: []apiParam{
+			requiredParam("q", "Case-insensitive substring"),
+			queryParam("limit", "integer", "Results per group, 1 to 100, default 20"),
+		}, Response: app.SearchResults{}},
+
+	{Method: http.MethodGet, Path: "/stats/assignments", Tag: "Stats", Summary: "Assignment statistics",
+		Params: params(statsFilterParams, []apiParam{
+			formatParam,
+			queryParam("section", "string", "CSV section: by_user, by_pr, by_team or fairness"),
+			queryParam("top_n", "integer", "Only the most loaded users and PRs"),
+		}, pageParams), Response: app.AssignmentStats{}},
+	{Method: http.MethodGet, Path: "/stats/latency", Tag: "Stats", Summary: "Time to first approval and to merge",
+		Params: params(statsFilterParams, []apiParam{formatParam}), Response: app.LatencyStats{}},
+	{Method: http.MethodGet, Path: "/stats/history", Tag: "Stats", Summary: "Daily assignment snapshots",
+		Params: []apiParam{
+			queryParam("team_name", "string", "Only the team"),
+			queryParam("user_id", "string", "Only the user"),
+			{Name: "from", Type: "string", Format: "date", Description: "First day, inclusive"},
+			{Name: "to", Type: "string", Format: "date", Description: "First day after the window"},
+		}, Response: struct {
+			History []app.StatsHistoryPoint `json:"history"`
+		}{}},
+	{Method: http.MethodGet, Path: "/stats/reviewMatrix", Tag: "Stats", Summary: "Author by reviewer assignment counts",
+		Params: statsFilterParams, Response: struct {
+			Teams []app.TeamReviewMatrix `json:"teams"`
+		}{}},
+	{Method: http.MethodGet, Path: "/stats/pullRequest", Tag: "Stats", Summary: "Review summary of a single PR",
+		Params: []apiParam{requiredParam("pull_request_id", "PR ID")}, Response: app.PullRequestStats{}},
+	{Method: http.MethodGet, Path: "/stats/sla", Tag: "Stats", Summary: "First review SLA compliance",
+		Params: statsFilterParams, Response: struct {
+			Teams []app.TeamSLACompliance `json:"teams"`
+		}{}},
+	{Method: http.MethodGet, Path: "/stats/velocity", Tag: "Stats", Summary: "Created, merged and closed PRs per week",
+		Params: statsFilterParams, Response: struct {
+			Teams []app.TeamVelocity `json:"teams"`
+		}{}},
+	{Method: http.MethodGet, Path: "/stats/forecast", Tag: "Stats", Summary: "Expected assignments next week",
+		Params: []apiParam{
+			queryParam("org_name", "string", "Only teams of the organization"),
+			queryParam("team_name", "string", "Only the team"),
+			queryParam("weeks", "integer", "Lookback in weeks, 4 by default"),
+		}, Response: struct {
+			Teams []app.TeamForecast `json:"teams"`
+		}{}},
+	{Method: http.MethodGet, Path: "/stats/strategies", Tag: "Stats", Summary: "Assignment outcomes per strategy",
+		Params: statsFilterParams, Response: struct {
+			Strategies []app.StrategyStats `json:"strategies"`
+		}{}},
+	{Method: http.MethodGet, Path: "/stats/stale", Tag: "Stats", Summary: "Open PRs without recent reviewer activity",
+		Params: []apiParam{
+			queryParam("org_name", "string", "Only teams of the organization"),
+			queryParam("team_name", "string", "Only the team"),
+			queryParam("days", "integer", "Idle days, 7 by default"),
+		}, Response: app.StaleReport{}},
+	{Method: http.MethodPost, Path: "/stats/refresh", Tag: "Stats", Summary: "Drop cached assignment statistics",
+		Response: struct {
+			Invalidated int `json:"invalidated"`
+		}{}},
+
+	{Method: http.MethodGet, Path: "/admin/reviewerStorage", Tag: "Admin", Summary: "Compare reviewer arrays with the reviewers table",
+		Response: app.ReviewerStorageReport{}},
+	{Method: http.MethodPost, Path: "/admin/reviewerStorage/backfill", Tag: "Admin", Summary: "Repair the reviewers table",
+		Response: app.ReviewerStorageReport{}},
+	{Method: http.MethodGet, Path: "/admin/usage", Tag: "Admin", Summary: "API usage per endpoint and client",
+		Params: []apiParam{
+			queryParam("endpoint", "string", "Route pattern"),
+			queryParam("client", "string", "Client from the X-Client-ID header"),
+			{Name: "from", Type: "string", Format: "date-time", Description: "Window start, inclusive"},
+			{Name: "to", Type: "string", Format: "date-time", Description: "Window end, exclusive"},
+		}, Response: struct {
+			Usage []app.APIUsage `json:"usage"`
+		}{}},
+	{Method: http.MethodGet, Path: "/admin/archive", Tag: "Admin", Summary: "Archive status",
+		Response: app.ArchiveStatus{}},
+	{Method: http.MethodPost, Path: "/admin/archive", Tag: "Admin", Summary: "Archive old merged PRs",
+		Request: archiveRequest{}, Response: app.ArchiveRun{}},
+	{Method: http.MethodPost, Path: "/admin/statsSnapshot", Tag: "Admin", Summary: "Save today's assignment snapshot",
+		Response: app.StatsSnapshot{}},
+	{Method: http.MethodGet, Path: "/admin/jobs", Tag: "Admin", Summary: "Background jobs and their last runs on any replica",
+		Response: struct {
+			Jobs []app.JobStatus `json:"jobs"`
+		}{}},
+	{Method: http.MethodGet, Path: "/admin/settings", Tag: "Admin", Summary: "Runtime settings and feature flags",
+		Response: runtimeSettingsResponse{}},
+	{Method: http.MethodPost, Path: "/admin/settings", Tag: "Admin",
+		Summary: "Change runtime settings and feature flags without a restart",
+		Request: runtimeSettingsRequest{}, Response: runtimeSettingsResponse{}},
+	{Method: http.MethodGet, Path: "/admin/notifications/failures", Tag: "Admin",
+		Summary: "Notifications that ran out of delivery attempts, newest first",
+		Params:  params([]apiParam{queryParam("channel", "string", "Only the channel, e.g. slack, email or jira")}, cursorPageParams),
+		Response: struct {
+			Failures   []app.NotificationFailure `json:"failures"`
+			NextCursor *string                   `json:"next_cursor"`
+		}{}},
+	{Method: http.MethodPost, Path: "/admin/notifications/failures/{id}/replay", Tag: "Admin",
+		Summary: "Queue a failed notification for delivery again",
+		Params:  []apiParam{pathParam("id", "failure_id")}, Response: struct {
+			FailureID int64 `json:"failure_id"`
+			MessageID int64 `json:"message_id"`
+		}{}},
+	{Method: http.MethodGet, Path: "/admin/apiKeys", Tag: "Admin", Summary: "API keys without the keys themselves",
+		Response: struct {
+			APIKeys []app.APIKey `json:"api_keys"`
+		}{}},
+	{Method: http.MethodPost, Path: "/admin/apiKeys", Tag: "Admin",
+		Summary: "Issue an API key for a client; the key is returned only here",
+		Request: issueAPIKeyRequest{}, Status: http.StatusCreated, Response: issueAPIKeyResponse{}},
+	{Method: http.MethodPost, Path: "/admin/apiKeys/{id}/revoke", Tag: "Admin", Summary: "Revoke an API key",
+		Params: []apiParam{pathParam("id", "key_id")}, Response: apiKeyResponse{}},
+	{Method: http.MethodGet, Path: "/admin/audit", Tag: "Admin", Summary: "Mutating API calls, newest first",
+		Params: params([]apiParam{
+			queryParam("actor", "string", "Client name or user_id of the caller"),
+			queryParam("endpoint", "string", "Route pattern, e.g. /team/deactivateMembers"),
+			queryParam("result", "string", "success (status below 400) or failure"),
+			{Name: "from", Type: "string", Format: "date-time", Description: "Window start, inclusive"},
+			{Name: "to", Type: "string", Format: "date-time", Description: "Window end, exclusive"},
+		}, cursorPageParams),
+		Response: struct {
+			Entries    []app.AuditEntry `json:"entries"`
+			NextCursor *string          `json:"next_cursor"`
+		}{}},
+	{Method: http.MethodGet, Path: "/admin/credentials", Tag: "Admin",
+		Summary: "Stored integration credentials, without their secrets",
+		Response: struct {
+			Credentials []app.Credential `json:"credentials"`
+		}{}},
+	{Method: http.MethodPost, Path: "/admin/credentials", Tag: "Admin",
+		Summary: "Store an integration credential encrypted; applied on restart",
+		Request: setCredentialRequest{}, Response: credentialResponse{}},
+	{Method: http.MethodPost, Path: "/admin/credentials/{name}/delete", Tag: "Admin",
+		Summary: "Delete a stored credential; the environment variable applies again on restart",
+		Params:  []apiParam{pathParam("name", "Credential name")}, Response: deleteCredentialResponse{}},
+
+	{Method: http.MethodGet, Path: "/ws", Tag: "Users",
+		Summary: "WebSocket: the review queue of a user, pushed on connect and on every change",
+		Params: []apiParam{requiredParam("user_id", "Reviewer whose queue to follow"),
+			queryParam(feedTokenParam, "string", "Feed token of the user, for browsers that cannot send headers")},
+		Status: http.StatusSwitchingProtocols, Response: queueMessage{}},
+
+	{Method: http.MethodPost, Path: "/events/ingest", Tag: "Integrations",
+		Summary: "Apply a PR event from any code host in the normalized schema",
+		Request: ingestEventRequest{}, Response: ingestEventResponse{}},
+	{Method: http.MethodPost, Path: "/webhooks/create", Tag: "Integrations",
+		Summary: "Subscribe a URL to signed webhook events; the secret is returned only here",
+		Request: createWebhookRequest{}, Status: http.StatusCreated, Response: createWebhookResponse{}},
+	{Method: http.MethodGet, Path: "/webhooks/list", Tag: "Integrations", Summary: "List webhook subscriptions",
+		Response: struct {
+			Webhooks []app.WebhookSubscription `json:"webhooks"`
+		}{}},
+	{Method: http.MethodPost, Path: "/webhooks/delete", Tag: "Integrations", Summary: "Delete a webhook subscription",
+		Request: webhookIDRequest{}, Response: webhookIDRequest{}},
+	{Method: http.MethodPost, Path: "/webhooks/test", Tag: "Integrations",
+		Summary: "Send a signed ping to a subscription right away",
+		Request: webhookIDRequest{}, Response: app.WebhookTest{}},
+	{Method: http.MethodPost, Path: "/integrations/slack/command", Tag: "Integrations",
+		Summary: "Slack slash command: my queue, reassign PR from user, stats team",
+		Params: []apiParam{
+			{Name: "X-Slack-Request-Timestamp", In: "header", Type: "integer", Required: true},
+			{Name: "X-Slack-Signature", In: "header", Type: "string", Required: true,
+				Description: "v0= HMAC-SHA256 of v0:timestamp:body with the signing secret"},
+		},
+		Request: slackCommandForm{}, RequestContentType: "application/x-www-form-urlencoded",
+		Response: slackCommandResponse{}, Public: true},
+
+	{Method: http.MethodGet, Path: "/scim/v2/ServiceProviderConfig", Tag: "SCIM",
+		Summary: "SCIM features supported by the service", Params: scimParams(), ContentType: scimContentType, Public: true},
+	{Method: http.MethodGet, Path: "/scim/v2/Users", Tag: "SCIM", Summary: "List users or find one by userName",
+		Params: scimParams(queryParam("filter", "string", `userName eq "u1"`),
+			queryParam("startIndex", "integer", "1-based index of the first user"),
+			queryParam("count", "integer", "Page size")),
+		ContentType: scimContentType, Response: scimListResponse{}, Public: true},
+	{Method: http.MethodPost, Path: "/scim/v2/Users", Tag: "SCIM", Summary: "Provision a user; userName becomes user_id",
+		Params: scimParams(), Request: scimUser{}, RequestContentType: scimContentType,
+		Status: http.StatusCreated, ContentType: scimContentType, Response: scimUser{}, Public: true},
+	{Method: http.MethodGet, Path: "/scim/v2/Users/{id}", Tag: "SCIM", Summary: "Get a user",
+		Params: scimParams(pathParam("id", "User ID")), ContentType: scimContentType, Response: scimUser{}, Public: true},
+	{Method: http.MethodPut, Path: "/scim/v2/Users/{id}", Tag: "SCIM",
+		Summary: "Replace the name, e-mail and activity of a user; deactivation removes open assignments",
+		Params:  scimParams(pathParam("id", "User ID")), Request: scimUser{}, RequestContentType: scimContentType,
+		ContentType: scimContentType, Response: scimUser{}, Public: true},
+	{Method: http.MethodPatch, Path: "/scim/v2/Users/{id}", Tag: "SCIM",
+		Summary: "Change active, displayName, name.formatted or emails of a user",
+		Params:  scimParams(pathParam("id", "User ID")), Request: scimPatchRequest{}, RequestContentType: scimContentType,
+		ContentType: scimContentType, Response: scimUser{}, Public: true},
+	{Method: http.MethodDelete, Path: "/scim/v2/Users/{id}", Tag: "SCIM", Summary: "Delete a user as /users/delete does",
+		Params: scimParams(pathParam("id", "User ID")), Status: http.StatusNoContent, ContentType: scimContentType, Public: true},
+	{Method: http.MethodGet, Path: "/scim/v2/Groups", Tag: "SCIM",
+		Summary: "List teams without members or find one by displayName",
+		Params: scimParams(queryParam("filter", "string", `displayName eq "team-1"`),
+			queryParam("startIndex", "integer", "1-based index of the first team"),
+			queryParam("count", "integer", "Page size")),
+		ContentType: scimContentType, Response: scimListResponse{}, Public: true},
+	{Method: http.MethodPost, Path: "/scim/v2/Groups", Tag: "SCIM",
+		Summary: "Create a team; listed members move into it from their teams",
+		Params:  scimParams(), Request: scimGroup{}, RequestContentType: scimContentType,
+		Status: http.StatusCreated, ContentType: scimContentType, Response: scimGroup{}, Public: true},
+	{Method: http.MethodGet, Path: "/scim/v2/Groups/{id}", Tag: "SCIM", Summary: "Get a team with its members",
+		Params: scimParams(pathParam("id", "Team name")), ContentType: scimContentType, Response: scimGroup{}, Public: true},
+	{Method: http.MethodPut, Path: "/scim/v2/Groups/{id}", Tag: "SCIM", Summary: "Replace the members of a team",
+		Params: scimParams(pathParam("id", "Team name")), Request: scimGroup{}, RequestContentType: scimContentType,
+		ContentType: scimContentType, Response: scimGroup{}, Public: true},
+	{Method: http.MethodPatch, Path: "/scim/v2/Groups/{id}", Tag: "SCIM", Summary: "Add, remove or replace team members",
+		Params: scimParams(pathParam("id", "Team name")), Request: scimPatchRequest{}, RequestContentType: scimContentType,
+		ContentType: scimContentType, Response: scimGroup{}, Public: true},
+	{Method: http.MethodDelete, Path: "/scim/v2/Groups/{id}", Tag: "SCIM",
+		Summary: "Delete a team as /team/delete without force does",
+		Params:  scimParams(pathParam("id", "Team name")), Status: http.StatusNoContent, ContentType: scimContentType, Public: true},
+
+	{Method: http.MethodGet, Path: "/healthz", Tag: "Operations", Summary: "Liveness probe",
+		Response: healthResponse{}, Public: true},
+	{Method: http.MethodGet, Path: "/readyz", Tag: "Operations",
+		Summary:  "Readiness probe: database reachability and migrations, 503 when not ready",
+		Response: readinessResponse{}, Public: true},
+	{Method: http.MethodGet, Path: "/metrics", Tag: "Operations", Summary: "Prometheus metrics",
+		ContentType: "text/plain", Public: true},
+	{Method: http.MethodGet, Path: "/openapi.json", Tag: "Operations", Summary: "This document", Public: true},
+	{Method: http.MethodGet, Path: "/docs", Tag: "Operations", Summary: "Swagger UI", ContentType: "text/html",
+		Public: true},
+}
+
+// openAPIDocument is built once from apiOperations.
+var openAPIDocument = sync.OnceValue(func() map[string]any {
+	schemas := &schemaRegistry{schemas: make(map[string]any)}
+	errorSchema := schemas.schemaOf(reflect.TypeOf(errorResponse{}))
+
+	paths := make(map[string]map[string]any)
+	for _, op := range apiOperations {
+		if paths[op.Path] == nil {
+			paths[op.Path] = make(map[string]any)
+		}
+		paths[op.Path][strings.ToLower(op.Method)] = op.document(schemas, errorSchema)
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "Review Assigner API",
+			"version": "1.0.0",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas.schemas,
+			"securitySchemes": map[string]any{
+				"apiKey": map[string]any{"type": "apiKey", "in": "header", "name": apiKeyHeader},
+				"bearer": map[string]any{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+		// Credentials are only checked when the server requires them; either
+		// scheme is enough.
+		"security": []any{map[string]any{"apiKey": []string{}}, map[string]any{"bearer": []string{}}},
+	}
+})
+
+func (op apiOperation) document(schemas *schemaRegistry, errorSchema map[string]any) map[string]any {
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	contentType := op.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	body := map[string]any{"type": "string"}
+	if op.Response != nil {
+		body = schemas.schemaOf(reflect.TypeOf(op.Response))
+	} else if contentType == "application/json" {
+		body = map[string]any{"type": "object"}
+	}
+
+	responses := map[string]any{
+		strconv.Itoa(status): map[string]any{
+			"description": http.StatusText(status),
+			"content":     map[string]any{contentType: map[string]any{"schema": body}},
+		},
+		"default": map[string]any{
+			"description": "Application error",
+			"content":     map[string]any{"application/json": map[string]any{"schema": errorSchema}},
+		},
+	}
+
+	doc := map[string]any{
+		"tags":      []string{op.Tag},
+		"summary":   op.Summary,
+		"responses": responses,
+	}
+	if op.Public {
+		doc["security"] = []any{}
+	}
+	if len(op.Params) > 0 || op.Request != nil {
+		responses["400"] = map[string]any{
+			"description": "Invalid parameters",
+			"content":     map[string]any{"application/json": map[string]any{"schema": errorSchema}},
+		}
+	}
+	if op.Request != nil && op.RequestContentType == "" {
+		responses["415"] = map[string]any{
+			"description": "Request body is not JSON",
+			"content":     map[string]any{"application/json": map[string]any{"schema": errorSchema}},
+		}
+	}
+	if len(op.Params) > 0 {
+		var params []any
+		for _, p := range op.Params {
+			params = append(params, p.document())
+		}
+		doc["parameters"] = params
+	}
+	if op.Request != nil {
+		requestType := op.RequestContentType
+		if requestType == "" {
+			requestType = "application/json"
+		}
+		content := map[string]any{
+			requestType: map[string]any{"schema": schemas.schemaOf(reflect.TypeOf(op.Request))},
+		}
+		if op.AltRequestContentType != "" {
+			content[op.AltRequestContentType] = map[string]any{"schema": map[string]any{"type": "string"}}
+		}
+		doc["requestBody"] = map[string]any{
+			"required": true,
+			"content":  content,
+		}
+	}
+	return doc
+}
+
+func (p apiParam) document() map[string]any {
+	in := p.In
+	if in == "" {
+		in = "query"
+	}
+	schema := map[string]any{"type": p.Type}
+	if p.Format != "" {
+		schema["format"] = p.Format
+	}
+	return map[string]any{
+		"name":        p.Name,
+		"in":          in,
+		"required":    p.Required,
+		"description": p.Description,
+		"schema":      schema,
+	}
+}
+
+// schemaRegistry converts Go types to JSON schemas following encoding/json
+// rules. Named structs are collected as components and referenced.
+type schemaRegistry struct {
+	schemas map[string]any
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+func (r *schemaRegistry) schemaOf(t reflect.Type) map[string]any {
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	// Raw JSON may hold any value.
+	if t == rawMessageType {
+		return map[string]any{}
+	}
+	// Types encoded as text, e.g. rate limits, are strings.
+	if t.Kind() != reflect.Pointer && t.Implements(textMarshalerType) {
+		return map[string]any{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := r.schemaOf(t.Elem())
+		if _, ok := s["$ref"]; !ok {
+			s["nullable"] = true
+		}
+		return s
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": r.schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": r.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return r.structSchema(t)
+		}
+		name := exportedName(t.Name())
+		if _, ok := r.schemas[name]; !ok {
+			// The placeholder stops recursion through self-referencing types.
+			r.schemas[name] = map[string]any{}
+			r.schemas[name] = r.structSchema(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	default:
+		return map[string]any{}
+	}
+}
+
+func (r *schemaRegistry) structSchema(t reflect.Type) map[string]any {
+	props := make(map[string]any)
+	r.addFields(props, t)
+	return map[string]any{"type": "object", "properties": props}
+}
+
+func (r *schemaRegistry) addFields(props map[string]any, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				r.addFields(props, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = r.schemaOf(f.Type)
+	}
+}
+
+func exportedName(name string) string {
+	runes := []rune(name)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+func (h *Handler) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, openAPIDocument())
+}
+
+func (h *Handler) handleDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(docsPage)
+}
@@ -0,0 +1,170 @@
+package httpserver
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"review-assigner/internal/app"
+	"review-assigner/internal/ratelimit"
+)
+
+// RouteClass groups the operations that share a rate limit.
+type RouteClass string
+
+// Route classes; their names are the keys of rate_limits in the runtime
+// settings. Probes, metrics and docs belong to none and are never limited.
+const (
+	// RouteClassRead is the GET operations.
+	RouteClassRead RouteClass = "read"
+	// RouteClassWrite is the operations changing teams, users and pull
+	// requests.
+	RouteClassWrite RouteClass = "write"
+	// RouteClassIntegration is the calls of other systems: ingested events,
+	// webhook management and Slack commands.
+	RouteClassIntegration RouteClass = "integration"
+	// RouteClassAdmin is the /admin endpoints.
+	RouteClassAdmin RouteClass = "admin"
+	// RouteClassAuthFailure is the calls refused with 401, counted per IP
+	// address before authentication, so that guessing credentials is
+	// limited too.
+	RouteClassAuthFailure RouteClass = "auth_failure"
+)
+
+// WithRateLimits limits how often each client calls the operations of a
+// route class; classes without a limit are not limited. The rate_limits of
+// the runtime settings override these limits without a restart. Clients
+// are told apart by API key, by the user of a Bearer token and otherwise by
+// IP address. Limits are kept in memory, so each replica applies them on
+// its own.
+func WithRateLimits(limits map[RouteClass]ratelimit.Limit) Option {
+	return func(h *Handler) {
+		h.rateLimits = limits
+		h.rateLimiters = make(map[RouteClass]*ratelimit.Limiter)
+		for _, class := range app.RateLimitClasses {
+			h.rateLimiters[RouteClass(class)] = ratelimit.New(limits[RouteClass(class)])
+		}
+	}
+}
+
+// rateLimiter returns the limiter of a route class with its current limit,
+// or nil when calls are not limited.
+func (h *Handler) rateLimiter(w http.ResponseWriter, r *http.Request, class RouteClass) *ratelimit.Limiter {
+	limiter := h.rateLimiters[class]
+	if limiter == nil {
+		return nil
+	}
+	limit := h.rateLimits[class]
+	override, ok, err := h.service.RateLimitOverride(r.Context(), string(class))
+	switch {
+	case err != nil:
+		h.logger.Warn("read rate limit override", "request_id", requestID(w), "class", class, "error", err)
+	case ok:
+		limit = override
+	}
+	limiter.SetLimit(limit)
+	return limiter
+}
+
+// routeClasses maps "METHOD /path" patterns to their route class.
+var routeClasses = sync.OnceValue(func() map[string]RouteClass {
+	classes := make(map[string]RouteClass)
+	for _, op := range apiOperations {
+		var class RouteClass
+		switch {
+		case op.Tag == "Operations":
+			continue
+		case strings.HasPrefix(op.Path, "/admin/"):
+			class = RouteClassAdmin
+		case op.Tag == "Integrations" && op.Method != http.MethodGet:
+			class = RouteClassIntegration
+		case op.Method == http.MethodGet:
+			class = RouteClassRead
+		default:
+			class = RouteClassWrite
+		}
+		classes[op.Method+" "+op.Path] = class
+	}
+	return classes
+})
+
+// withRateLimit answers 429 RATE_LIMITED with Retry-After to clients over
+// the limit of the route class. It runs after authentication, which
+// identifies the client.
+func (h *Handler) withRateLimit(mux *http.ServeMux, next http.Handler) http.Handler {
+	if len(h.rateLimiters) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pattern := mux.Handler(r)
+		class, ok := routeClasses()[pattern]
+		if !ok && pattern == "" {
+			// Unknown paths still cost a token, so that probing them is
+			// limited too.
+			class = RouteClassWrite
+			if r.Method == http.MethodGet {
+				class = RouteClassRead
+			}
+		}
+		if limiter := h.rateLimiter(w, r, class); limiter != nil {
+			if allowed, retryAfter := limiter.Allow(rateLimitKey(r)); !allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				h.writeAppError(w, &app.Error{Code: app.ErrorCodeRateLimited,
+					Message: "rate limit of " + string(class) + " calls exceeded"})
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withAuthFailureLimit answers 429 RATE_LIMITED with Retry-After to IP
+// addresses whose calls were refused with 401 too often, before
+// authentication looks up their credentials. Only refused calls spend
+// tokens.
+func (h *Handler) withAuthFailureLimit(next http.Handler) http.Handler {
+	if len(h.rateLimiters) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limiter := h.rateLimiter(w, r, RouteClassAuthFailure)
+		key := "ip:" + remoteIP(r)
+		if allowed, retryAfter := limiter.Check(key); !allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			h.writeAppError(w, &app.Error{Code: app.ErrorCodeRateLimited,
+				Message: "too many calls with invalid credentials"})
+			return
+		}
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		if rec.status == http.StatusUnauthorized {
+			limiter.Allow(key)
+		}
+	})
+}
+
+// rateLimitKey identifies the client whose bucket a call spends.
+func rateLimitKey(r *http.Request) string {
+	if c := callerOf(r); c != nil {
+		switch {
+		case c.apiKeyID != 0:
+			return "key:" + strconv.FormatInt(c.apiKeyID, 10)
+		case c.client != "":
+			return "client:" + c.client
+		case c.userID != "":
+			return "user:" + c.userID
+		}
+	}
+	return "ip:" + remoteIP(r)
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
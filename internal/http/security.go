@@ -0,0 +1,64 @@
+package httpserver
+
+import (
+	"mime"
+	"net/http"
+	"sync"
+
+	"review-assigner/internal/app"
+)
+
+// hstsMaxAge tells browsers to use HTTPS for a year.
+const hstsMaxAge = "max-age=31536000; includeSubDomains"
+
+// withSecurityHeaders stops browsers from sniffing content types and from
+// caching API responses, and asks them to keep to HTTPS once they reached
+// the server over it. Handlers may still set their own Cache-Control.
+func withSecurityHeaders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+		header.Set("X-Content-Type-Options", "nosniff")
+		header.Set("Cache-Control", "no-store")
+		if r.TLS != nil {
+			header.Set("Strict-Transport-Security", hstsMaxAge)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// jsonOperations are the "METHOD /path" patterns of operations with a JSON
+// request body, mapped to the other content type they accept, if any.
+var jsonOperations = sync.OnceValue(func() map[string]string {
+	ops := make(map[string]string)
+	for _, op := range apiOperations {
+		if op.Request != nil && op.RequestContentType == "" {
+			ops[op.Method+" "+op.Path] = op.AltRequestContentType
+		}
+	}
+	return ops
+})
+
+// withJSONContentType refuses bodies that are not JSON on the operations
+// expecting it with 415, so that a form or a text body does not reach the
+// JSON decoder. Requests without a body are left to the handlers.
+func (h *Handler) withJSONContentType(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pattern := mux.Handler(r)
+		alt, ok := jsonOperations()[pattern]
+		if !ok || (r.ContentLength == 0 && r.Header.Get("Content-Type") == "") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if mediaType != "application/json" && (alt == "" || mediaType != alt) {
+			accepted := "application/json"
+			if alt != "" {
+				accepted += " or " + alt
+			}
+			h.writeAppError(w, &app.Error{Code: app.ErrorCodeUnsupportedMediaType,
+				Message: "Content-Type must be " + accepted})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,236 @@
+package httpserver
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"reflect"
+	"review-assigner/internal/app"
+	"strings"
+	"time"
+)
+
+// The subset of RFC 6455 needed to push JSON messages: text frames from the
+// server, and ping, pong and close frames in both directions.
+const (
+	wsAcceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	wsOpText  = 0x1
+	wsOpClose = 0x8
+	wsOpPing  = 0x9
+	wsOpPong  = 0xA
+
+	// wsMaxClientFrame bounds frames read from clients, which only need to
+	// send control frames.
+	wsMaxClientFrame = 4 << 10
+	wsPingInterval   = 30 * time.Second
+	wsWriteTimeout   = 10 * time.Second
+)
+
+// queueMessage is pushed to a queue subscriber on connect and whenever the
+// queue changes; it carries the whole queue as /users/getReview returns it.
+type queueMessage struct {
+	Type         string                 `json:"type"`
+	UserID       string                 `json:"user_id"`
+	PullRequests []app.PullRequestShort `json:"pull_requests"`
+}
+
+// WithShutdown closes open queue subscriptions with a close frame once done
+// is closed. http.Server.Shutdown does not track hijacked connections, so
+// without it WebSockets outlive a graceful shutdown.
+func WithShutdown(done <-chan struct{}) Option {
+	return func(h *Handler) {
+		h.shutdown = done
+	}
+}
+
+// handleQueueSocket upgrades the request to a WebSocket and pushes the
+// review queue of user_id each time it changes.
+func (h *Handler) handleQueueSocket(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		h.writeValidationError(w, "user_id", "user_id is required")
+		return
+	}
+	if err := h.authorizeSelf(r, userID); err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+	if _, err := h.service.GetUser(r.Context(), userID); err != nil {
+		h.writeAppError(w, err)
+		return
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") ||
+		r.Header.Get("Sec-WebSocket-Version") != "13" || key == "" {
+		h.writeValidationError(w, "", "expected a WebSocket upgrade request")
+		return
+	}
+
+	// Subscribe before the first read so that no change is missed.
+	changes, unsubscribe := h.service.SubscribeQueueChanges(userID)
+	defer unsubscribe()
+
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		h.writeAppError(w, fmt.Errorf("hijack connection: %w", err))
+		return
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	// Clear the server's request deadlines: the connection lives on.
+	_ = conn.SetDeadline(time.Time{})
+
+	sum := sha1.Sum([]byte(key + wsAcceptGUID))
+	_, _ = fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Accept: %s\r\n%s: %s\r\n\r\n",
+		base64.StdEncoding.EncodeToString(sum[:]), requestIDHeader, requestID(w))
+	if err := rw.Flush(); err != nil {
+		return
+	}
+
+	pings := make(chan []byte, 1)
+	closed := make(chan struct{})
+	go readWebSocket(rw.Reader, pings, closed)
+
+	ticker := time.NewTicker(wsPingInterval)
+	defer ticker.Stop()
+
+	var last []app.PullRequestShort
+	for first := true; ; first = false {
+		if !first {
+			select {
+			case <-closed:
+				_ = writeWebSocketFrame(conn, wsOpClose, nil)
+				return
+			case <-h.shutdown:
+				// 1001 going away.
+				_ = writeWebSocketFrame(conn, wsOpClose, []byte{0x03, 0xE9})
+				return
+			case payload := <-pings:
+				if writeWebSocketFrame(conn, wsOpPong, payload) != nil {
+					return
+				}
+				continue
+			case <-ticker.C:
+				if writeWebSocketFrame(conn, wsOpPing, nil) != nil {
+					return
+				}
+				continue
+			case <-changes:
+			}
+		}
+
+		prs, _, err := h.service.GetUserReviews(r.Context(), userID, app.Page{})
+		if err != nil {
+			return
+		}
+		if !first && reflect.DeepEqual(prs, last) {
+			continue
+		}
+		last = prs
+		data, err := json.Marshal(queueMessage{Type: "queue", UserID: userID, PullRequests: prs})
+		if err != nil || writeWebSocketFrame(conn, wsOpText, data) != nil {
+			return
+		}
+	}
+}
+
+// readWebSocket reads client frames until the client closes the connection
+// or breaks the protocol, passing pings on to be answered.
+func readWebSocket(r *bufio.Reader, pings chan<- []byte, closed chan<- struct{}) {
+	defer close(closed)
+	for {
+		opcode, payload, err := readWebSocketFrame(r)
+		if err != nil || opcode == wsOpClose {
+			return
+		}
+		if opcode == wsOpPing {
+			select {
+			case pings <- payload:
+			default:
+			}
+		}
+	}
+}
+
+func readWebSocketFrame(r *bufio.Reader) (byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	opcode := header[0] & 0x0F
+	masked := header[1]&0x80 != 0
+	size := uint64(header[1] & 0x7F)
+	switch size {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		size = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		size = binary.BigEndian.Uint64(ext[:])
+	}
+	// Clients must mask their frames.
+	if !masked || size > wsMaxClientFrame {
+		return 0, nil, errors.New("invalid client frame")
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(r, mask[:]); err != nil {
+		return 0, nil, err
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return opcode, payload, nil
+}
+
+// writeWebSocketFrame writes a single unmasked frame, as servers do.
+func writeWebSocketFrame(conn net.Conn, opcode byte, payload []byte) error {
+	header := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= 0xFFFF:
+		header = append(header, 126)
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	_ = conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	_, err := conn.Write(append(header, payload...))
+	return err
+}
+
+// headerContains reports whether a comma-separated header lists token,
+// ignoring case.
+func headerContains(header http.Header, name, token string) bool {
+	for _, v := range header.Values(name) {
+		for _, item := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(item), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+// Package jira updates Jira issues linked to pull requests.
+package jira
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client calls the Jira REST API v2 with basic authentication: an account
+// e-mail and an API token for Jira Cloud, or a username and password for
+// Jira Server.
+type Client struct {
+	baseURL string
+	user    string
+	token   string
+	client  *http.Client
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used to call Jira.
+func WithHTTPClient(c *http.Client) Option {
+	return func(cl *Client) {
+		cl.client = c
+	}
+}
+
+// NewClient creates a client for the Jira site at baseURL, e.g.
+// https://example.atlassian.net.
+func NewClient(baseURL, user, token string, opts ...Option) *Client {
+	c := &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		user:    user,
+		token:   token,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// CommentIssue implements app.IssueTracker.
+func (c *Client) CommentIssue(ctx context.Context, key, text string) error {
+	if err := c.post(ctx, "/rest/api/2/issue/"+url.PathEscape(key)+"/comment", map[string]string{"body": text}); err != nil {
+		return fmt.Errorf("jira: comment %s: %w", key, err)
+	}
+	return nil
+}
+
+// TransitionIssue implements app.IssueTracker.
+func (c *Client) TransitionIssue(ctx context.Context, key, transitionID string) error {
+	payload := map[string]any{"transition": map[string]string{"id": transitionID}}
+	if err := c.post(ctx, "/rest/api/2/issue/"+url.PathEscape(key)+"/transitions", payload); err != nil {
+		return fmt.Errorf("jira: transition %s: %w", key, err)
+	}
+	return nil
+}
+
+func (c *Client) post(ctx context.Context, path string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if c.user != "" || c.token != "" {
+		req.SetBasicAuth(c.user, c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	// Comments answer 201 and transitions 204.
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
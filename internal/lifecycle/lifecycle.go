@@ -0,0 +1,119 @@
+// Package lifecycle starts and stops the components of the server in order.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Hook is a component with startup and shutdown work. Start must not block:
+// long-running work goes to a goroutine, see Background. Either function
+// may be nil.
+type Hook struct {
+	Name  string
+	Start func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+}
+
+// Manager starts hooks in the order they were appended and stops them in
+// reverse, so that a component stops before the ones it depends on.
+type Manager struct {
+	logger  *slog.Logger
+	hooks   []Hook
+	started int
+}
+
+// New creates a Manager that logs hook failures and timings to logger.
+func New(logger *slog.Logger) *Manager {
+	return &Manager{logger: logger}
+}
+
+// Append adds a hook. Hooks must be appended before Start.
+func (m *Manager) Append(h Hook) {
+	m.hooks = append(m.hooks, h)
+}
+
+// Start runs the Start functions in order. When one fails, the hooks
+// started before it are stopped and the error is returned.
+func (m *Manager) Start(ctx context.Context) error {
+	for _, h := range m.hooks {
+		if h.Start != nil {
+			if err := h.Start(ctx); err != nil {
+				err = fmt.Errorf("start %s: %w", h.Name, err)
+				if stopErr := m.Stop(ctx); stopErr != nil {
+					err = errors.Join(err, stopErr)
+				}
+				return err
+			}
+		}
+		m.started++
+		m.logger.Debug("started", "component", h.Name)
+	}
+	return nil
+}
+
+// Stop runs the Stop functions of the started hooks in reverse order. Every
+// hook is stopped even if another fails or ctx expires, so each gets the
+// chance to give up quickly; the errors are joined.
+func (m *Manager) Stop(ctx context.Context) error {
+	var errs []error
+	for ; m.started > 0; m.started-- {
+		h := m.hooks[m.started-1]
+		if h.Stop == nil {
+			continue
+		}
+		start := time.Now()
+		if err := h.Stop(ctx); err != nil {
+			m.logger.Error("stop", "component", h.Name, "error", err)
+			errs = append(errs, fmt.Errorf("stop %s: %w", h.Name, err))
+			continue
+		}
+		m.logger.Debug("stopped", "component", h.Name, "duration", time.Since(start))
+	}
+	return errors.Join(errs...)
+}
+
+// Background is a hook running fn in a goroutine from Start until Stop
+// cancels its context. Stop waits for fn to return, or for its own ctx to
+// expire. An error from fn is logged.
+func Background(name string, logger *slog.Logger, fn func(ctx context.Context) error) Hook {
+	var (
+		cancel context.CancelFunc
+		wg     sync.WaitGroup
+	)
+	return Hook{
+		Name: name,
+		Start: func(context.Context) error {
+			// The start context may be short-lived; the goroutine runs
+			// until Stop.
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := fn(ctx); err != nil {
+					logger.Error("background component failed", "component", name, "error", err)
+				}
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			cancel()
+			done := make(chan struct{})
+			go func() {
+				wg.Wait()
+				close(done)
+			}()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return fmt.Errorf("wait for %s: %w", name, ctx.Err())
+			}
+		},
+	}
+}
@@ -0,0 +1,159 @@
+// Package ratelimit limits how often each client may call the service with
+// in-memory token buckets.
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit allows Count calls per Period. Calls spend tokens of a bucket that
+// holds up to Count and refills continuously, so an idle client may burst
+// Count calls at once. The zero Limit allows everything.
+type Limit struct {
+	Count  int
+	Period time.Duration
+}
+
+// ParseLimit parses "COUNT/PERIOD" such as "100/1m". "0" and "off" disable
+// the limit.
+func ParseLimit(s string) (Limit, error) {
+	if s == "0" || s == "off" {
+		return Limit{}, nil
+	}
+	count, period, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: expected COUNT/PERIOD", s)
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil || n <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: count must be a positive integer", s)
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: period must be a positive duration", s)
+	}
+	return Limit{Count: n, Period: d}, nil
+}
+
+// Enabled reports whether the limit restricts anything.
+func (l Limit) Enabled() bool {
+	return l.Count > 0 && l.Period > 0
+}
+
+func (l Limit) String() string {
+	if !l.Enabled() {
+		return "off"
+	}
+	return strconv.Itoa(l.Count) + "/" + l.Period.String()
+}
+
+// MarshalText encodes the limit as ParseLimit reads it.
+func (l Limit) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText decodes a limit with ParseLimit.
+func (l *Limit) UnmarshalText(text []byte) error {
+	parsed, err := ParseLimit(string(text))
+	if err != nil {
+		return err
+	}
+	*l = parsed
+	return nil
+}
+
+// Limiter keeps a bucket per key. It is safe for concurrent use.
+type Limiter struct {
+	mu        sync.Mutex
+	limit     Limit
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	// at is when tokens was last brought up to date.
+	at time.Time
+}
+
+// New creates a Limiter applying limit to every key.
+func New(limit Limit) *Limiter {
+	return &Limiter{limit: limit, buckets: make(map[string]*bucket)}
+}
+
+// SetLimit changes the limit. Buckets keep their tokens, up to the new
+// count.
+func (l *Limiter) SetLimit(limit Limit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = limit
+}
+
+// Allow spends a token of key's bucket. When the bucket is empty, it
+// returns false and how long until a token is available.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.limit.Enabled() {
+		return true, 0
+	}
+	now := time.Now()
+	perToken := l.limit.Period / time.Duration(l.limit.Count)
+	b := l.refill(key, now, perToken)
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) * float64(perToken))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// Check is Allow without spending the token, for limits that only count
+// some of the calls, e.g. the failed ones.
+func (l *Limiter) Check(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.limit.Enabled() {
+		return true, 0
+	}
+	now := time.Now()
+	perToken := l.limit.Period / time.Duration(l.limit.Count)
+	b := l.refill(key, now, perToken)
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) * float64(perToken))
+	}
+	return true, 0
+}
+
+// refill returns key's bucket brought up to date.
+func (l *Limiter) refill(key string, now time.Time, perToken time.Duration) *bucket {
+	l.sweep(now)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.limit.Count), at: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(l.limit.Count), b.tokens+float64(now.Sub(b.at))/float64(perToken))
+	b.at = now
+	return b
+}
+
+// sweep forgets, once per period, the buckets that have refilled: a new
+// bucket is full as well, so the memory of idle clients is not needed.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.limit.Period {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.at) >= l.limit.Period {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+// Package secretbox encrypts secrets stored in the database with AES-256-GCM
+// under a master key.
+package secretbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// KeySize is the length of a master key in bytes.
+const KeySize = 32
+
+// Box seals and opens secrets with one master key. It is safe for
+// concurrent use.
+type Box struct {
+	aead cipher.AEAD
+}
+
+// New creates a Box from a KeySize-byte master key.
+func New(key []byte) (*Box, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("secretbox: key must be %d bytes, got %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("secretbox: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("secretbox: %w", err)
+	}
+	return &Box{aead: aead}, nil
+}
+
+// ParseKey decodes a base64 master key, as generated by
+// "openssl rand -base64 32".
+func ParseKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("secretbox: key is not base64: %w", err)
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("secretbox: key must be %d bytes, got %d", KeySize, len(key))
+	}
+	return key, nil
+}
+
+// Seal encrypts plaintext under a random nonce, which prefixes the result.
+// label binds the result to where it is stored, e.g. a credential name:
+// Open fails unless given the same label.
+func (b *Box) Seal(plaintext, label []byte) ([]byte, error) {
+	nonce := make([]byte, b.aead.NonceSize(), b.aead.NonceSize()+len(plaintext)+b.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("secretbox: nonce: %w", err)
+	}
+	return b.aead.Seal(nonce, nonce, plaintext, label), nil
+}
+
+// Open decrypts a result of Seal with the same label.
+func (b *Box) Open(sealed, label []byte) ([]byte, error) {
+	if len(sealed) < b.aead.NonceSize() {
+		return nil, errors.New("secretbox: sealed value is too short")
+	}
+	nonce, ciphertext := sealed[:b.aead.NonceSize()], sealed[b.aead.NonceSize():]
+	plaintext, err := b.aead.Open(nil, nonce, ciphertext, label)
+	if err != nil {
+		return nil, errors.New("secretbox: cannot decrypt: wrong key or corrupted value")
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,193 @@
+// Package slack delivers reviewer notifications to Slack.
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"review-assigner/internal/app"
+)
+
+// DefaultAPIURL is the Slack Web API base URL.
+const DefaultAPIURL = "https://slack.com/api"
+
+// Channel is the outbox channel of Slack notifications.
+const Channel = "slack"
+
+// Notifier sends assignment notices to Slack. With a bot token reviewers
+// whose slack_handle is a Slack member ID (U…/W…) get a direct message;
+// other reviewers are mentioned in the incoming webhook's channel, if one is
+// configured.
+type Notifier struct {
+	token      string
+	webhookURL string
+	apiURL     string
+	client     *http.Client
+}
+
+// Option configures a Notifier.
+type Option func(*Notifier)
+
+// WithAPIURL overrides the Slack Web API base URL.
+func WithAPIURL(url string) Option {
+	return func(n *Notifier) {
+		n.apiURL = strings.TrimRight(url, "/")
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to call Slack.
+func WithHTTPClient(c *http.Client) Option {
+	return func(n *Notifier) {
+		n.client = c
+	}
+}
+
+// NewNotifier creates a notifier for a bot token, an incoming webhook URL
+// or both.
+func NewNotifier(token, webhookURL string, opts ...Option) *Notifier {
+	n := &Notifier{
+		token:      token,
+		webhookURL: webhookURL,
+		apiURL:     DefaultAPIURL,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+	return n
+}
+
+// Channel implements app.Notifier.
+func (n *Notifier) Channel() string {
+	return Channel
+}
+
+// NotifyAssignment implements app.Notifier.
+func (n *Notifier) NotifyAssignment(ctx context.Context, notice app.AssignmentNotice) error {
+	handle := notice.Reviewer.SlackHandle
+	if handle == "" || !notice.Preferences.Slack {
+		return nil
+	}
+
+	var err error
+	switch {
+	case n.token != "" && isMemberID(handle):
+		err = n.postMessage(ctx, handle, noticeText(notice))
+	case n.webhookURL != "":
+		err = n.postWebhook(ctx, mention(handle)+": "+noticeText(notice))
+	default:
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("slack: %w", err)
+	}
+	return nil
+}
+
+// noticeText renders a notice addressed to its reviewer.
+func noticeText(notice app.AssignmentNotice) string {
+	pr := notice.PullRequest
+	title := fmt.Sprintf("*%s* (`%s`, %s priority)", Escape(pr.Name), Escape(pr.ID), strings.ToLower(pr.Priority))
+	if notice.EventType == app.AssignmentEventReplaced {
+		return fmt.Sprintf("You no longer need to review %s: it was handed over to %s.", title, Escape(notice.ReplacedBy))
+	}
+	text := fmt.Sprintf("You were assigned to review %s by %s.", title, Escape(pr.AuthorID))
+	if pr.Deadline != nil {
+		text += fmt.Sprintf(" Deadline: %s.", pr.Deadline.UTC().Format(time.RFC3339))
+	}
+	return text
+}
+
+// escaper escapes the control characters of Slack's mrkdwn.
+var escaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// Escape makes user input safe to embed in a Slack message: without it a
+// pull request named "<!channel>" would notify the whole channel.
+func Escape(s string) string {
+	return escaper.Replace(s)
+}
+
+// isMemberID reports whether a Slack handle is a member ID rather than a
+// display name.
+func isMemberID(handle string) bool {
+	if len(handle) < 2 || (handle[0] != 'U' && handle[0] != 'W') {
+		return false
+	}
+	for _, c := range handle[1:] {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// mention renders a handle so that Slack notifies its owner where possible.
+func mention(handle string) string {
+	if isMemberID(handle) {
+		return "<@" + handle + ">"
+	}
+	return "@" + strings.TrimPrefix(handle, "@")
+}
+
+// postMessage sends a direct message with chat.postMessage.
+func (n *Notifier) postMessage(ctx context.Context, channel, text string) error {
+	body, err := n.post(ctx, n.apiURL+"/chat.postMessage", n.token, map[string]string{"channel": channel, "text": text})
+	if err != nil {
+		return err
+	}
+	// The Web API reports failures with 200 and ok=false.
+	var resp struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("decode chat.postMessage response: %w", err)
+	}
+	if !resp.OK {
+		return fmt.Errorf("chat.postMessage: %s", resp.Error)
+	}
+	return nil
+}
+
+// postWebhook posts a message to the incoming webhook's channel.
+func (n *Notifier) postWebhook(ctx context.Context, text string) error {
+	_, err := n.post(ctx, n.webhookURL, "", map[string]string{"text": text})
+	return err
+}
+
+func (n *Notifier) post(ctx context.Context, url, token string, payload any) ([]byte, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("encode message: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
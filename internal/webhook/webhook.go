@@ -0,0 +1,195 @@
+// Package webhook signs, sends and verifies outbound webhook deliveries.
+//
+// Every delivery carries the headers
+//
+//	X-Webhook-ID:        event ID, the same on retries
+//	X-Webhook-Timestamp: Unix time of this attempt, in seconds
+//	X-Webhook-Signature: v1=<hex HMAC-SHA256 of "<timestamp>.<body>">
+//
+// keyed with the subscription secret. Receivers recompute the signature and
+// reject timestamps further than Tolerance from their clock, which bounds
+// how long a captured delivery can be replayed; each attempt is signed anew.
+//
+// Deliveries only go to public addresses unless WithPrivateNetworks is set,
+// so that subscriptions cannot reach internal services.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Delivery headers.
+const (
+	HeaderID        = "X-Webhook-ID"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Tolerance is how far a delivery's timestamp may be from the receiver's
+// clock.
+const Tolerance = 5 * time.Minute
+
+// signatureVersion prefixes signatures so that the scheme can change.
+const signatureVersion = "v1"
+
+// Errors returned by Verify.
+var (
+	ErrMissingSignature = errors.New("webhook: missing signature headers")
+	ErrStaleTimestamp   = errors.New("webhook: timestamp outside the tolerance window")
+	ErrBadSignature     = errors.New("webhook: signature mismatch")
+)
+
+// ErrPrivateAddress is returned for deliveries to loopback, private,
+// link-local and other non-public addresses.
+var ErrPrivateAddress = errors.New("webhook: destination is not a public address")
+
+// Sign returns the X-Webhook-Signature value of body sent at timestamp.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signatureVersion + "=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature headers of a delivery received at now against
+// secret. A comma-separated signature header may list several signatures,
+// e.g. during secret rotation; one match is enough.
+func Verify(secret string, header http.Header, body []byte, now time.Time) error {
+	ts, sigs := header.Get(HeaderTimestamp), header.Get(HeaderSignature)
+	if ts == "" || sigs == "" {
+		return ErrMissingSignature
+	}
+	timestamp, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ErrMissingSignature
+	}
+	if d := now.Sub(time.Unix(timestamp, 0)); d > Tolerance || d < -Tolerance {
+		return ErrStaleTimestamp
+	}
+	want := Sign(secret, timestamp, body)
+	for _, sig := range strings.Split(sigs, ",") {
+		if hmac.Equal([]byte(strings.TrimSpace(sig)), []byte(want)) {
+			return nil
+		}
+	}
+	return ErrBadSignature
+}
+
+// Sender posts signed deliveries.
+type Sender struct {
+	client       *http.Client
+	allowPrivate bool
+	now          func() time.Time
+}
+
+// Option configures a Sender.
+type Option func(*Sender)
+
+// WithHTTPClient sets the HTTP client used to deliver webhooks. The client
+// is used as is, without the check of destination addresses.
+func WithHTTPClient(c *http.Client) Option {
+	return func(s *Sender) {
+		s.client = c
+	}
+}
+
+// WithPrivateNetworks allows deliveries to non-public addresses, e.g. to
+// receivers inside the same network.
+func WithPrivateNetworks() Option {
+	return func(s *Sender) {
+		s.allowPrivate = true
+	}
+}
+
+// NewSender creates a sender. Redirects are not followed, so a delivery
+// never reaches a host other than the subscribed one. The address is
+// checked after DNS resolution, right before connecting.
+func NewSender(opts ...Option) *Sender {
+	s := &Sender{now: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.client == nil {
+		dialer := &net.Dialer{Timeout: 5 * time.Second}
+		if !s.allowPrivate {
+			dialer.Control = publicOnly
+		}
+		s.client = &http.Client{
+			Timeout: 10 * time.Second,
+			// Deliveries are not proxied, so that the check applies to the
+			// receiver itself.
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: 5 * time.Second,
+				MaxIdleConnsPerHost: 4,
+				IdleConnTimeout:     90 * time.Second,
+			},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	}
+	return s
+}
+
+// publicOnly is a net.Dialer Control function refusing connections to
+// addresses that are not public unicast ones.
+func publicOnly(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
+// SendWebhook implements app.WebhookSender. Any status other than 2xx is
+// an error.
+func (s *Sender) SendWebhook(ctx context.Context, url, secret, eventID string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("webhook: build request: %w", err)
+	}
+	timestamp := s.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "review-assigner-webhook/1")
+	req.Header.Set(HeaderID, eventID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook: send: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	// Drain a little of the body so that the connection can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook: unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
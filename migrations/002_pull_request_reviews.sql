@@ -0,0 +1,7 @@
+CREATE TABLE pull_request_reviews (
+    pull_request_id TEXT NOT NULL references pull_requests(pull_request_id),
+    user_id TEXT NOT NULL references users(user_id),
+    status TEXT NOT NULL CHECK (status IN ('APPROVED')),
+    reviewed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (pull_request_id, user_id)
+);
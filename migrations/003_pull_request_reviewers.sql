@@ -0,0 +1,9 @@
+CREATE TABLE pull_request_reviewers (
+    pull_request_id TEXT NOT NULL references pull_requests(pull_request_id),
+    user_id TEXT NOT NULL references users(user_id),
+    position INTEGER NOT NULL,
+    assigned_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (pull_request_id, user_id)
+);
+
+CREATE INDEX pull_request_reviewers_user_id_idx ON pull_request_reviewers(user_id);
@@ -0,0 +1,5 @@
+ALTER TABLE pull_requests
+    ADD COLUMN priority TEXT NOT NULL DEFAULT 'MEDIUM' CHECK (priority IN ('LOW', 'MEDIUM', 'HIGH'));
+
+CREATE INDEX pull_requests_author_id_idx ON pull_requests(author_id);
+CREATE INDEX pull_requests_created_at_idx ON pull_requests(created_at);
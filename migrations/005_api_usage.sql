@@ -0,0 +1,10 @@
+CREATE TABLE api_usage (
+    bucket TIMESTAMP WITH TIME ZONE NOT NULL,
+    endpoint TEXT NOT NULL,
+    client TEXT NOT NULL,
+    calls BIGINT NOT NULL DEFAULT 0,
+    errors BIGINT NOT NULL DEFAULT 0,
+    total_duration_ms DOUBLE PRECISION NOT NULL DEFAULT 0,
+    max_duration_ms DOUBLE PRECISION NOT NULL DEFAULT 0,
+    PRIMARY KEY (bucket, endpoint, client)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE pull_requests
+    ADD COLUMN co_author_ids TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,4 @@
+ALTER TABLE pull_requests
+    ADD COLUMN labels TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX pull_requests_labels_idx ON pull_requests USING GIN (labels);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS assignment_events (
+    event_id            BIGSERIAL PRIMARY KEY,
+    pull_request_id     TEXT        NOT NULL REFERENCES pull_requests (pull_request_id) ON DELETE CASCADE,
+    event_type          TEXT        NOT NULL CHECK (event_type IN ('ASSIGNED', 'REMOVED', 'REPLACED')),
+    user_id             TEXT        NOT NULL,
+    replacement_user_id TEXT,
+    reason              TEXT        NOT NULL,
+    strategy            TEXT,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS assignment_events_pull_request_idx ON assignment_events (pull_request_id, event_id);
@@ -0,0 +1,13 @@
+ALTER TABLE pull_requests
+    ADD COLUMN archived_at TIMESTAMPTZ;
+
+CREATE INDEX pull_requests_archivable_idx ON pull_requests (merged_at)
+    WHERE status = 'MERGED' AND archived_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS archive_runs (
+    run_id      BIGSERIAL PRIMARY KEY,
+    cutoff      TIMESTAMPTZ NOT NULL,
+    archived    INTEGER     NOT NULL,
+    started_at  TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ NOT NULL DEFAULT clock_timestamp()
+);
@@ -0,0 +1,4 @@
+ALTER TABLE pull_requests
+    ADD COLUMN needs_security_review BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN security_reviewer_id  TEXT REFERENCES users (user_id),
+    ADD COLUMN security_approved_at  TIMESTAMPTZ;
@@ -0,0 +1,43 @@
+CREATE TABLE IF NOT EXISTS pull_request_merge_snapshots (
+    pull_request_id TEXT    NOT NULL REFERENCES pull_requests (pull_request_id),
+    user_id         TEXT    NOT NULL,
+    position        INTEGER NOT NULL,
+    review_status   TEXT    NOT NULL CHECK (review_status IN ('APPROVED', 'PENDING')),
+    reviewed_at     TIMESTAMPTZ,
+    PRIMARY KEY (pull_request_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS pull_request_merge_snapshots_user_idx ON pull_request_merge_snapshots (user_id);
+
+CREATE OR REPLACE FUNCTION reject_merge_snapshot_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'pull_request_merge_snapshots is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER pull_request_merge_snapshots_immutable
+    BEFORE UPDATE OR DELETE ON pull_request_merge_snapshots
+    FOR EACH ROW EXECUTE FUNCTION reject_merge_snapshot_change();
+
+INSERT INTO pull_request_merge_snapshots (pull_request_id, user_id, position, review_status, reviewed_at)
+SELECT p.pull_request_id,
+       a.user_id,
+       a.position,
+       CASE WHEN r.user_id IS NULL THEN 'PENDING' ELSE 'APPROVED' END,
+       r.reviewed_at
+FROM pull_requests p
+CROSS JOIN LATERAL unnest(p.assigned_reviewers) WITH ORDINALITY AS a(user_id, position)
+LEFT JOIN pull_request_reviews r
+       ON r.pull_request_id = p.pull_request_id
+      AND r.user_id = a.user_id
+      AND r.status = 'APPROVED'
+WHERE p.status = 'MERGED';
+
+UPDATE pull_requests
+SET assigned_reviewers = '{}'
+WHERE status = 'MERGED';
+
+DELETE FROM pull_request_reviewers r
+USING pull_requests p
+WHERE r.pull_request_id = p.pull_request_id
+  AND p.status = 'MERGED';
@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS team_settings (
+    team_name   TEXT PRIMARY KEY REFERENCES teams (team_name),
+    strict_mode BOOLEAN NOT NULL DEFAULT FALSE
+);
@@ -0,0 +1,9 @@
+ALTER TABLE users
+    DROP CONSTRAINT users_team_name_fkey,
+    ADD CONSTRAINT users_team_name_fkey
+        FOREIGN KEY (team_name) REFERENCES teams (team_name) ON UPDATE CASCADE;
+
+ALTER TABLE team_settings
+    DROP CONSTRAINT team_settings_team_name_fkey,
+    ADD CONSTRAINT team_settings_team_name_fkey
+        FOREIGN KEY (team_name) REFERENCES teams (team_name) ON UPDATE CASCADE;
@@ -0,0 +1,2 @@
+ALTER TABLE users
+    ALTER COLUMN team_name DROP NOT NULL;
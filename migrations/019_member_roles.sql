@@ -0,0 +1,7 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'MEMBER'
+        CHECK (role IN ('LEAD', 'SENIOR', 'MEMBER'));
+
+ALTER TABLE team_settings
+    ADD COLUMN IF NOT EXISTS exclude_leads  BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS require_senior BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS organizations (
+    org_name   TEXT PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE teams
+    ADD COLUMN IF NOT EXISTS org_name TEXT REFERENCES organizations (org_name) ON UPDATE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_teams_org_name ON teams (org_name);
+
+ALTER TABLE team_settings
+    ADD COLUMN IF NOT EXISTS org_fallback BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,4 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS email        TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS slack_handle TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS avatar_url   TEXT NOT NULL DEFAULT '';
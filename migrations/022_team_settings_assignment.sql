@@ -0,0 +1,5 @@
+ALTER TABLE team_settings
+    ADD COLUMN IF NOT EXISTS reviewer_count     INT  NOT NULL DEFAULT 2 CHECK (reviewer_count BETWEEN 0 AND 10),
+    ADD COLUMN IF NOT EXISTS strategy           TEXT NOT NULL DEFAULT 'TEAM_ORDER' CHECK (strategy IN ('TEAM_ORDER', 'RANDOM')),
+    ADD COLUMN IF NOT EXISTS sla_hours          INT  NOT NULL DEFAULT 0 CHECK (sla_hours >= 0),
+    ADD COLUMN IF NOT EXISTS required_approvals INT  NOT NULL DEFAULT 0 CHECK (required_approvals BETWEEN 0 AND 10);
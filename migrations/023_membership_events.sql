@@ -0,0 +1,17 @@
+-- team_name is not a foreign key: history outlives the team and follows renames explicitly.
+CREATE TABLE IF NOT EXISTS membership_events (
+    event_id     BIGSERIAL PRIMARY KEY,
+    team_name    TEXT        NOT NULL,
+    user_id      TEXT        NOT NULL,
+    event_type   TEXT        NOT NULL CHECK (event_type IN ('JOINED', 'LEFT', 'ACTIVATED', 'DEACTIVATED')),
+    related_team TEXT,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS membership_events_team_idx ON membership_events (team_name, event_id);
+
+INSERT INTO membership_events(team_name, user_id, event_type)
+SELECT team_name, user_id, 'JOINED'
+FROM users
+WHERE team_name IS NOT NULL
+ORDER BY team_name, user_id;
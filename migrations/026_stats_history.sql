@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS stats_history (
+    snapshot_date     DATE    NOT NULL,
+    user_id           TEXT    NOT NULL,
+    team_name         TEXT,
+    open_assignments  INTEGER NOT NULL,
+    total_assignments INTEGER NOT NULL,
+    PRIMARY KEY (snapshot_date, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS stats_history_team_idx ON stats_history (team_name, snapshot_date);
@@ -0,0 +1,6 @@
+ALTER TABLE pull_requests
+    ADD COLUMN IF NOT EXISTS jira_issues TEXT[] NOT NULL DEFAULT '{}';
+
+ALTER TABLE team_settings
+    ADD COLUMN IF NOT EXISTS jira_comment_on_merge BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS jira_merge_transition TEXT    NOT NULL DEFAULT '';
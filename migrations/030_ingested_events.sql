@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS ingested_events (
+    event_id        TEXT PRIMARY KEY,
+    event_type      TEXT        NOT NULL,
+    pull_request_id TEXT        NOT NULL,
+    received_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
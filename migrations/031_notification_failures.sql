@@ -0,0 +1,17 @@
+-- Each message is delivered through one channel; '' delivers to every
+-- notifier, as messages queued before channels existed do.
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS channel TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS notification_failures (
+    failure_id BIGSERIAL PRIMARY KEY,
+    message_id BIGINT      NOT NULL,
+    kind       TEXT        NOT NULL,
+    channel    TEXT        NOT NULL,
+    payload    JSONB       NOT NULL,
+    attempts   INTEGER     NOT NULL,
+    last_error TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    failed_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS notification_failures_channel_idx ON notification_failures (channel, failure_id);
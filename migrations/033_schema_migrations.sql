@@ -0,0 +1,11 @@
+-- schema_migrations records applied migrations so that readiness checks can
+-- tell a database that lags behind the code. Every later migration inserts
+-- its own number.
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    INTEGER     PRIMARY KEY,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO schema_migrations (version)
+SELECT generate_series(1, 33)
+ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,21 @@
+-- runtime_settings holds settings changed through /admin/settings, one row
+-- per setting; missing rows mean the built-in default.
+CREATE TABLE IF NOT EXISTS runtime_settings (
+    name       TEXT PRIMARY KEY,
+    value      JSONB       NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- A NULL strategy or sla_hours follows the runtime default. The old
+-- built-in defaults cannot be told from explicit values, so they follow it
+-- too.
+ALTER TABLE team_settings
+    ALTER COLUMN strategy DROP NOT NULL,
+    ALTER COLUMN strategy DROP DEFAULT,
+    ALTER COLUMN sla_hours DROP NOT NULL,
+    ALTER COLUMN sla_hours DROP DEFAULT;
+
+UPDATE team_settings SET strategy = NULL WHERE strategy = 'TEAM_ORDER';
+UPDATE team_settings SET sla_hours = NULL WHERE sla_hours = 0;
+
+INSERT INTO schema_migrations (version) VALUES (34) ON CONFLICT (version) DO NOTHING;
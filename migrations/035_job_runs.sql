@@ -0,0 +1,13 @@
+-- job_runs tracks the last run of each background job across replicas; a
+-- replica claims a run by moving started_at forward.
+CREATE TABLE IF NOT EXISTS job_runs (
+    job_name    TEXT PRIMARY KEY,
+    started_at  TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ,
+    instance    TEXT        NOT NULL,
+    last_error  TEXT        NOT NULL DEFAULT '',
+    runs        BIGINT      NOT NULL DEFAULT 0,
+    failures    BIGINT      NOT NULL DEFAULT 0
+);
+
+INSERT INTO schema_migrations (version) VALUES (35) ON CONFLICT (version) DO NOTHING;
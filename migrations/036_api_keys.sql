@@ -0,0 +1,13 @@
+-- api_keys authenticates API clients. Only the SHA-256 of a key is kept;
+-- key_prefix is its start, shown to tell keys apart.
+CREATE TABLE IF NOT EXISTS api_keys (
+    key_id       BIGSERIAL PRIMARY KEY,
+    client_name  TEXT        NOT NULL,
+    key_hash     TEXT        NOT NULL UNIQUE,
+    key_prefix   TEXT        NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ
+);
+
+INSERT INTO schema_migrations (version) VALUES (36) ON CONFLICT (version) DO NOTHING;
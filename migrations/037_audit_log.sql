@@ -0,0 +1,19 @@
+-- audit_log records every mutating API call: who made it, to which
+-- endpoint, a summary of its payload and the result.
+CREATE TABLE IF NOT EXISTS audit_log (
+    audit_id   BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    client     TEXT        NOT NULL,
+    user_id    TEXT        NOT NULL DEFAULT '',
+    method     TEXT        NOT NULL,
+    endpoint   TEXT        NOT NULL,
+    path       TEXT        NOT NULL,
+    payload    JSONB,
+    status     INTEGER     NOT NULL,
+    error_code TEXT        NOT NULL DEFAULT '',
+    request_id TEXT        NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_created_at_idx ON audit_log (created_at);
+
+INSERT INTO schema_migrations (version) VALUES (37) ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,5 @@
+-- scopes limits an API key to the listed operation groups; NULL keeps the
+-- full access of the keys issued before scopes.
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS scopes TEXT[];
+
+INSERT INTO schema_migrations (version) VALUES (38) ON CONFLICT (version) DO NOTHING;
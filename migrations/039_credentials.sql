@@ -0,0 +1,13 @@
+-- credentials holds integration secrets sealed with the master key
+-- CREDENTIALS_KEY: AES-256-GCM, the nonce prefixing the ciphertext.
+CREATE TABLE IF NOT EXISTS credentials (
+    name       TEXT PRIMARY KEY,
+    sealed     BYTEA       NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- secret_sealed replaces secret once the master key is configured; secret
+-- is then empty.
+ALTER TABLE webhook_subscriptions ADD COLUMN IF NOT EXISTS secret_sealed BYTEA;
+
+INSERT INTO schema_migrations (version) VALUES (39) ON CONFLICT (version) DO NOTHING;
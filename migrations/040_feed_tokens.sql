@@ -0,0 +1,10 @@
+-- feed_tokens hold one token per user for the clients that cannot send
+-- headers: calendar subscriptions and browser WebSockets. Only the SHA-256
+-- of a token is stored, like for API keys.
+CREATE TABLE IF NOT EXISTS feed_tokens (
+    user_id    TEXT PRIMARY KEY REFERENCES users(user_id),
+    token_hash TEXT        NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO schema_migrations (version) VALUES (40) ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,8 @@
+ALTER TABLE pull_requests
+    ADD COLUMN IF NOT EXISTS github_repo   TEXT    NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS github_number INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS github_login TEXT NOT NULL DEFAULT '';
+
+INSERT INTO schema_migrations (version) VALUES (41) ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,9 @@
+-- Events recorded before this migration have finished: failed ones were
+-- deleted again.
+ALTER TABLE ingested_events
+    ADD COLUMN IF NOT EXISTS completed_at TIMESTAMPTZ DEFAULT NOW();
+
+ALTER TABLE ingested_events
+    ALTER COLUMN completed_at DROP DEFAULT;
+
+INSERT INTO schema_migrations (version) VALUES (42) ON CONFLICT (version) DO NOTHING;
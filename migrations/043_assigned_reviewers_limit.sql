@@ -0,0 +1,9 @@
+-- Teams may assign up to 10 reviewers (team_settings.reviewer_count), the
+-- initial schema allowed only 2.
+ALTER TABLE pull_requests
+    DROP CONSTRAINT IF EXISTS pull_requests_assigned_reviewers_check;
+
+ALTER TABLE pull_requests
+    ADD CONSTRAINT pull_requests_assigned_reviewers_check CHECK (cardinality(assigned_reviewers) <= 10);
+
+INSERT INTO schema_migrations (version) VALUES (43) ON CONFLICT (version) DO NOTHING;
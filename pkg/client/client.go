@@ -0,0 +1,385 @@
+// Package client is a typed Go client for the review assigner HTTP API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"review-assigner/internal/app"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Types shared with the service. They are aliases, so values decoded by the
+// client are the same types the service encodes.
+type (
+	Team             = app.Team
+	TeamMember       = app.TeamMember
+	User             = app.User
+	PullRequest      = app.PullRequest
+	PullRequestShort = app.PullRequestShort
+	ReviewSummary    = app.ReviewSummary
+	PreviousReviewer = app.PreviousReviewer
+	AssignmentStats  = app.AssignmentStats
+	StatsFilter      = app.StatsFilter
+	// Error is an application error returned by the service. Every *APIError
+	// unwraps to one, so it can be matched with errors.As.
+	Error      = app.Error
+	ErrorCode  = app.ErrorCode
+	FieldError = app.FieldError
+)
+
+// Application error codes.
+const (
+	ErrorCodeTeamExists             = app.ErrorCodeTeamExists
+	ErrorCodePRExists               = app.ErrorCodePRExists
+	ErrorCodePRMerged               = app.ErrorCodePRMerged
+	ErrorCodeNotAssigned            = app.ErrorCodeNotAssigned
+	ErrorCodeNoCandidate            = app.ErrorCodeNoCandidate
+	ErrorCodeNotFound               = app.ErrorCodeNotFound
+	ErrorCodeIdempotencyKeyReused   = app.ErrorCodeIdempotencyKeyReused
+	ErrorCodeSecurityReviewRequired = app.ErrorCodeSecurityReviewRequired
+	ErrorCodeNotEnoughReviewers     = app.ErrorCodeNotEnoughReviewers
+	ErrorCodeTeamHasOpenPRs         = app.ErrorCodeTeamHasOpenPRs
+	ErrorCodeUserExists             = app.ErrorCodeUserExists
+	ErrorCodeOrgExists              = app.ErrorCodeOrgExists
+	ErrorCodeNotEnoughApprovals     = app.ErrorCodeNotEnoughApprovals
+	ErrorCodeValidation             = app.ErrorCodeValidation
+	ErrorCodeUserInOtherTeam        = app.ErrorCodeUserInOtherTeam
+	ErrorCodeInternal               = app.ErrorCodeInternal
+	ErrorCodeUnauthorized           = app.ErrorCodeUnauthorized
+	ErrorCodeForbidden              = app.ErrorCodeForbidden
+)
+
+// Client calls the review assigner API. It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	clientID   string
+	apiKey     string
+	token      string
+	retries    int
+	retryWait  time.Duration
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for requests, http.DefaultClient by default.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithClientID sets the X-Client-ID header the service uses for usage analytics.
+func WithClientID(id string) Option {
+	return func(c *Client) {
+		c.clientID = id
+	}
+}
+
+// WithAPIKey authenticates requests with an X-API-Key, for servers with
+// API_KEY_AUTH.
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// WithBearerToken authenticates requests with an OpenID Connect token in
+// the Authorization header, for servers with OIDC_ISSUER_URL.
+func WithBearerToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithRetries retries safe requests up to n times on errors IsRetryable
+// accepts, waiting wait before the first retry and doubling it after each
+// one, or longer when the service asks for it with Retry-After. GET requests
+// and pull request creation with an idempotency key are safe; other requests
+// are never retried.
+func WithRetries(n int, wait time.Duration) Option {
+	return func(c *Client) {
+		c.retries = n
+		c.retryWait = wait
+	}
+}
+
+// New creates a client for the service at baseURL, e.g. "http://localhost:8080".
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// CreateTeam creates a team with its members.
+func (c *Client) CreateTeam(ctx context.Context, team Team) (Team, error) {
+	var resp struct {
+		Team Team `json:"team"`
+	}
+	err := c.do(ctx, request{method: http.MethodPost, path: "/team/add", body: team}, &resp)
+	return resp.Team, err
+}
+
+// GetTeam returns a team with its members.
+func (c *Client) GetTeam(ctx context.Context, name string) (Team, error) {
+	var team Team
+	err := c.do(ctx, request{method: http.MethodGet, path: "/team/get", query: url.Values{"team_name": {name}}}, &team)
+	return team, err
+}
+
+// CreateUser creates a user, optionally attached to an existing team.
+func (c *Client) CreateUser(ctx context.Context, user User) (User, error) {
+	var resp struct {
+		User User `json:"user"`
+	}
+	err := c.do(ctx, request{method: http.MethodPost, path: "/users/create", body: user}, &resp)
+	return resp.User, err
+}
+
+// SetUserIsActive activates or deactivates a user.
+func (c *Client) SetUserIsActive(ctx context.Context, userID string, isActive bool) (User, error) {
+	body := map[string]any{"user_id": userID, "is_active": isActive}
+	var resp struct {
+		User User `json:"user"`
+	}
+	err := c.do(ctx, request{method: http.MethodPost, path: "/users/setIsActive", body: body}, &resp)
+	return resp.User, err
+}
+
+// ListOptions selects a page of a list. A zero Limit returns all rows.
+type ListOptions struct {
+	Limit int
+	// Cursor is the next cursor returned with the previous page.
+	Cursor string
+}
+
+func (o ListOptions) values() url.Values {
+	q := url.Values{}
+	if o.Limit > 0 {
+		q.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Cursor != "" {
+		q.Set("cursor", o.Cursor)
+	}
+	return q
+}
+
+// GetUserReviews returns a page of the user's review queue and the cursor of
+// the next page, empty on the last page.
+func (c *Client) GetUserReviews(ctx context.Context, userID string, opts ListOptions) ([]PullRequestShort, string, error) {
+	q := opts.values()
+	q.Set("user_id", userID)
+	var resp struct {
+		PullRequests []PullRequestShort `json:"pull_requests"`
+		NextCursor   *string            `json:"next_cursor"`
+	}
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/users/getReview", query: q}, &resp); err != nil {
+		return nil, "", err
+	}
+	var next string
+	if resp.NextCursor != nil {
+		next = *resp.NextCursor
+	}
+	return resp.PullRequests, next, nil
+}
+
+// CreatePullRequestInput holds the fields of a new pull request.
+type CreatePullRequestInput struct {
+	ID                  string     `json:"pull_request_id"`
+	Name                string     `json:"pull_request_name"`
+	AuthorID            string     `json:"author_id"`
+	Priority            string     `json:"priority,omitempty"`
+	CoAuthorIDs         []string   `json:"co_author_ids,omitempty"`
+	Labels              []string   `json:"labels,omitempty"`
+	JiraIssues          []string   `json:"jira_issues,omitempty"`
+	GitHubRepo          string     `json:"github_repo,omitempty"`
+	GitHubNumber        int        `json:"github_number,omitempty"`
+	Deadline            *time.Time `json:"deadline,omitempty"`
+	NeedsSecurityReview bool       `json:"needs_security_review,omitempty"`
+	// WaitSeconds makes a strict mode team wait up to this many seconds for
+	// reviewers before the request fails with ErrNotEnoughReviewers.
+	WaitSeconds int `json:"wait_seconds,omitempty"`
+	// IdempotencyKey makes retries return the originally created pull request
+	// and allows the client to retry the request.
+	IdempotencyKey string `json:"-"`
+}
+
+// CreatePullRequest creates a pull request and assigns reviewers.
+func (c *Client) CreatePullRequest(ctx context.Context, in CreatePullRequestInput) (PullRequest, error) {
+	req := request{method: http.MethodPost, path: "/pullRequest/create", body: in}
+	if in.IdempotencyKey != "" {
+		req.header = http.Header{"Idempotency-Key": {in.IdempotencyKey}}
+		req.retry = true
+	}
+	var resp struct {
+		PR PullRequest `json:"pr"`
+	}
+	err := c.do(ctx, req, &resp)
+	return resp.PR, err
+}
+
+// MergePullRequest merges a pull request and returns its review summary.
+func (c *Client) MergePullRequest(ctx context.Context, prID string) (PullRequest, ReviewSummary, error) {
+	var resp struct {
+		PR            PullRequest   `json:"pr"`
+		ReviewSummary ReviewSummary `json:"review_summary"`
+	}
+	body := map[string]any{"pull_request_id": prID}
+	err := c.do(ctx, request{method: http.MethodPost, path: "/pullRequest/merge", body: body}, &resp)
+	return resp.PR, resp.ReviewSummary, err
+}
+
+// ApprovePullRequest records an approval of an assigned reviewer.
+func (c *Client) ApprovePullRequest(ctx context.Context, prID, userID string) (PullRequest, error) {
+	var resp struct {
+		PR PullRequest `json:"pr"`
+	}
+	body := map[string]any{"pull_request_id": prID, "user_id": userID}
+	err := c.do(ctx, request{method: http.MethodPost, path: "/pullRequest/approve", body: body}, &resp)
+	return resp.PR, err
+}
+
+// ReassignResult is the outcome of replacing a reviewer.
+type ReassignResult struct {
+	PR                PullRequest        `json:"pr"`
+	ReplacedBy        string             `json:"replaced_by"`
+	PreviousReviewers []PreviousReviewer `json:"previous_reviewers"`
+}
+
+// Reassign replaces oldUserID on the pull request with another teammate.
+func (c *Client) Reassign(ctx context.Context, prID, oldUserID string) (ReassignResult, error) {
+	var resp ReassignResult
+	body := map[string]any{"pull_request_id": prID, "old_user_id": oldUserID}
+	err := c.do(ctx, request{method: http.MethodPost, path: "/pullRequest/reassign", body: body}, &resp)
+	return resp, err
+}
+
+// GetStats returns assignment statistics matching the filter.
+func (c *Client) GetStats(ctx context.Context, f StatsFilter) (AssignmentStats, error) {
+	q := url.Values{}
+	for name, v := range map[string]string{"org_name": f.OrgName, "team_name": f.TeamName, "status": f.Status} {
+		if v != "" {
+			q.Set(name, v)
+		}
+	}
+	if f.From != nil {
+		q.Set("from", f.From.Format(time.RFC3339))
+	}
+	if f.To != nil {
+		q.Set("to", f.To.Format(time.RFC3339))
+	}
+	var stats AssignmentStats
+	err := c.do(ctx, request{method: http.MethodGet, path: "/stats/assignments", query: q}, &stats)
+	return stats, err
+}
+
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	header http.Header
+	body   any
+	// retry marks a POST request that is safe to repeat.
+	retry bool
+}
+
+// do sends the request, retrying it when allowed, and decodes a successful
+// JSON response into out.
+func (c *Client) do(ctx context.Context, req request, out any) error {
+	var payload []byte
+	if req.body != nil {
+		var err error
+		if payload, err = json.Marshal(req.body); err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+	}
+
+	retries := 0
+	if req.retry || req.method == http.MethodGet {
+		retries = c.retries
+	}
+	wait := c.retryWait
+	for attempt := 0; ; attempt++ {
+		err := c.send(ctx, req, payload, out)
+		if err == nil || attempt >= retries || !IsRetryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		delay := wait
+		if hint := RetryAfter(err); hint > delay {
+			delay = hint
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		wait *= 2
+	}
+}
+
+func (c *Client) send(ctx context.Context, req request, payload []byte, out any) error {
+	u := c.baseURL + req.path
+	if len(req.query) > 0 {
+		u += "?" + req.query.Encode()
+	}
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, u, body)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	for name, values := range req.header {
+		httpReq.Header[name] = values
+	}
+	if payload != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if c.clientID != "" {
+		httpReq.Header.Set("X-Client-ID", c.clientID)
+	}
+	if c.apiKey != "" {
+		httpReq.Header.Set("X-API-Key", c.apiKey)
+	}
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", req.method, req.path, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		return decodeError(resp, data)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sentinel errors, one per error code. Errors returned by the client match
+// the sentinel of their code with errors.Is:
+//
+//	if errors.Is(err, client.ErrNoCandidate) { ... }
+var (
+	ErrTeamExists             = &Error{Code: ErrorCodeTeamExists, Message: "team already exists"}
+	ErrPRExists               = &Error{Code: ErrorCodePRExists, Message: "pull request already exists"}
+	ErrPRMerged               = &Error{Code: ErrorCodePRMerged, Message: "pull request is merged"}
+	ErrNotAssigned            = &Error{Code: ErrorCodeNotAssigned, Message: "reviewer is not assigned"}
+	ErrNoCandidate            = &Error{Code: ErrorCodeNoCandidate, Message: "no replacement candidate"}
+	ErrNotFound               = &Error{Code: ErrorCodeNotFound, Message: "not found"}
+	ErrIdempotencyKeyReused   = &Error{Code: ErrorCodeIdempotencyKeyReused, Message: "idempotency key reused"}
+	ErrSecurityReviewRequired = &Error{Code: ErrorCodeSecurityReviewRequired, Message: "security review required"}
+	ErrNotEnoughReviewers     = &Error{Code: ErrorCodeNotEnoughReviewers, Message: "not enough reviewers"}
+	ErrTeamHasOpenPRs         = &Error{Code: ErrorCodeTeamHasOpenPRs, Message: "team has open pull requests"}
+	ErrUserExists             = &Error{Code: ErrorCodeUserExists, Message: "user already exists"}
+	ErrOrgExists              = &Error{Code: ErrorCodeOrgExists, Message: "organization already exists"}
+	ErrNotEnoughApprovals     = &Error{Code: ErrorCodeNotEnoughApprovals, Message: "not enough approvals"}
+	ErrValidation             = &Error{Code: ErrorCodeValidation, Message: "invalid request"}
+	ErrUserInOtherTeam        = &Error{Code: ErrorCodeUserInOtherTeam, Message: "user is in another team"}
+	ErrInternal               = &Error{Code: ErrorCodeInternal, Message: "internal error"}
+	ErrUnauthorized           = &Error{Code: ErrorCodeUnauthorized, Message: "request is not authenticated"}
+)
+
+// retryableCodes are the error codes of failures that may pass on a retry.
+var retryableCodes = map[ErrorCode]bool{
+	ErrorCodeInternal: true,
+}
+
+// APIError is returned for every error response carrying an application
+// error. It unwraps to the *Error and matches the sentinel of its code.
+type APIError struct {
+	Err        *Error
+	StatusCode int
+	// RequestID identifies the request in the service logs; quote it when
+	// reporting an issue.
+	RequestID string
+	// RetryAfter is the delay the service asked for with Retry-After, if any.
+	RetryAfter time.Duration
+}
+
+// Error returns the code and message of the application error.
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Err.Code, e.Err.Message)
+}
+
+// Unwrap returns the application error.
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is an *Error with the same code, such as one of
+// the sentinel errors.
+func (e *APIError) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Err.Code
+}
+
+// Retryable reports whether repeating the request may succeed.
+func (e *APIError) Retryable() bool {
+	return retryableCodes[e.Err.Code] || retryableStatus(e.StatusCode)
+}
+
+// StatusError is returned for responses that carry no application error,
+// such as 5xx responses of a proxy.
+type StatusError struct {
+	StatusCode int
+	Body       string
+	RequestID  string
+	RetryAfter time.Duration
+}
+
+// Error returns the status and the response body.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d: %s", e.StatusCode, e.Body)
+}
+
+// Retryable reports whether repeating the request may succeed.
+func (e *StatusError) Retryable() bool {
+	return retryableStatus(e.StatusCode)
+}
+
+func retryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// IsRetryable reports whether err is a network error or an error response
+// that may pass on a retry: internal errors, 429 and 5xx responses.
+func IsRetryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable()
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Retryable()
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// RetryAfter returns the delay an error response asked for with
+// Retry-After, or zero.
+func RetryAfter(err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.RetryAfter
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.RetryAfter
+	}
+	return 0
+}
+
+// decodeError maps an error envelope to *APIError and anything else, such
+// as a proxy error page, to *StatusError.
+func decodeError(resp *http.Response, data []byte) error {
+	requestID := resp.Header.Get("X-Request-ID")
+	retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+
+	var body struct {
+		Error struct {
+			Code      ErrorCode    `json:"code"`
+			Message   string       `json:"message"`
+			Fields    []FieldError `json:"fields"`
+			RequestID string       `json:"request_id"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &body); err == nil && body.Error.Code != "" {
+		if body.Error.RequestID != "" {
+			requestID = body.Error.RequestID
+		}
+		return &APIError{
+			Err:        &Error{Code: body.Error.Code, Message: body.Error.Message, Fields: body.Error.Fields},
+			StatusCode: resp.StatusCode,
+			RequestID:  requestID,
+			RetryAfter: retryAfter,
+		}
+	}
+	return &StatusError{
+		StatusCode: resp.StatusCode,
+		Body:       strings.TrimSpace(string(data)),
+		RequestID:  requestID,
+		RetryAfter: retryAfter,
+	}
+}
+
+// parseRetryAfter reads a Retry-After value in seconds or as an HTTP date.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(v); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(v); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,26 @@
+package client
+
+import (
+	"net/http"
+	"time"
+
+	"review-assigner/internal/webhook"
+)
+
+// WebhookTolerance is how far a webhook delivery's timestamp may be from
+// the receiver's clock.
+const WebhookTolerance = webhook.Tolerance
+
+// Errors returned by VerifyWebhook.
+var (
+	ErrWebhookMissingSignature = webhook.ErrMissingSignature
+	ErrWebhookStaleTimestamp   = webhook.ErrStaleTimestamp
+	ErrWebhookBadSignature     = webhook.ErrBadSignature
+)
+
+// VerifyWebhook checks that a webhook delivery received now was signed with
+// the subscription secret within WebhookTolerance. body must be the raw
+// request body; receivers should also drop repeated X-Webhook-ID values.
+func VerifyWebhook(secret string, header http.Header, body []byte) error {
+	return webhook.Verify(secret, header, body, time.Now())
+}